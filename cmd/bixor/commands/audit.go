@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// Verify the audit event hash chain. Returns false if any event was changed, removed or reordered.
+func auditVerify(db *sql.DB) bool {
+	rows, err := db.Query(`
+		SELECT id, actor_id, actor_role, action, target_type, target_id, reason, details, before, after,
+			   ip_address, user_agent, request_id, prev_hash, hash, created_at
+		FROM audit_events
+		ORDER BY id ASC
+	`)
+	if err != nil {
+		logError(fmt.Sprintf("Failed to query audit events: %v", err))
+		return false
+	}
+	defer rows.Close()
+
+	prevHash := ""
+	var head *models.AuditEvent
+	checked, unchained, problems := 0, 0, 0
+	for rows.Next() {
+		var event models.AuditEvent
+		var details, before, after []byte
+		err := rows.Scan(&event.ID, &event.ActorID, &event.ActorRole, &event.Action, &event.TargetType, &event.TargetID,
+			&event.Reason, &details, &before, &after, &event.IPAddress, &event.UserAgent, &event.RequestID,
+			&event.PrevHash, &event.Hash, &event.CreatedAt)
+		if err != nil {
+			logError(fmt.Sprintf("Error scanning audit event: %v", err))
+			return false
+		}
+		event.Details, event.Before, event.After = details, before, after
+
+		// Events recorded before the hash chain existed
+		if event.Hash == nil {
+			if head != nil {
+				logError(fmt.Sprintf("Event %d has no hash but follows chained events", event.ID))
+				problems++
+			} else {
+				unchained++
+			}
+			continue
+		}
+
+		if event.PrevHash == nil || *event.PrevHash != prevHash {
+			logError(fmt.Sprintf("Event %d does not link to the event before it; events were removed or reordered", event.ID))
+			problems++
+		}
+
+		hash, err := models.AuditEventHash(valueOrEmpty(event.PrevHash), &event)
+		if err != nil {
+			logError(fmt.Sprintf("Failed to hash event %d: %v", event.ID, err))
+			problems++
+		} else if hash != *event.Hash {
+			logError(fmt.Sprintf("Event %d (%s) was modified: stored hash does not match its contents", event.ID, event.Action))
+			problems++
+		}
+
+		prevHash = *event.Hash
+		head = &event
+		checked++
+	}
+	if err := rows.Err(); err != nil {
+		logError(fmt.Sprintf("Error reading audit events: %v", err))
+		return false
+	}
+
+	if unchained > 0 {
+		logWarn(fmt.Sprintf("%d events were recorded before the hash chain existed and can not be verified", unchained))
+	}
+	if head != nil {
+		logInfo(fmt.Sprintf("Chain head: event %d, hash %s", head.ID, *head.Hash))
+		logInfo("Keep the head hash outside the database to detect removal of the newest events")
+	}
+
+	if problems > 0 {
+		logError(fmt.Sprintf("Audit chain is broken: %d problems in %d events", problems, checked))
+		return false
+	}
+
+	logSuccess(fmt.Sprintf("Audit chain intact: %d events verified", checked))
+	return true
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit [verify]",
+	Short: "Audit log commands",
+	Long: `Check the append-only audit log of security and staff actions.
+
+- verify: Recompute the hash chain and report changed, removed or reordered events
+
+Examples:
+  bixor audit verify`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Handle shorthand syntax like audit[verify]
+		action := args[0]
+		if strings.Contains(action, "[") && strings.Contains(action, "]") {
+			start := strings.Index(action, "[")
+			end := strings.Index(action, "]")
+			if start != -1 && end != -1 && end > start {
+				action = action[start+1 : end]
+			}
+		}
+
+		switch action {
+		case "verify":
+			db := testConnection()
+			ok := auditVerify(db)
+			db.Close()
+			if !ok {
+				os.Exit(1)
+			}
+		default:
+			logError(fmt.Sprintf("Unknown audit action: %s", action))
+			cmd.Help()
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// List registered API clients
+func clientList(db *sql.DB) {
+	rows, err := db.Query(`
+		SELECT name, COALESCE(description, ''), status, previous_secret_expires_at, rotated_at, created_at
+		FROM api_clients
+		ORDER BY name
+	`)
+	if err != nil {
+		logError(fmt.Sprintf("Failed to query API clients: %v", err))
+		return
+	}
+	defer rows.Close()
+
+	fmt.Printf("\n%s%-20s %-10s %-25s %-20s %-30s%s\n",
+		ColorBlue, "Name", "Status", "Previous Secret Until", "Rotated At", "Description", ColorReset)
+	fmt.Println(strings.Repeat("-", 110))
+
+	count := 0
+	for rows.Next() {
+		var name, description, status string
+		var previousExpiresAt, rotatedAt sql.NullTime
+		var createdAt time.Time
+		if err := rows.Scan(&name, &description, &status, &previousExpiresAt, &rotatedAt, &createdAt); err != nil {
+			logError(fmt.Sprintf("Error scanning row: %v", err))
+			continue
+		}
+
+		previous := "-"
+		if previousExpiresAt.Valid && previousExpiresAt.Time.After(time.Now()) {
+			previous = previousExpiresAt.Time.Format("2006-01-02 15:04:05")
+		}
+		rotated := "-"
+		if rotatedAt.Valid {
+			rotated = rotatedAt.Time.Format("2006-01-02 15:04:05")
+		}
+
+		fmt.Printf("%-20s %-10s %-25s %-20s %-30s\n", name, status, previous, rotated, description)
+		count++
+	}
+
+	if count == 0 {
+		logWarn("No API clients found. Create one with 'bixor client create <name>'.")
+	} else {
+		logInfo(fmt.Sprintf("Found %d API clients", count))
+	}
+}
+
+// Create a new API client and print its secret once
+func clientCreate(db *sql.DB, name, description string) {
+	secret, err := models.GenerateClientSecret()
+	if err != nil {
+		logError(fmt.Sprintf("Failed to generate secret: %v", err))
+		return
+	}
+
+	var descriptionArg interface{}
+	if description != "" {
+		descriptionArg = description
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO api_clients (name, description, secret_hash)
+		VALUES ($1, $2, $3)
+	`, name, descriptionArg, models.HashClientSecret(secret))
+	if err != nil {
+		logError(fmt.Sprintf("Failed to create API client: %v", err))
+		return
+	}
+
+	logSuccess(fmt.Sprintf("API client '%s' created", name))
+	printClientSecret(secret)
+}
+
+// Rotate a client's secret, keeping the old one valid for a grace period
+func clientRotate(db *sql.DB, name string, graceHours int) {
+	secret, err := models.GenerateClientSecret()
+	if err != nil {
+		logError(fmt.Sprintf("Failed to generate secret: %v", err))
+		return
+	}
+
+	previousExpiresAt := time.Now().Add(time.Duration(graceHours) * time.Hour)
+
+	result, err := db.Exec(`
+		UPDATE api_clients
+		SET previous_secret_hash = secret_hash,
+			previous_secret_expires_at = $1,
+			secret_hash = $2,
+			rotated_at = NOW(),
+			updated_at = NOW()
+		WHERE name = $3 AND status = 'active'
+	`, previousExpiresAt, models.HashClientSecret(secret), name)
+	if err != nil {
+		logError(fmt.Sprintf("Failed to rotate secret: %v", err))
+		return
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		logWarn(fmt.Sprintf("No active API client found with name: %s", name))
+		return
+	}
+
+	logSuccess(fmt.Sprintf("Secret for '%s' rotated", name))
+	logInfo(fmt.Sprintf("The previous secret stays valid until %s", previousExpiresAt.Format("2006-01-02 15:04:05")))
+	printClientSecret(secret)
+}
+
+// Revoke a client so none of its secrets are accepted anymore
+func clientRevoke(db *sql.DB, name string) {
+	result, err := db.Exec(`
+		UPDATE api_clients
+		SET status = 'revoked', previous_secret_hash = NULL, previous_secret_expires_at = NULL,
+			revoked_at = NOW(), updated_at = NOW()
+		WHERE name = $1 AND status = 'active'
+	`, name)
+	if err != nil {
+		logError(fmt.Sprintf("Failed to revoke API client: %v", err))
+		return
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		logWarn(fmt.Sprintf("No active API client found with name: %s", name))
+		return
+	}
+
+	logSuccess(fmt.Sprintf("API client '%s' revoked", name))
+	logInfo("Running servers stop accepting its secrets once their client cache refreshes (API_CLIENTS_CACHE_SECONDS)")
+}
+
+func printClientSecret(secret string) {
+	fmt.Println()
+	fmt.Printf("  Secret: %s%s%s\n", ColorYellow, secret, ColorReset)
+	fmt.Println()
+	logWarn("Store this secret now. It is not saved and cannot be shown again.")
+}
+
+// clientCmd represents the client command
+var clientCmd = &cobra.Command{
+	Use:   "client [list|create|rotate|revoke] [name]",
+	Short: "API client management commands",
+	Long: `Manage the API clients (web, mobile, admin panel) allowed to call backend-secret protected routes.
+Each client has its own secret, sent in the X-Backend-Secret header.
+
+- list: List all API clients
+- create <name>: Create a client and print its secret
+- rotate <name>: Issue a new secret; the old one stays valid for --grace-hours
+- revoke <name>: Revoke a client and all of its secrets
+
+Examples:
+  bixor client create web --description "Next.js frontend"
+  bixor client rotate mobile --grace-hours 48
+  bixor client revoke admin-panel`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Handle shorthand syntax like client[list]
+		action := args[0]
+		if strings.Contains(action, "[") && strings.Contains(action, "]") {
+			start := strings.Index(action, "[")
+			end := strings.Index(action, "]")
+			if start != -1 && end != -1 && end > start {
+				action = action[start+1 : end]
+			}
+		}
+
+		name := ""
+		if len(args) > 1 {
+			name = strings.ToLower(strings.TrimSpace(args[1]))
+		}
+
+		switch action {
+		case "list":
+			db := testConnection()
+			defer db.Close()
+			clientList(db)
+		case "create", "rotate", "revoke":
+			if name == "" {
+				logError(fmt.Sprintf("Missing client name. Usage: bixor client %s <name>", action))
+				os.Exit(1)
+			}
+
+			db := testConnection()
+			defer db.Close()
+
+			switch action {
+			case "create":
+				description, _ := cmd.Flags().GetString("description")
+				clientCreate(db, name, description)
+			case "rotate":
+				graceHours, _ := cmd.Flags().GetInt("grace-hours")
+				clientRotate(db, name, graceHours)
+			case "revoke":
+				clientRevoke(db, name)
+			}
+		default:
+			logError(fmt.Sprintf("Unknown client action: %s", action))
+			cmd.Help()
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(clientCmd)
+
+	clientCmd.Flags().StringP("description", "d", "", "Client description (create only)")
+	clientCmd.Flags().Int("grace-hours", 24, "Hours the previous secret stays valid after rotation")
+}
@@ -0,0 +1,48 @@
+-- Create email_changes table to track pending and completed email address changes
+CREATE TABLE IF NOT EXISTS email_changes (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    old_email TEXT NOT NULL,
+    old_email_status BOOLEAN NOT NULL,
+    new_email TEXT NOT NULL,
+    cancel_token_hash TEXT NOT NULL UNIQUE, -- SHA-256 of the token sent to the old address
+    status TEXT NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL, -- Deadline for confirming the new address
+    cancel_until TIMESTAMP WITH TIME ZONE NOT NULL, -- Deadline for the old address to cancel the change
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_email_changes_user_id ON email_changes(user_id);
+CREATE INDEX IF NOT EXISTS idx_email_changes_new_email ON email_changes(LOWER(new_email));
+CREATE INDEX IF NOT EXISTS idx_email_changes_status ON email_changes(status);
+
+-- Add constraint for email change status values
+ALTER TABLE email_changes ADD CONSTRAINT chk_email_changes_status
+CHECK (status IN ('pending', 'confirmed', 'cancelled', 'expired'));
+
+-- Add email format validation for the new address
+ALTER TABLE email_changes ADD CONSTRAINT chk_email_changes_new_email_format
+CHECK (new_email ~* '^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$');
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_email_changes_updated_at
+    BEFORE UPDATE ON email_changes
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Allow the 'email-change' OTP type (code is sent to the new address)
+ALTER TABLE otps DROP CONSTRAINT IF EXISTS chk_otps_type;
+ALTER TABLE otps ADD CONSTRAINT chk_otps_type
+CHECK (type IN ('email-verification', 'password-reset', '2fa', 'phone-verification', 'email-change'));
+
+-- Withdrawals are locked until this time after a sensitive account change
+ALTER TABLE users ADD COLUMN IF NOT EXISTS withdrawals_locked_until TIMESTAMP WITH TIME ZONE;
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('007', 'Create email_changes table and withdrawal lock for email changes', 'migration_007_email_changes')
+ON CONFLICT (version) DO NOTHING;
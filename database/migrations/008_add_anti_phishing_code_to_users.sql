@@ -0,0 +1,8 @@
+-- Add anti-phishing code to users table
+-- Stored encrypted (AES-256-GCM) by the application; shown in every outgoing email
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anti_phishing_code TEXT;
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum) 
+VALUES ('008', 'Add encrypted anti-phishing code to users', 'migration_008_anti_phishing_code')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,36 @@
+-- Create api_clients table: named clients (web, mobile, admin panel) allowed to call protected routes
+CREATE TABLE IF NOT EXISTS api_clients (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT,
+    secret_hash TEXT NOT NULL, -- SHA-256 of the current secret
+    previous_secret_hash TEXT, -- SHA-256 of the secret being rotated out
+    previous_secret_expires_at TIMESTAMP WITH TIME ZONE, -- Previous secret is accepted until this time
+    status TEXT DEFAULT 'active' NOT NULL,
+    rotated_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_api_clients_status ON api_clients(status);
+
+-- Add constraint for client status values
+ALTER TABLE api_clients ADD CONSTRAINT chk_api_clients_status
+CHECK (status IN ('active', 'revoked'));
+
+-- Add constraint for client names (used in logs and CLI)
+ALTER TABLE api_clients ADD CONSTRAINT chk_api_clients_name_format
+CHECK (name ~ '^[a-z0-9][a-z0-9_-]{1,49}$');
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_api_clients_updated_at
+    BEFORE UPDATE ON api_clients
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('009', 'Create api_clients table for per-client backend secrets', 'migration_009_api_clients')
+ON CONFLICT (version) DO NOTHING;
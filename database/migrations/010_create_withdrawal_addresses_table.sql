@@ -0,0 +1,49 @@
+-- Create withdrawal_addresses table (per-user address book per coin)
+CREATE TABLE IF NOT EXISTS withdrawal_addresses (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    coin_id INTEGER NOT NULL REFERENCES coins(id) ON DELETE CASCADE,
+    label VARCHAR(100),
+    address VARCHAR(255) NOT NULL,
+    memo VARCHAR(255), -- Destination tag / memo for coins that need one
+    status TEXT NOT NULL DEFAULT 'pending',
+    confirm_token_hash TEXT NOT NULL UNIQUE, -- SHA-256 of the token sent by email
+    confirm_expires_at TIMESTAMP WITH TIME ZONE NOT NULL, -- Deadline for the email confirmation
+    available_at TIMESTAMP WITH TIME ZONE NOT NULL, -- Entry can not be used for withdrawals before this time
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    removed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_withdrawal_addresses_user_id ON withdrawal_addresses(user_id);
+CREATE INDEX IF NOT EXISTS idx_withdrawal_addresses_coin_id ON withdrawal_addresses(coin_id);
+CREATE INDEX IF NOT EXISTS idx_withdrawal_addresses_status ON withdrawal_addresses(status);
+
+-- An address can only be in a user's book once per coin and memo (removed entries excluded)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_withdrawal_addresses_unique
+ON withdrawal_addresses(user_id, coin_id, address, COALESCE(memo, ''))
+WHERE status != 'removed';
+
+-- Add constraint for withdrawal address status values
+ALTER TABLE withdrawal_addresses ADD CONSTRAINT chk_withdrawal_addresses_status
+CHECK (status IN ('pending', 'active', 'removed'));
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_withdrawal_addresses_updated_at
+    BEFORE UPDATE ON withdrawal_addresses
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Whitelist-only mode: withdrawals are only allowed to available address book entries
+ALTER TABLE users ADD COLUMN IF NOT EXISTS withdrawal_whitelist_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Store the destination of withdrawals
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS address VARCHAR(255);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS memo VARCHAR(255);
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('010', 'Create withdrawal address book and whitelist mode', 'migration_010_withdrawal_addresses')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,98 @@
+-- Create kyc_submissions table for identity data submitted by users
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    status TEXT NOT NULL DEFAULT 'pending',
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    date_of_birth DATE NOT NULL,
+    nationality VARCHAR(2) NOT NULL, -- ISO 3166-1 alpha-2
+    country VARCHAR(2) NOT NULL, -- Country of residence, ISO 3166-1 alpha-2
+    city TEXT NOT NULL,
+    address TEXT NOT NULL,
+    postal_code TEXT,
+    document_type TEXT NOT NULL,
+    document_number TEXT NOT NULL, -- Encrypted with DATA_ENCRYPTION_KEY
+    reviewed_by UUID REFERENCES users(id),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    rejection_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_user_id ON kyc_submissions(user_id);
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_status ON kyc_submissions(status);
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_created_at ON kyc_submissions(created_at);
+
+-- Only one submission per user can wait for review
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kyc_submissions_one_pending
+ON kyc_submissions(user_id) WHERE status = 'pending';
+
+-- Add constraints for enumerated values
+ALTER TABLE kyc_submissions ADD CONSTRAINT chk_kyc_submissions_status
+CHECK (status IN ('pending', 'approved', 'rejected'));
+
+ALTER TABLE kyc_submissions ADD CONSTRAINT chk_kyc_submissions_document_type
+CHECK (document_type IN ('passport', 'national_id', 'driving_license'));
+
+-- Create trigger to automatically update updated_at
+CREATE TRIGGER update_kyc_submissions_updated_at
+    BEFORE UPDATE ON kyc_submissions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Create kyc_documents table for files attached to a submission
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    submission_id UUID NOT NULL REFERENCES kyc_submissions(id),
+    kind TEXT NOT NULL,
+    file_path TEXT NOT NULL, -- Relative to KYC_UPLOAD_DIR
+    file_name TEXT NOT NULL, -- Original name from the upload
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_submission_id ON kyc_documents(submission_id);
+
+ALTER TABLE kyc_documents ADD CONSTRAINT chk_kyc_documents_kind
+CHECK (kind IN ('document_front', 'document_back', 'selfie', 'proof_of_address'));
+
+-- Create kyc_history table, an append-only log of every KYC status change
+CREATE TABLE IF NOT EXISTS kyc_history (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    submission_id UUID NOT NULL REFERENCES kyc_submissions(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    actor_id UUID REFERENCES users(id), -- Reviewer, or the user for submissions
+    action TEXT NOT NULL,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_history_submission_id ON kyc_history(submission_id);
+CREATE INDEX IF NOT EXISTS idx_kyc_history_user_id ON kyc_history(user_id);
+
+ALTER TABLE kyc_history ADD CONSTRAINT chk_kyc_history_action
+CHECK (action IN ('submitted', 'approved', 'rejected'));
+
+-- History rows can never be changed or removed
+CREATE OR REPLACE FUNCTION prevent_kyc_history_modification()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'kyc_history is append-only';
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER kyc_history_immutable
+    BEFORE UPDATE OR DELETE ON kyc_history
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_kyc_history_modification();
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('011', 'Create KYC submissions, documents and history tables', 'migration_011_kyc_tables')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,12 @@
+-- New documents are stored encrypted under a storage key. Documents uploaded
+-- before this migration keep their file_path below KYC_UPLOAD_DIR.
+ALTER TABLE kyc_documents ADD COLUMN IF NOT EXISTS storage_key TEXT;
+ALTER TABLE kyc_documents ALTER COLUMN file_path DROP NOT NULL;
+
+ALTER TABLE kyc_documents ADD CONSTRAINT chk_kyc_documents_location
+CHECK (storage_key IS NOT NULL OR file_path IS NOT NULL);
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('012', 'Add storage keys to KYC documents', 'migration_012_kyc_document_storage_keys')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,65 @@
+-- Create kyc_tiers table with the features unlocked by each KYC status
+CREATE TABLE IF NOT EXISTS kyc_tiers (
+    kyc_status TEXT PRIMARY KEY,
+    can_deposit BOOLEAN NOT NULL DEFAULT TRUE,
+    can_withdraw BOOLEAN NOT NULL DEFAULT TRUE,
+    can_transfer BOOLEAN NOT NULL DEFAULT FALSE,
+    can_trade BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+ALTER TABLE kyc_tiers ADD CONSTRAINT chk_kyc_tiers_kyc_status
+CHECK (kyc_status IN ('not_submitted', 'pending', 'verified', 'rejected'));
+
+-- Create kyc_limits table with deposit and withdrawal caps per KYC status.
+-- Caps are USD values (amount * coins.price) applied to each coin separately.
+-- A row with coin_id NULL is the default for the tier, a row with a coin_id overrides it for that coin.
+-- A NULL cap means unlimited.
+CREATE TABLE IF NOT EXISTS kyc_limits (
+    id SERIAL PRIMARY KEY,
+    kyc_status TEXT NOT NULL REFERENCES kyc_tiers(kyc_status) ON DELETE CASCADE,
+    coin_id INTEGER REFERENCES coins(id) ON DELETE CASCADE,
+    withdraw_daily NUMERIC(20, 2),
+    withdraw_monthly NUMERIC(20, 2),
+    deposit_daily NUMERIC(20, 2),
+    deposit_monthly NUMERIC(20, 2),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kyc_limits_tier_coin ON kyc_limits(kyc_status, COALESCE(coin_id, 0));
+
+-- Usage is summed per user, type and period
+CREATE INDEX IF NOT EXISTS idx_transactions_user_type_created_at ON transactions(user_id, type, created_at);
+
+CREATE TRIGGER update_kyc_tiers_updated_at
+    BEFORE UPDATE ON kyc_tiers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_kyc_limits_updated_at
+    BEFORE UPDATE ON kyc_limits
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Default tiers: unverified users can deposit, trade and withdraw small amounts,
+-- rejected users can only withdraw what they hold, verified users unlock everything
+INSERT INTO kyc_tiers (kyc_status, can_deposit, can_withdraw, can_transfer, can_trade) VALUES
+('not_submitted', TRUE, TRUE, FALSE, TRUE),
+('pending', TRUE, TRUE, FALSE, TRUE),
+('rejected', FALSE, TRUE, FALSE, FALSE),
+('verified', TRUE, TRUE, TRUE, TRUE)
+ON CONFLICT (kyc_status) DO NOTHING;
+
+INSERT INTO kyc_limits (kyc_status, coin_id, withdraw_daily, withdraw_monthly, deposit_daily, deposit_monthly) VALUES
+('not_submitted', NULL, 1000, 5000, 5000, 20000),
+('pending', NULL, 1000, 5000, 5000, 20000),
+('rejected', NULL, 1000, 5000, 0, 0),
+('verified', NULL, 100000, 1000000, NULL, NULL)
+ON CONFLICT DO NOTHING;
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('013', 'Create KYC tiers and limits tables', 'migration_013_kyc_limits_tables')
+ON CONFLICT (version) DO NOTHING;
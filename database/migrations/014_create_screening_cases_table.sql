@@ -0,0 +1,40 @@
+-- Create screening_cases table for sanctions list hits awaiting compliance review
+CREATE TABLE IF NOT EXISTS screening_cases (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subject_type TEXT NOT NULL, -- registration, kyc_submission, withdrawal_address
+    subject_id UUID, -- KYC submission for kyc_submission cases
+    screened_value TEXT NOT NULL, -- Name or address that was screened
+    matches JSONB NOT NULL, -- List entries that matched, best first
+    top_score NUMERIC(5, 4) NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    reviewed_by UUID REFERENCES users(id),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    review_note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_screening_cases_status ON screening_cases(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_screening_cases_subject ON screening_cases(user_id, subject_type, screened_value);
+
+-- Only one open case per screened value
+CREATE UNIQUE INDEX IF NOT EXISTS idx_screening_cases_open
+ON screening_cases(user_id, subject_type, screened_value) WHERE status = 'open';
+
+ALTER TABLE screening_cases ADD CONSTRAINT chk_screening_cases_subject_type
+CHECK (subject_type IN ('registration', 'kyc_submission', 'withdrawal_address'));
+
+-- open: action blocked, cleared: false positive, confirmed: true match, action stays blocked
+ALTER TABLE screening_cases ADD CONSTRAINT chk_screening_cases_status
+CHECK (status IN ('open', 'cleared', 'confirmed'));
+
+CREATE TRIGGER update_screening_cases_updated_at
+    BEFORE UPDATE ON screening_cases
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('014', 'Create screening cases table', 'migration_014_screening_cases_table')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,111 @@
+-- Create login_events table to record sign-ins and the device they came from
+CREATE TABLE IF NOT EXISTS login_events (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_address TEXT,
+    user_agent TEXT,
+    device_hash TEXT NOT NULL, -- SHA-256 of the User-Agent and X-Device-ID headers
+    new_device BOOLEAN NOT NULL DEFAULT FALSE, -- First sign-in from this device, not counting the first device of the account
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_events_user ON login_events(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_events_device ON login_events(user_id, device_hash);
+
+-- Create monitoring_rules table for configurable transaction and login monitoring rules
+CREATE TABLE IF NOT EXISTS monitoring_rules (
+    id SERIAL PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    description TEXT,
+    rule_type TEXT NOT NULL, -- threshold, structuring, velocity, deposit_withdraw, new_device_withdrawal
+    event_type TEXT NOT NULL, -- deposit, withdraw, login
+    threshold_usd NUMERIC(20, 2), -- Amount in USD at the coin's current price
+    window_minutes INTEGER, -- Look-back window
+    min_count INTEGER, -- Number of events in the window that raises an alert
+    ratio NUMERIC(5, 4), -- structuring: band below the threshold, deposit_withdraw: share of deposits withdrawn
+    severity TEXT NOT NULL DEFAULT 'medium',
+    action TEXT NOT NULL DEFAULT 'alert', -- alert, or freeze to also set the account status to frozen
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+ALTER TABLE monitoring_rules ADD CONSTRAINT chk_monitoring_rules_type
+CHECK (rule_type IN ('threshold', 'structuring', 'velocity', 'deposit_withdraw', 'new_device_withdrawal'));
+
+ALTER TABLE monitoring_rules ADD CONSTRAINT chk_monitoring_rules_event_type
+CHECK (event_type IN ('deposit', 'withdraw', 'login'));
+
+ALTER TABLE monitoring_rules ADD CONSTRAINT chk_monitoring_rules_severity
+CHECK (severity IN ('low', 'medium', 'high', 'critical'));
+
+ALTER TABLE monitoring_rules ADD CONSTRAINT chk_monitoring_rules_action
+CHECK (action IN ('alert', 'freeze'));
+
+ALTER TABLE monitoring_rules ADD CONSTRAINT chk_monitoring_rules_params
+CHECK (
+    (threshold_usd IS NULL OR threshold_usd > 0) AND
+    (window_minutes IS NULL OR window_minutes > 0) AND
+    (min_count IS NULL OR min_count > 0) AND
+    (ratio IS NULL OR (ratio > 0 AND ratio <= 1))
+);
+
+CREATE TRIGGER update_monitoring_rules_updated_at
+    BEFORE UPDATE ON monitoring_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Default rules
+INSERT INTO monitoring_rules (code, name, description, rule_type, event_type, threshold_usd, window_minutes, min_count, ratio, severity, action) VALUES
+('large_deposit', 'Large deposit', 'A single deposit of at least the threshold', 'threshold', 'deposit', 10000, NULL, NULL, NULL, 'medium', 'alert'),
+('large_withdrawal', 'Large withdrawal', 'A single withdrawal of at least the threshold', 'threshold', 'withdraw', 10000, NULL, NULL, NULL, 'medium', 'alert'),
+('deposit_structuring', 'Deposit structuring', 'Several deposits just below the threshold within the window', 'structuring', 'deposit', 10000, 1440, 3, 0.1000, 'high', 'alert'),
+('withdrawal_structuring', 'Withdrawal structuring', 'Several withdrawals just below the threshold within the window', 'structuring', 'withdraw', 10000, 1440, 3, 0.1000, 'high', 'alert'),
+('withdrawal_velocity', 'Withdrawal velocity', 'Many withdrawals, or a large total, within the window', 'velocity', 'withdraw', 25000, 60, 5, NULL, 'medium', 'alert'),
+('rapid_deposit_withdraw', 'Rapid deposit and withdrawal', 'Most of the deposits of the window withdrawn again', 'deposit_withdraw', 'withdraw', 1000, 1440, NULL, 0.8000, 'high', 'alert'),
+('new_device_withdrawal', 'Withdrawal from a new device', 'A withdrawal shortly after a sign-in from a new device', 'new_device_withdrawal', 'withdraw', NULL, 1440, NULL, NULL, 'high', 'freeze'),
+('login_new_devices', 'New device sign-ins', 'Sign-ins from several new devices within the window', 'velocity', 'login', NULL, 1440, 3, NULL, 'medium', 'alert')
+ON CONFLICT (code) DO NOTHING;
+
+-- Create monitoring_alerts table for rule hits awaiting compliance review
+CREATE TABLE IF NOT EXISTS monitoring_alerts (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    rule_id INTEGER NOT NULL REFERENCES monitoring_rules(id),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    login_event_id UUID REFERENCES login_events(id) ON DELETE SET NULL,
+    severity TEXT NOT NULL,
+    details JSONB NOT NULL, -- Observed values that triggered the rule
+    account_frozen BOOLEAN NOT NULL DEFAULT FALSE, -- The alert froze the account
+    previous_status TEXT, -- Account status before the freeze, restored when the alert is cleared
+    status TEXT NOT NULL DEFAULT 'open',
+    reviewed_by UUID REFERENCES users(id),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    review_note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_monitoring_alerts_status ON monitoring_alerts(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_monitoring_alerts_user ON monitoring_alerts(user_id, rule_id, created_at);
+
+-- A transaction or login event raises each rule at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_monitoring_alerts_transaction
+ON monitoring_alerts(rule_id, transaction_id) WHERE transaction_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_monitoring_alerts_login_event
+ON monitoring_alerts(rule_id, login_event_id) WHERE login_event_id IS NOT NULL;
+
+-- open: awaiting review, cleared: no concern, confirmed: suspicious activity confirmed
+ALTER TABLE monitoring_alerts ADD CONSTRAINT chk_monitoring_alerts_status
+CHECK (status IN ('open', 'cleared', 'confirmed'));
+
+CREATE TRIGGER update_monitoring_alerts_updated_at
+    BEFORE UPDATE ON monitoring_alerts
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('015', 'Create transaction monitoring tables', 'migration_015_transaction_monitoring_tables')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,65 @@
+-- Add short human-friendly referral codes to users
+ALTER TABLE users ADD COLUMN IF NOT EXISTS referral_code TEXT;
+
+-- Generate an unused code: 8 characters without the ambiguous 0, O, 1 and I
+CREATE OR REPLACE FUNCTION generate_referral_code()
+RETURNS TEXT AS $$
+DECLARE
+    alphabet CONSTANT TEXT := '23456789ABCDEFGHJKLMNPQRSTUVWXYZ';
+    code TEXT;
+BEGIN
+    LOOP
+        code := '';
+        FOR i IN 1..8 LOOP
+            code := code || substr(alphabet, 1 + floor(random() * length(alphabet))::INTEGER, 1);
+        END LOOP;
+        EXIT WHEN NOT EXISTS (SELECT 1 FROM users WHERE referral_code = code);
+    END LOOP;
+    RETURN code;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE users SET referral_code = generate_referral_code() WHERE referral_code IS NULL;
+
+ALTER TABLE users ALTER COLUMN referral_code SET DEFAULT generate_referral_code();
+ALTER TABLE users ALTER COLUMN referral_code SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_referral_code ON users(referral_code);
+
+-- Referral commissions are credited to the referrer's wallet as ledger transactions
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS chk_transactions_type;
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_type
+CHECK (type IN ('deposit', 'withdraw', 'transfer', 'referral'));
+
+-- Create referral_commissions table: one row per fee of a referee that earned the referrer a commission
+CREATE TABLE IF NOT EXISTS referral_commissions (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    referrer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    referee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    coin_id INTEGER NOT NULL REFERENCES coins(id),
+    source_type TEXT NOT NULL, -- trade
+    source_id UUID NOT NULL, -- Trade the fee was charged on
+    volume_usd NUMERIC(20, 2) NOT NULL DEFAULT 0, -- Referee's traded value in USD
+    fee_amount NUMERIC(20, 8) NOT NULL, -- Fee the referee paid, in coin units
+    rate NUMERIC(5, 4) NOT NULL, -- Share of the fee paid out
+    commission_amount NUMERIC(20, 8) NOT NULL, -- Credited to the referrer, in coin units
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL, -- Ledger entry of the credit
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_referral_commissions_referrer ON referral_commissions(referrer_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_referral_commissions_referee ON referral_commissions(referee_id);
+
+-- A fee is paid out at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_referral_commissions_source
+ON referral_commissions(source_type, source_id, referee_id);
+
+ALTER TABLE referral_commissions ADD CONSTRAINT chk_referral_commissions_source_type
+CHECK (source_type IN ('trade'));
+
+ALTER TABLE referral_commissions ADD CONSTRAINT chk_referral_commissions_amounts
+CHECK (fee_amount >= 0 AND commission_amount >= 0 AND rate >= 0 AND rate <= 1);
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('016', 'Create referral tables', 'migration_016_referral_tables')
+ON CONFLICT (version) DO NOTHING;
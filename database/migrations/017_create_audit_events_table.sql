@@ -0,0 +1,26 @@
+-- Set by an administrator's forced password reset; login is refused until the password is reset
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create audit_events table for staff actions and other security relevant changes
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL for system actions
+    actor_role TEXT,
+    action TEXT NOT NULL, -- e.g. user.suspend, user.password_reset
+    target_type TEXT NOT NULL, -- e.g. user
+    target_id TEXT NOT NULL,
+    reason TEXT,
+    details JSONB,
+    ip_address TEXT,
+    user_agent TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('017', 'Create audit events table', 'migration_017_audit_events_table')
+ON CONFLICT (version) DO NOTHING;
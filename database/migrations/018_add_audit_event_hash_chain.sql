@@ -0,0 +1,35 @@
+-- Before/after diff, request ID and hash chain for audit events
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS before JSONB;
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS after JSONB;
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS request_id TEXT;
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS prev_hash TEXT; -- Hash of the previous event, empty for the first
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS hash TEXT; -- NULL only for events recorded before the chain existed
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_request_id ON audit_events(request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, created_at);
+
+-- The actor is part of the hashed event, so deleting a user must not rewrite it
+ALTER TABLE audit_events DROP CONSTRAINT IF EXISTS audit_events_actor_id_fkey;
+
+-- Audit events are append-only
+CREATE OR REPLACE FUNCTION prevent_audit_event_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_audit_event_changes();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION prevent_audit_event_changes();
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('018', 'Add audit event hash chain', 'migration_018_audit_event_hash_chain')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,45 @@
+-- Set when the personal data of a closed account (deleted_at) has been anonymized
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_pending_anonymization ON users(deleted_at)
+WHERE deleted_at IS NOT NULL AND anonymized_at IS NULL;
+
+-- Create data_exports table for archives of a user's personal data
+CREATE TABLE IF NOT EXISTS data_exports (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    format TEXT NOT NULL DEFAULT 'json', -- File format inside the zip archive
+    status TEXT NOT NULL DEFAULT 'pending',
+    storage_key TEXT, -- Encrypted archive, set once ready
+    size_bytes BIGINT,
+    sha256 TEXT,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE, -- The archive is deleted after this time
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON data_exports(status, created_at);
+
+-- Only one export per user can be in progress
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_exports_in_progress
+ON data_exports(user_id) WHERE status IN ('pending', 'processing');
+
+ALTER TABLE data_exports ADD CONSTRAINT chk_data_exports_format
+CHECK (format IN ('json', 'csv'));
+
+ALTER TABLE data_exports ADD CONSTRAINT chk_data_exports_status
+CHECK (status IN ('pending', 'processing', 'ready', 'failed', 'expired'));
+
+CREATE TRIGGER update_data_exports_updated_at
+    BEFORE UPDATE ON data_exports
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('019', 'Create data exports table and account anonymization', 'migration_019_data_exports_table')
+ON CONFLICT (version) DO NOTHING;
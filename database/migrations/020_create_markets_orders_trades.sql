@@ -0,0 +1,119 @@
+-- Create markets table: spot trading pairs of two coins
+CREATE TABLE IF NOT EXISTS markets (
+    id SERIAL PRIMARY KEY,
+    symbol TEXT NOT NULL UNIQUE, -- e.g. BTC-USDT
+    base_coin_id INTEGER NOT NULL REFERENCES coins(id),
+    quote_coin_id INTEGER NOT NULL REFERENCES coins(id),
+    price_precision INTEGER NOT NULL DEFAULT 2, -- Decimal places of prices (tick size 10^-n)
+    quantity_precision INTEGER NOT NULL DEFAULT 6, -- Decimal places of base quantities
+    min_quantity NUMERIC(20, 8) NOT NULL DEFAULT 0,
+    max_quantity NUMERIC(20, 8),
+    min_notional NUMERIC(20, 8) NOT NULL DEFAULT 0, -- Minimum order value in the quote coin
+    maker_fee_rate NUMERIC(10, 6) NOT NULL DEFAULT 0.001,
+    taker_fee_rate NUMERIC(10, 6) NOT NULL DEFAULT 0.001,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE(base_coin_id, quote_coin_id)
+);
+
+-- price * quantity must fit the 8 decimal places of wallet balances
+ALTER TABLE markets ADD CONSTRAINT chk_markets_precision
+CHECK (price_precision >= 0 AND quantity_precision >= 0 AND price_precision + quantity_precision <= 8);
+
+ALTER TABLE markets ADD CONSTRAINT chk_markets_coins
+CHECK (base_coin_id <> quote_coin_id);
+
+ALTER TABLE markets ADD CONSTRAINT chk_markets_fee_rates
+CHECK (maker_fee_rate >= 0 AND maker_fee_rate < 1 AND taker_fee_rate >= 0 AND taker_fee_rate < 1);
+
+CREATE TRIGGER update_markets_updated_at
+    BEFORE UPDATE ON markets
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Create orders table
+CREATE TABLE IF NOT EXISTS orders (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    market_id INTEGER NOT NULL REFERENCES markets(id),
+    side TEXT NOT NULL,
+    type TEXT NOT NULL,
+    price NUMERIC(20, 8), -- Limit price, NULL for market orders
+    quantity NUMERIC(20, 8), -- Base quantity, NULL for market buys sized by quote_quantity
+    quote_quantity NUMERIC(20, 8), -- Quote amount to spend, market buys only
+    filled_quantity NUMERIC(20, 8) NOT NULL DEFAULT 0,
+    filled_quote NUMERIC(20, 8) NOT NULL DEFAULT 0,
+    fee NUMERIC(20, 8) NOT NULL DEFAULT 0, -- Paid in the received coin
+    reserved NUMERIC(20, 8) NOT NULL DEFAULT 0, -- Still held in frozen_balance for this order
+    status TEXT NOT NULL DEFAULT 'new',
+    client_order_id TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id, created_at);
+
+-- Open orders are loaded into the in-memory order book on startup
+CREATE INDEX IF NOT EXISTS idx_orders_open ON orders(market_id, created_at)
+WHERE status IN ('new', 'partially_filled');
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_client_order_id
+ON orders(user_id, client_order_id) WHERE client_order_id IS NOT NULL;
+
+ALTER TABLE orders ADD CONSTRAINT chk_orders_side
+CHECK (side IN ('buy', 'sell'));
+
+ALTER TABLE orders ADD CONSTRAINT chk_orders_type
+CHECK (type IN ('limit', 'market'));
+
+ALTER TABLE orders ADD CONSTRAINT chk_orders_status
+CHECK (status IN ('new', 'partially_filled', 'filled', 'cancelled', 'rejected'));
+
+ALTER TABLE orders ADD CONSTRAINT chk_orders_amounts
+CHECK (filled_quantity >= 0 AND filled_quote >= 0 AND fee >= 0 AND reserved >= 0
+    AND (price IS NOT NULL OR type = 'market')
+    AND (quantity IS NOT NULL OR quote_quantity IS NOT NULL));
+
+CREATE TRIGGER update_orders_updated_at
+    BEFORE UPDATE ON orders
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Create trades table: one row per execution between a taker and a maker order
+CREATE TABLE IF NOT EXISTS trades (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    market_id INTEGER NOT NULL REFERENCES markets(id),
+    buy_order_id UUID NOT NULL REFERENCES orders(id),
+    sell_order_id UUID NOT NULL REFERENCES orders(id),
+    buyer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seller_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    price NUMERIC(20, 8) NOT NULL,
+    quantity NUMERIC(20, 8) NOT NULL,
+    quote_quantity NUMERIC(20, 8) NOT NULL,
+    taker_side TEXT NOT NULL,
+    buyer_fee NUMERIC(20, 8) NOT NULL DEFAULT 0, -- In the base coin
+    seller_fee NUMERIC(20, 8) NOT NULL DEFAULT 0, -- In the quote coin
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_trades_market ON trades(market_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_trades_buyer ON trades(buyer_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_trades_seller ON trades(seller_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_trades_buy_order ON trades(buy_order_id);
+CREATE INDEX IF NOT EXISTS idx_trades_sell_order ON trades(sell_order_id);
+
+ALTER TABLE trades ADD CONSTRAINT chk_trades_taker_side
+CHECK (taker_side IN ('buy', 'sell'));
+
+-- Seed markets against USDT for the seeded coins
+INSERT INTO markets (symbol, base_coin_id, quote_coin_id, price_precision, quantity_precision, min_quantity, min_notional)
+SELECT b.ticker || '-' || q.ticker, b.id, q.id, 2, 6, 0.000001, 5
+FROM coins b, coins q
+WHERE b.ticker IN ('BTC', 'ETH', 'BNB', 'SOL') AND q.ticker = 'USDT'
+ON CONFLICT DO NOTHING;
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('020', 'Create markets, orders and trades tables', 'migration_020_markets_orders_trades')
+ON CONFLICT (version) DO NOTHING;
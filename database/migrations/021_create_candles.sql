@@ -0,0 +1,41 @@
+-- 1m candles are written with every trade; higher timeframes are rebuilt from the next lower
+-- one by the candle aggregator. Timeframes without trades have no row.
+CREATE TABLE IF NOT EXISTS candles (
+    market_id INTEGER NOT NULL REFERENCES markets(id) ON DELETE CASCADE,
+    timeframe TEXT NOT NULL,
+    open_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    open NUMERIC(20, 8) NOT NULL,
+    high NUMERIC(20, 8) NOT NULL,
+    low NUMERIC(20, 8) NOT NULL,
+    close NUMERIC(20, 8) NOT NULL,
+    volume NUMERIC(30, 8) NOT NULL DEFAULT 0, -- In the base coin
+    quote_volume NUMERIC(30, 8) NOT NULL DEFAULT 0, -- In the quote coin
+    trade_count INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (market_id, timeframe, open_time)
+);
+
+-- The aggregator looks up the candles changed since its last run
+CREATE INDEX IF NOT EXISTS idx_candles_updated ON candles(timeframe, updated_at);
+
+ALTER TABLE candles ADD CONSTRAINT chk_candles_timeframe
+CHECK (timeframe IN ('1m', '5m', '15m', '1h', '4h', '1d', '1w'));
+
+ALTER TABLE candles ADD CONSTRAINT chk_candles_prices
+CHECK (low <= open AND low <= close AND high >= open AND high >= close AND volume >= 0 AND quote_volume >= 0);
+
+-- Backfill 1m candles from the trades executed so far
+INSERT INTO candles (market_id, timeframe, open_time, open, high, low, close, volume, quote_volume, trade_count)
+SELECT market_id, '1m', date_trunc('minute', created_at),
+    (array_agg(price ORDER BY created_at, id))[1],
+    MAX(price), MIN(price),
+    (array_agg(price ORDER BY created_at DESC, id DESC))[1],
+    SUM(quantity), SUM(quote_quantity), COUNT(*)
+FROM trades
+GROUP BY market_id, date_trunc('minute', created_at)
+ON CONFLICT DO NOTHING;
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('021', 'Create candles table', 'migration_021_candles')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,18 @@
+-- Create price_history table: every price the oracle wrote to coins.price with the quotes it was made of
+CREATE TABLE IF NOT EXISTS price_history (
+    id BIGSERIAL PRIMARY KEY,
+    coin_id INTEGER NOT NULL REFERENCES coins(id) ON DELETE CASCADE,
+    price NUMERIC(20, 8) NOT NULL,
+    quotes JSONB NOT NULL DEFAULT '[]', -- [{source, price, rejected}]
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_coin ON price_history(coin_id, created_at);
+
+ALTER TABLE price_history ADD CONSTRAINT chk_price_history_price
+CHECK (price > 0);
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('022', 'Create price history table', 'migration_022_price_history')
+ON CONFLICT (version) DO NOTHING;
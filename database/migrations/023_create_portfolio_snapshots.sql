@@ -0,0 +1,26 @@
+-- users.global_balance is the valuation of all wallets, refreshed by the portfolio job when
+-- balances or prices change. That refresh is not a change to the user, so it leaves updated_at alone.
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+CREATE TRIGGER update_users_updated_at
+    BEFORE UPDATE ON users
+    FOR EACH ROW
+    WHEN (OLD.global_balance IS NOT DISTINCT FROM NEW.global_balance)
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Create portfolio_snapshots table: the valuation of each user's wallets at the start of every day (UTC)
+CREATE TABLE IF NOT EXISTS portfolio_snapshots (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    snapshot_date DATE NOT NULL,
+    quote_currency TEXT NOT NULL, -- USD or a coin ticker
+    total_value NUMERIC(20, 2) NOT NULL,
+    assets JSONB NOT NULL DEFAULT '[]', -- [{ticker, quantity, value}]
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (user_id, snapshot_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_snapshots_date ON portfolio_snapshots(snapshot_date);
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('023', 'Create portfolio snapshots table', 'migration_023_portfolio_snapshots')
+ON CONFLICT (version) DO NOTHING;
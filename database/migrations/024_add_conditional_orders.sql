@@ -0,0 +1,34 @@
+-- Conditional orders: stop and take-profit orders wait with status 'untriggered' (funds already
+-- frozen) until the reference price reaches stop_price, then run as a limit or market order
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS stop_price NUMERIC(20, 8);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS trigger_by TEXT; -- last_price, mark_price
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS triggered_at TIMESTAMP WITH TIME ZONE;
+-- The other leg of a one-cancels-other pair
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS linked_order_id UUID REFERENCES orders(id);
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_type;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_type
+CHECK (type IN ('limit', 'market', 'stop_market', 'stop_limit', 'take_profit', 'take_profit_limit'));
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_status;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_status
+CHECK (status IN ('untriggered', 'new', 'partially_filled', 'filled', 'cancelled', 'rejected'));
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_amounts;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_amounts
+CHECK (filled_quantity >= 0 AND filled_quote >= 0 AND fee >= 0 AND reserved >= 0
+    AND (price IS NOT NULL OR type IN ('market', 'stop_market', 'take_profit'))
+    AND (quantity IS NOT NULL OR quote_quantity IS NOT NULL));
+
+ALTER TABLE orders ADD CONSTRAINT chk_orders_trigger
+CHECK ((type IN ('limit', 'market')) = (stop_price IS NULL)
+    AND (trigger_by IS NULL OR trigger_by IN ('last_price', 'mark_price')));
+
+-- Waiting conditional orders are loaded into the trigger books on startup
+CREATE INDEX IF NOT EXISTS idx_orders_untriggered ON orders(market_id, created_at)
+WHERE status = 'untriggered';
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('024', 'Add stop, take-profit and OCO orders', 'migration_024_conditional_orders')
+ON CONFLICT (version) DO NOTHING;
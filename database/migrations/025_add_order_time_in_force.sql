@@ -0,0 +1,31 @@
+-- Time in force: GTC rests until cancelled, IOC cancels what does not fill at once, FOK fills
+-- completely or is rejected, GTD rests until expire_at
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS time_in_force TEXT NOT NULL DEFAULT 'GTC';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS expire_at TIMESTAMP WITH TIME ZONE;
+-- Post-only orders never take liquidity; reject or reprice them when they would
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS post_only BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS post_only_mode TEXT;
+-- Reason code of orders rejected after they were recorded
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS reject_reason TEXT;
+
+-- Market orders never rested
+UPDATE orders SET time_in_force = 'IOC' WHERE type IN ('market', 'stop_market', 'take_profit');
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_status;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_status
+CHECK (status IN ('untriggered', 'new', 'partially_filled', 'filled', 'cancelled', 'expired', 'rejected'));
+
+ALTER TABLE orders ADD CONSTRAINT chk_orders_time_in_force
+CHECK (time_in_force IN ('GTC', 'IOC', 'FOK', 'GTD')
+    AND (time_in_force = 'GTD') = (expire_at IS NOT NULL)
+    AND post_only = (post_only_mode IS NOT NULL)
+    AND (post_only_mode IS NULL OR post_only_mode IN ('reject', 'reprice')));
+
+-- Open GTD orders for the expiry sweep
+CREATE INDEX IF NOT EXISTS idx_orders_expire_at ON orders(expire_at)
+WHERE expire_at IS NOT NULL AND status IN ('untriggered', 'new', 'partially_filled');
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('025', 'Add time in force and post-only orders', 'migration_025_time_in_force')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,26 @@
+-- Self-trade prevention: what the matching engine does when an order would match a resting
+-- order of the same user. Resolved when the order is placed, from the order or the account setting.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS self_trade_prevention TEXT NOT NULL DEFAULT 'none';
+
+ALTER TABLE orders ADD CONSTRAINT chk_orders_self_trade_prevention
+CHECK (self_trade_prevention IN ('none', 'cancel_newest', 'cancel_oldest', 'cancel_both', 'decrement_and_cancel'));
+
+-- Create user_trading_settings table: account defaults for orders
+CREATE TABLE IF NOT EXISTS user_trading_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    self_trade_prevention TEXT NOT NULL DEFAULT 'none',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    CONSTRAINT chk_user_trading_settings_self_trade_prevention
+        CHECK (self_trade_prevention IN ('none', 'cancel_newest', 'cancel_oldest', 'cancel_both', 'decrement_and_cancel'))
+);
+
+CREATE TRIGGER update_user_trading_settings_updated_at
+    BEFORE UPDATE ON user_trading_settings
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('026', 'Add self-trade prevention', 'migration_026_self_trade_prevention')
+ON CONFLICT (version) DO NOTHING;
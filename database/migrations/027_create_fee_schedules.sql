@@ -0,0 +1,138 @@
+-- Trading fees: a maker can be paid a rebate (negative rate) no larger than the taker rate of
+-- the same schedule. Maker and taker may pay rates of different tiers or overrides, so settlement
+-- also caps each rebate at the taker's fee on the fill.
+ALTER TABLE markets DROP CONSTRAINT IF EXISTS chk_markets_fee_rates;
+ALTER TABLE markets ADD CONSTRAINT chk_markets_fee_rates
+CHECK (maker_fee_rate > -1 AND maker_fee_rate < 1 AND taker_fee_rate >= 0 AND taker_fee_rate < 1
+    AND maker_fee_rate + taker_fee_rate >= 0);
+
+-- An order's fee is negative when its maker rebates exceed what it paid as a taker
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_amounts;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_amounts
+CHECK (filled_quantity >= 0 AND filled_quote >= 0 AND reserved >= 0
+    AND (price IS NOT NULL OR type IN ('market', 'stop_market', 'take_profit'))
+    AND (quantity IS NOT NULL OR quote_quantity IS NOT NULL));
+
+-- Create fee_tiers table: VIP tiers reached by rolling 30-day trading volume
+CREATE TABLE IF NOT EXISTS fee_tiers (
+    tier INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    min_volume_usd NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    CONSTRAINT chk_fee_tiers_values CHECK (tier >= 0 AND min_volume_usd >= 0 AND (tier > 0 OR min_volume_usd = 0))
+);
+
+CREATE TRIGGER update_fee_tiers_updated_at
+    BEFORE UPDATE ON fee_tiers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Create fee_schedules table: maker and taker rates per market and tier. A user pays the
+-- rates of the highest scheduled tier at or below their own, else the market's rates.
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    market_id INTEGER NOT NULL REFERENCES markets(id) ON DELETE CASCADE,
+    tier INTEGER NOT NULL REFERENCES fee_tiers(tier) ON DELETE CASCADE,
+    maker_fee_rate NUMERIC(10, 6) NOT NULL,
+    taker_fee_rate NUMERIC(10, 6) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (market_id, tier),
+    CONSTRAINT chk_fee_schedules_rates CHECK (maker_fee_rate > -1 AND maker_fee_rate < 1
+        AND taker_fee_rate >= 0 AND taker_fee_rate < 1 AND maker_fee_rate + taker_fee_rate >= 0)
+);
+
+CREATE TRIGGER update_fee_schedules_updated_at
+    BEFORE UPDATE ON fee_schedules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Create user_fee_overrides table: rates set by an admin for one user, on one market or on all
+-- markets (market_id NULL). A market override wins over an all-markets one.
+CREATE TABLE IF NOT EXISTS user_fee_overrides (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    market_id INTEGER REFERENCES markets(id) ON DELETE CASCADE,
+    maker_fee_rate NUMERIC(10, 6) NOT NULL,
+    taker_fee_rate NUMERIC(10, 6) NOT NULL,
+    reason TEXT NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    CONSTRAINT chk_user_fee_overrides_rates CHECK (maker_fee_rate > -1 AND maker_fee_rate < 1
+        AND taker_fee_rate >= 0 AND taker_fee_rate < 1 AND maker_fee_rate + taker_fee_rate >= 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_fee_overrides_user_market
+ON user_fee_overrides(user_id, COALESCE(market_id, 0));
+
+CREATE TRIGGER update_user_fee_overrides_updated_at
+    BEFORE UPDATE ON user_fee_overrides
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Create user_fee_tiers table: each user's 30-day volume and tier, refreshed by the fee tier job.
+-- Users without a row are in tier 0.
+CREATE TABLE IF NOT EXISTS user_fee_tiers (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    volume_30d_usd NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    tier INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Create fee_revenue table: trading fees collected minus maker rebates paid, per coin
+CREATE TABLE IF NOT EXISTS fee_revenue (
+    coin_id INTEGER PRIMARY KEY REFERENCES coins(id),
+    balance NUMERIC(20, 8) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Create fee_revenue_entries table: one signed entry per fee or rebate of a trade
+CREATE TABLE IF NOT EXISTS fee_revenue_entries (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    coin_id INTEGER NOT NULL REFERENCES coins(id),
+    trade_id UUID NOT NULL REFERENCES trades(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    entry_type TEXT NOT NULL,
+    amount NUMERIC(20, 8) NOT NULL, -- Positive for fees, negative for rebates
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    CONSTRAINT chk_fee_revenue_entries_type CHECK (entry_type IN ('trade_fee', 'maker_rebate'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_fee_revenue_entries_coin ON fee_revenue_entries(coin_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_fee_revenue_entries_trade ON fee_revenue_entries(trade_id);
+
+-- Trades record the rates each side paid and their USD value, which drives the tiers
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS buyer_fee_rate NUMERIC(10, 6);
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS seller_fee_rate NUMERIC(10, 6);
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS volume_usd NUMERIC(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE trades t SET volume_usd = ROUND(t.quote_quantity * c.price, 2)
+FROM markets m JOIN coins c ON c.id = m.quote_coin_id
+WHERE m.id = t.market_id AND t.volume_usd = 0;
+
+CREATE INDEX IF NOT EXISTS idx_trades_created_at ON trades(created_at);
+
+-- Seed the tiers and a schedule for every market
+INSERT INTO fee_tiers (tier, name, min_volume_usd) VALUES
+    (0, 'Regular', 0),
+    (1, 'VIP 1', 100000),
+    (2, 'VIP 2', 1000000),
+    (3, 'VIP 3', 10000000)
+ON CONFLICT (tier) DO NOTHING;
+
+INSERT INTO fee_schedules (market_id, tier, maker_fee_rate, taker_fee_rate)
+SELECT m.id, s.tier, s.maker_fee_rate, s.taker_fee_rate
+FROM markets m, (VALUES
+    (0, 0.001, 0.001),
+    (1, 0.0008, 0.001),
+    (2, 0.0005, 0.0008),
+    (3, -0.0001, 0.0006)
+) AS s(tier, maker_fee_rate, taker_fee_rate)
+ON CONFLICT DO NOTHING;
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('027', 'Create fee schedules, tiers and fee revenue', 'migration_027_fee_schedules')
+ON CONFLICT (version) DO NOTHING;
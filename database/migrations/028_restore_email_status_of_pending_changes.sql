@@ -0,0 +1,12 @@
+-- Starting an email change no longer resets email_status. Restore it for accounts whose
+-- change was never confirmed; the pending row holds the status from before the first request.
+UPDATE users u
+SET email_status = ec.old_email_status, updated_at = NOW()
+FROM email_changes ec
+WHERE ec.user_id = u.id AND ec.status = 'pending' AND u.email = ec.old_email
+    AND ec.old_email_status = TRUE AND u.email_status = FALSE;
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('028', 'Restore email status of unconfirmed email changes', 'migration_028_restore_email_status')
+ON CONFLICT (version) DO NOTHING;
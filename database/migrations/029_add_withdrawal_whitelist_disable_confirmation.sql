@@ -0,0 +1,11 @@
+-- Turning whitelist-only withdrawals off waits for a confirmation link sent by email
+ALTER TABLE users ADD COLUMN IF NOT EXISTS withdrawal_whitelist_disable_token_hash TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS withdrawal_whitelist_disable_expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_withdrawal_whitelist_disable_token
+ON users(withdrawal_whitelist_disable_token_hash) WHERE withdrawal_whitelist_disable_token_hash IS NOT NULL;
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('029', 'Add withdrawal whitelist disable confirmation', 'migration_029_withdrawal_whitelist_disable')
+ON CONFLICT (version) DO NOTHING;
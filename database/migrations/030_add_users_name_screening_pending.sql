@@ -0,0 +1,8 @@
+-- Names waiting for sanctions screening: set on registration and name changes, cleared once the
+-- name was screened. Login screens the name again and stays blocked until screening completes.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS name_screening_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('030', 'Add users name screening pending flag', 'migration_030_name_screening_pending')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,27 @@
+-- Referral commissions on trading fees are paid out of the fee revenue account
+ALTER TABLE fee_revenue_entries DROP CONSTRAINT IF EXISTS chk_fee_revenue_entries_type;
+ALTER TABLE fee_revenue_entries ADD CONSTRAINT chk_fee_revenue_entries_type
+CHECK (entry_type IN ('trade_fee', 'maker_rebate', 'referral_commission'));
+
+-- Debit the commissions paid before this migration
+WITH posted AS (
+    INSERT INTO fee_revenue_entries (coin_id, trade_id, user_id, entry_type, amount, created_at)
+    SELECT rc.coin_id, rc.source_id, rc.referrer_id, 'referral_commission', -rc.commission_amount, rc.created_at
+    FROM referral_commissions rc
+    JOIN trades t ON t.id = rc.source_id
+    WHERE rc.source_type = 'trade' AND rc.commission_amount > 0
+      AND NOT EXISTS (
+        SELECT 1 FROM fee_revenue_entries e
+        WHERE e.trade_id = rc.source_id AND e.user_id = rc.referrer_id AND e.coin_id = rc.coin_id
+          AND e.entry_type = 'referral_commission'
+      )
+    RETURNING coin_id, amount
+)
+INSERT INTO fee_revenue (coin_id, balance)
+SELECT coin_id, SUM(amount) FROM posted GROUP BY coin_id
+ON CONFLICT (coin_id) DO UPDATE SET balance = fee_revenue.balance + EXCLUDED.balance, updated_at = NOW();
+
+-- Record this migration
+INSERT INTO schema_migrations (version, description, checksum)
+VALUES ('031', 'Add referral commission fee revenue entries', 'migration_031_referral_commission_fee_entries')
+ON CONFLICT (version) DO NOTHING;
@@ -462,24 +462,8 @@ export class AuthService {
       headers.set('Authorization', `Bearer ${currentToken}`);
     }
 
-    // Add backend secret if needed (though Next.js API routes usually handle this if proxying, 
-    // but here we are calling internal API routes directly or via generic proxy).
-    // The previous implementation assumes direct calls to /api/... which matches the backend routes.
-    // If the frontend is hitting the backend directly, we rely on the browser not needing the secret
-    // OR we need to add it. The existing code suggests we hit '/api/...' which might be rewritten.
-    // However, looking at 'routes.go', it expects 'X-Backend-Secret'.
-    // In `AuthService`, we don't see X-Backend-Secret being added in `getDefaultHeaders`.
-    // Wait, `API_PROTECTION.md` says:
-    // "Usage from Frontend: headers: { 'X-Backend-Secret': process.env.NEXT_PUBLIC_BACKEND_SECRET, ... }"
-    // So we SHOULD add it.
-
-    // So we SHOULD add it.
-
-    // Fallback to 'test123' if env var is missing (e.g., server not restarted)
-    const backendSecret = process.env.NEXT_PUBLIC_BACKEND_SECRET || 'test123';
-    if (backendSecret) {
-      headers.set('X-Backend-Secret', backendSecret);
-    }
+    // The backend secret is added server-side by middleware.ts when /api/v1 requests
+    // are proxied to the backend, so it is never shipped to the browser.
 
     const config = {
       ...options,
@@ -0,0 +1,20 @@
+import { NextRequest, NextResponse } from 'next/server';
+
+// Adds the web client's backend secret to /api/v1 requests on the server, right
+// before they are rewritten to the Go backend. The secret never reaches the browser.
+export function middleware(request: NextRequest) {
+  const headers = new Headers(request.headers);
+  headers.delete('X-Backend-Secret');
+  headers.delete('X-API-Secret');
+
+  const backendSecret = process.env.BACKEND_SECRET;
+  if (backendSecret) {
+    headers.set('X-Backend-Secret', backendSecret);
+  }
+
+  return NextResponse.next({ request: { headers } });
+}
+
+export const config = {
+  matcher: '/api/v1/:path*',
+};
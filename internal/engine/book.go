@@ -0,0 +1,407 @@
+// Package engine implements the in-memory central limit order book used by the matching
+// engine and the rolling market statistics next to it. It has no I/O: the caller persists orders and settles fills, and serializes all
+// access to a book.
+package engine
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Side of an order
+type Side string
+
+const (
+	Buy  Side = "buy"
+	Sell Side = "sell"
+)
+
+// Order is an order matched against or resting in a book
+type Order struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Side      Side
+	Price     *big.Rat      // Limit price; nil for market orders
+	Remaining *big.Rat      // Base quantity left to fill; nil for market buys sized by Funds
+	Funds     *big.Rat      // Quote amount left to spend, market buys only
+	SelfTrade SelfTradeMode // Takers only: what to do on reaching an order of the same user
+	seq       uint64        // Time priority within a price level
+}
+
+// Fill is one execution between a taker and a resting maker order
+type Fill struct {
+	MakerID        uuid.UUID
+	MakerUserID    uuid.UUID
+	Price          *big.Rat // Always the maker's price
+	Quantity       *big.Rat
+	MakerRemaining *big.Rat // Left on the maker order after this fill
+}
+
+// Level is the total resting quantity at a price. A zero quantity in a diff means the
+// level was removed.
+type Level struct {
+	Price    *big.Rat
+	Quantity *big.Rat
+}
+
+type priceLevel struct {
+	price  *big.Rat
+	orders []*Order // Oldest first
+}
+
+// Book is the order book of one market
+type Book struct {
+	quantityDecimals int
+	bids             []*priceLevel // Highest price first
+	asks             []*priceLevel // Lowest price first
+	orders           map[uuid.UUID]*Order
+	seq              uint64
+	updateID         uint64
+	dirty            map[Side]map[string]*big.Rat // Levels changed since the last TakeChanges
+}
+
+// NewBook creates an empty book. Market buys sized by funds are filled in steps of
+// 10^-quantityDecimals.
+func NewBook(quantityDecimals int) *Book {
+	return &Book{
+		quantityDecimals: quantityDecimals,
+		orders:           make(map[uuid.UUID]*Order),
+		dirty:            map[Side]map[string]*big.Rat{Buy: {}, Sell: {}},
+	}
+}
+
+// UpdateID returns the ID of the last change to the visible book
+func (b *Book) UpdateID() uint64 {
+	return b.updateID
+}
+
+// SetUpdateID continues the update IDs of a previous book when a book is rebuilt, so
+// subscribers never see them go backwards
+func (b *Book) SetUpdateID(id uint64) {
+	b.updateID = id
+}
+
+// Match executes taker against the opposite side of the book, best price first and oldest
+// order first within a price. Limit orders only match at their price or better. The taker's
+// Remaining (or Funds) is reduced; the taker is never added to the book. Orders of the taker's
+// own user are handled by its SelfTrade mode and returned as preventions; when a prevention
+// cancels the taker, matching stops and the caller must not rest the remainder.
+func (b *Book) Match(taker *Order) ([]Fill, []Prevention) {
+	var fills []Fill
+	var preventions []Prevention
+	levels := &b.asks
+	if taker.Side == Sell {
+		levels = &b.bids
+	}
+
+	for len(*levels) > 0 {
+		level := (*levels)[0]
+		if taker.Price != nil && !crosses(taker.Side, taker.Price, level.price) {
+			break
+		}
+
+		for len(level.orders) > 0 {
+			maker := level.orders[0]
+			if maker.UserID == taker.UserID && taker.SelfTrade.prevents() {
+				prevention := b.preventSelfTrade(taker, level)
+				preventions = append(preventions, prevention)
+				if prevention.TakerCancelled {
+					if len(level.orders) == 0 {
+						*levels = (*levels)[1:]
+					}
+					return fills, preventions
+				}
+				continue
+			}
+
+			quantity := b.fillQuantity(taker, maker, level.price)
+			if quantity.Sign() <= 0 {
+				return fills, preventions
+			}
+
+			maker.Remaining.Sub(maker.Remaining, quantity)
+			if taker.Remaining != nil {
+				taker.Remaining.Sub(taker.Remaining, quantity)
+			} else {
+				taker.Funds.Sub(taker.Funds, new(big.Rat).Mul(quantity, level.price))
+			}
+			b.markDirty(maker.Side, level.price)
+
+			fills = append(fills, Fill{
+				MakerID:        maker.ID,
+				MakerUserID:    maker.UserID,
+				Price:          new(big.Rat).Set(level.price),
+				Quantity:       quantity,
+				MakerRemaining: new(big.Rat).Set(maker.Remaining),
+			})
+
+			if maker.Remaining.Sign() == 0 {
+				level.orders = level.orders[1:]
+				delete(b.orders, maker.ID)
+			}
+		}
+
+		if len(level.orders) == 0 {
+			*levels = (*levels)[1:]
+		}
+	}
+
+	return fills, preventions
+}
+
+// Fillable reports whether Match would fill taker completely, without changing the book. A
+// market buy sized by funds is complete once the rest does not pay for one quantity step.
+// Self-trade prevention that cancels or decrements the taker counts as not filled.
+func (b *Book) Fillable(taker *Order) bool {
+	levels := b.asks
+	if taker.Side == Sell {
+		levels = b.bids
+	}
+	left := &Order{Side: taker.Side, Price: taker.Price}
+	if taker.Remaining != nil {
+		left.Remaining = new(big.Rat).Set(taker.Remaining)
+	} else {
+		left.Funds = new(big.Rat).Set(taker.Funds)
+	}
+
+	for _, level := range levels {
+		if taker.Price != nil && !crosses(taker.Side, taker.Price, level.price) {
+			break
+		}
+		for _, maker := range level.orders {
+			// Only cancel_oldest goes on past an own order without cutting the taker short
+			if maker.UserID == taker.UserID && taker.SelfTrade.prevents() {
+				if taker.SelfTrade != CancelOldest {
+					return false
+				}
+				continue
+			}
+			quantity := b.fillQuantity(left, maker, level.price)
+			if quantity.Sign() <= 0 {
+				return left.Remaining == nil
+			}
+			if left.Remaining != nil {
+				left.Remaining.Sub(left.Remaining, quantity)
+				if left.Remaining.Sign() == 0 {
+					return true
+				}
+			} else {
+				left.Funds.Sub(left.Funds, new(big.Rat).Mul(quantity, level.price))
+			}
+		}
+	}
+	if left.Remaining != nil {
+		return left.Remaining.Sign() == 0
+	}
+	return left.Funds.Sign() == 0
+}
+
+// fillQuantity returns how much of maker the taker can execute at price
+func (b *Book) fillQuantity(taker, maker *Order, price *big.Rat) *big.Rat {
+	if taker.Remaining != nil {
+		return minRat(taker.Remaining, maker.Remaining)
+	}
+
+	// Market buy sized by funds: as many quantity steps as the funds pay for
+	affordable := floorRat(new(big.Rat).Quo(taker.Funds, price), b.quantityDecimals)
+	return minRat(affordable, maker.Remaining)
+}
+
+// Add rests a limit order in the book behind existing orders at the same price
+func (b *Book) Add(order *Order) {
+	b.seq++
+	order.seq = b.seq
+	b.orders[order.ID] = order
+
+	levels := &b.bids
+	if order.Side == Sell {
+		levels = &b.asks
+	}
+
+	i := sort.Search(len(*levels), func(i int) bool {
+		return !better(order.Side, (*levels)[i].price, order.Price)
+	})
+	if i < len(*levels) && (*levels)[i].price.Cmp(order.Price) == 0 {
+		(*levels)[i].orders = append((*levels)[i].orders, order)
+	} else {
+		level := &priceLevel{price: new(big.Rat).Set(order.Price), orders: []*Order{order}}
+		*levels = append(*levels, nil)
+		copy((*levels)[i+1:], (*levels)[i:])
+		(*levels)[i] = level
+	}
+	b.markDirty(order.Side, order.Price)
+}
+
+// Cancel removes a resting order and returns it, or nil if it is not in the book
+func (b *Book) Cancel(id uuid.UUID) *Order {
+	order, ok := b.orders[id]
+	if !ok {
+		return nil
+	}
+	delete(b.orders, id)
+
+	levels := &b.bids
+	if order.Side == Sell {
+		levels = &b.asks
+	}
+	for i, level := range *levels {
+		if level.price.Cmp(order.Price) != 0 {
+			continue
+		}
+		for j, resting := range level.orders {
+			if resting.ID == id {
+				level.orders = append(level.orders[:j], level.orders[j+1:]...)
+				break
+			}
+		}
+		if len(level.orders) == 0 {
+			*levels = append((*levels)[:i], (*levels)[i+1:]...)
+		}
+		break
+	}
+	b.markDirty(order.Side, order.Price)
+	return order
+}
+
+// Get returns a resting order
+func (b *Book) Get(id uuid.UUID) *Order {
+	return b.orders[id]
+}
+
+// Best returns the best bid and ask prices; nil when a side is empty
+func (b *Book) Best() (*big.Rat, *big.Rat) {
+	var bid, ask *big.Rat
+	if len(b.bids) > 0 {
+		bid = new(big.Rat).Set(b.bids[0].price)
+	}
+	if len(b.asks) > 0 {
+		ask = new(big.Rat).Set(b.asks[0].price)
+	}
+	return bid, ask
+}
+
+// HasLiquidity reports whether the side a taker on side would match against has orders
+func (b *Book) HasLiquidity(side Side) bool {
+	if side == Buy {
+		return len(b.asks) > 0
+	}
+	return len(b.bids) > 0
+}
+
+// Depth returns up to limit aggregated levels per side, best first. A limit of 0 returns all.
+func (b *Book) Depth(limit int) ([]Level, []Level) {
+	return aggregate(b.bids, limit), aggregate(b.asks, limit)
+}
+
+// GroupLevels merges levels of one side, best first, into buckets of tick. Bids are rounded down
+// and asks up, so grouped sides never overlap. A limit of 0 returns all buckets.
+func GroupLevels(side Side, levels []Level, tick *big.Rat, limit int) []Level {
+	grouped := make([]Level, 0)
+	for _, level := range levels {
+		steps := new(big.Rat).Quo(level.Price, tick)
+		whole, rest := new(big.Int).QuoRem(steps.Num(), steps.Denom(), new(big.Int))
+		if side == Sell && rest.Sign() > 0 {
+			whole.Add(whole, big.NewInt(1))
+		}
+		price := new(big.Rat).Mul(new(big.Rat).SetInt(whole), tick)
+
+		if n := len(grouped); n > 0 && grouped[n-1].Price.Cmp(price) == 0 {
+			grouped[n-1].Quantity.Add(grouped[n-1].Quantity, level.Quantity)
+			continue
+		}
+		if limit > 0 && len(grouped) == limit {
+			break
+		}
+		grouped = append(grouped, Level{Price: price, Quantity: new(big.Rat).Set(level.Quantity)})
+	}
+	return grouped
+}
+
+// TakeChanges returns the levels changed since the last call with their new totals and
+// advances the update ID. ok is false if nothing visible changed.
+func (b *Book) TakeChanges() (bids, asks []Level, updateID uint64, ok bool) {
+	if len(b.dirty[Buy]) == 0 && len(b.dirty[Sell]) == 0 {
+		return nil, nil, b.updateID, false
+	}
+
+	bids = b.changedLevels(Buy, b.bids)
+	asks = b.changedLevels(Sell, b.asks)
+	b.dirty = map[Side]map[string]*big.Rat{Buy: {}, Sell: {}}
+	b.updateID++
+	return bids, asks, b.updateID, true
+}
+
+// changedLevels returns the current totals of the dirty levels of one side, best first
+func (b *Book) changedLevels(side Side, levels []*priceLevel) []Level {
+	changed := make([]Level, 0, len(b.dirty[side]))
+	for _, price := range b.dirty[side] {
+		total := new(big.Rat)
+		for _, level := range levels {
+			if level.price.Cmp(price) == 0 {
+				total = levelQuantity(level)
+				break
+			}
+		}
+		changed = append(changed, Level{Price: price, Quantity: total})
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return better(side, changed[i].Price, changed[j].Price)
+	})
+	return changed
+}
+
+func (b *Book) markDirty(side Side, price *big.Rat) {
+	b.dirty[side][price.RatString()] = new(big.Rat).Set(price)
+}
+
+// crosses reports whether a taker with a limit price can trade at a maker's price
+func crosses(side Side, limit, price *big.Rat) bool {
+	if side == Buy {
+		return price.Cmp(limit) <= 0
+	}
+	return price.Cmp(limit) >= 0
+}
+
+// better reports whether price a ranks before price b on side
+func better(side Side, a, b *big.Rat) bool {
+	if side == Buy {
+		return a.Cmp(b) > 0
+	}
+	return a.Cmp(b) < 0
+}
+
+func aggregate(levels []*priceLevel, limit int) []Level {
+	if limit <= 0 || limit > len(levels) {
+		limit = len(levels)
+	}
+	result := make([]Level, 0, limit)
+	for _, level := range levels[:limit] {
+		result = append(result, Level{Price: new(big.Rat).Set(level.price), Quantity: levelQuantity(level)})
+	}
+	return result
+}
+
+func levelQuantity(level *priceLevel) *big.Rat {
+	total := new(big.Rat)
+	for _, order := range level.orders {
+		total.Add(total, order.Remaining)
+	}
+	return total
+}
+
+func minRat(a, b *big.Rat) *big.Rat {
+	if a.Cmp(b) <= 0 {
+		return new(big.Rat).Set(a)
+	}
+	return new(big.Rat).Set(b)
+}
+
+// floorRat rounds a non-negative value down to decimals
+func floorRat(x *big.Rat, decimals int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	scaled := new(big.Int).Mul(x.Num(), scale)
+	scaled.Quo(scaled, x.Denom())
+	return new(big.Rat).SetFrac(scaled, scale)
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"math/big"
+
+	"github.com/google/uuid"
+)
+
+// SelfTradeMode decides what happens when a taker reaches a resting order of the same user
+type SelfTradeMode string
+
+const (
+	// AllowSelfTrade matches the orders like any other (the zero value does the same)
+	AllowSelfTrade SelfTradeMode = "none"
+	// CancelNewest cancels the rest of the taker and leaves the resting order
+	CancelNewest SelfTradeMode = "cancel_newest"
+	// CancelOldest cancels the resting order and goes on matching the taker
+	CancelOldest SelfTradeMode = "cancel_oldest"
+	// CancelBoth cancels the resting order and the rest of the taker
+	CancelBoth SelfTradeMode = "cancel_both"
+	// DecrementAndCancel takes the quantity that would have matched off both orders and
+	// cancels whichever has nothing left
+	DecrementAndCancel SelfTradeMode = "decrement_and_cancel"
+)
+
+func (m SelfTradeMode) prevents() bool {
+	return m != "" && m != AllowSelfTrade
+}
+
+// Prevention is a match between two orders of the same user that was not executed
+type Prevention struct {
+	MakerID        uuid.UUID
+	Mode           SelfTradeMode
+	Price          *big.Rat // The maker's price
+	Quantity       *big.Rat // What would have matched; taken off both orders by DecrementAndCancel
+	MakerRemaining *big.Rat // Left on the maker order; zero when it was cancelled
+	MakerCancelled bool     // The maker order left the book
+	TakerCancelled bool     // Matching stopped; the rest of the taker is cancelled
+}
+
+// preventSelfTrade applies the taker's mode to the first order of level, which belongs to the
+// taker's user
+func (b *Book) preventSelfTrade(taker *Order, level *priceLevel) Prevention {
+	maker := level.orders[0]
+	quantity := b.fillQuantity(taker, maker, level.price)
+	prevention := Prevention{
+		MakerID:  maker.ID,
+		Mode:     taker.SelfTrade,
+		Price:    new(big.Rat).Set(level.price),
+		Quantity: quantity,
+	}
+
+	switch taker.SelfTrade {
+	case CancelOldest:
+		prevention.MakerCancelled = true
+	case CancelBoth:
+		prevention.MakerCancelled, prevention.TakerCancelled = true, true
+	case DecrementAndCancel:
+		maker.Remaining.Sub(maker.Remaining, quantity)
+		if taker.Remaining != nil {
+			taker.Remaining.Sub(taker.Remaining, quantity)
+			prevention.TakerCancelled = taker.Remaining.Sign() == 0
+		} else {
+			// A market buy sized by funds that cannot pay for one more step is done
+			taker.Funds.Sub(taker.Funds, new(big.Rat).Mul(quantity, level.price))
+			prevention.TakerCancelled = quantity.Sign() == 0 || taker.Funds.Sign() == 0
+		}
+		prevention.MakerCancelled = maker.Remaining.Sign() == 0
+		b.markDirty(maker.Side, level.price)
+	default:
+		prevention.TakerCancelled = true
+	}
+
+	if prevention.MakerCancelled {
+		level.orders = level.orders[1:]
+		delete(b.orders, maker.ID)
+		b.markDirty(maker.Side, level.price)
+	}
+	prevention.MakerRemaining = new(big.Rat).Set(maker.Remaining)
+	if prevention.MakerCancelled {
+		prevention.MakerRemaining = new(big.Rat)
+	}
+	return prevention
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"math/big"
+	"time"
+)
+
+// statsWindowMinutes is the length of the rolling statistics window
+const statsWindowMinutes = 24 * 60
+
+// statsBucket holds the trades of one minute
+type statsBucket struct {
+	minute      int64 // Unix minute
+	open        *big.Rat
+	high        *big.Rat
+	low         *big.Rat
+	volume      *big.Rat
+	quoteVolume *big.Rat
+	count       int
+}
+
+// Stats are the trade statistics of the last 24 hours. Open, High and Low are nil when there
+// were no trades.
+type Stats struct {
+	Open        *big.Rat
+	High        *big.Rat
+	Low         *big.Rat
+	Volume      *big.Rat
+	QuoteVolume *big.Rat
+	Count       int
+}
+
+// RollingStats keeps 24h trade statistics up to date with every trade, in one bucket per
+// minute, so reading them never scans trades. The window moves by the minute.
+type RollingStats struct {
+	buckets []*statsBucket // Oldest first
+}
+
+func NewRollingStats() *RollingStats {
+	return &RollingStats{}
+}
+
+// Add records a trade
+func (s *RollingStats) Add(at time.Time, price, quantity, quote *big.Rat) {
+	s.AddBucket(at, price, price, price, quantity, quote, 1)
+}
+
+// AddBucket records an aggregate of trades within the minute of at, e.g. a 1m candle when
+// the statistics are rebuilt. Buckets must be added in time order.
+func (s *RollingStats) AddBucket(at time.Time, open, high, low, volume, quote *big.Rat, count int) {
+	minute := at.Unix() / 60
+	var last *statsBucket
+	if len(s.buckets) > 0 {
+		last = s.buckets[len(s.buckets)-1]
+	}
+
+	// A clock step backwards is counted in the current minute
+	if last == nil || minute > last.minute {
+		s.buckets = append(s.buckets, &statsBucket{
+			minute:      minute,
+			open:        new(big.Rat).Set(open),
+			high:        new(big.Rat).Set(high),
+			low:         new(big.Rat).Set(low),
+			volume:      new(big.Rat).Set(volume),
+			quoteVolume: new(big.Rat).Set(quote),
+			count:       count,
+		})
+		s.prune(minute)
+		return
+	}
+
+	if high.Cmp(last.high) > 0 {
+		last.high.Set(high)
+	}
+	if low.Cmp(last.low) < 0 {
+		last.low.Set(low)
+	}
+	last.volume.Add(last.volume, volume)
+	last.quoteVolume.Add(last.quoteVolume, quote)
+	last.count += count
+}
+
+// Snapshot returns the statistics of the 24 hours up to now
+func (s *RollingStats) Snapshot(now time.Time) Stats {
+	s.prune(now.Unix() / 60)
+
+	stats := Stats{Volume: new(big.Rat), QuoteVolume: new(big.Rat)}
+	for _, bucket := range s.buckets {
+		if stats.Open == nil {
+			stats.Open = new(big.Rat).Set(bucket.open)
+			stats.High = new(big.Rat).Set(bucket.high)
+			stats.Low = new(big.Rat).Set(bucket.low)
+		}
+		if bucket.high.Cmp(stats.High) > 0 {
+			stats.High.Set(bucket.high)
+		}
+		if bucket.low.Cmp(stats.Low) < 0 {
+			stats.Low.Set(bucket.low)
+		}
+		stats.Volume.Add(stats.Volume, bucket.volume)
+		stats.QuoteVolume.Add(stats.QuoteVolume, bucket.quoteVolume)
+		stats.Count += bucket.count
+	}
+	return stats
+}
+
+// prune drops the buckets that left the window ending at the given minute
+func (s *RollingStats) prune(minute int64) {
+	cutoff := minute - statsWindowMinutes
+	i := 0
+	for i < len(s.buckets) && s.buckets[i].minute <= cutoff {
+		i++
+	}
+	if i > 0 {
+		s.buckets = append(s.buckets[:0:0], s.buckets[i:]...)
+	}
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Direction in which a price has to move to reach a trigger
+type Direction int
+
+const (
+	// Falling triggers fire when the price is at or below the trigger price (stop sells, take-profit buys)
+	Falling Direction = iota
+	// Rising triggers fire when the price is at or above the trigger price (stop buys, take-profit sells)
+	Rising
+)
+
+// Trigger is a conditional order waiting for a price
+type Trigger struct {
+	OrderID   uuid.UUID
+	Price     *big.Rat
+	Direction Direction
+}
+
+// TriggerBook holds the conditional orders of one market for one reference price (last trade or
+// mark price). Like Book it has no I/O and the caller serializes access.
+type TriggerBook struct {
+	falling []*Trigger // Highest price first, so the next to fire is in front
+	rising  []*Trigger // Lowest price first
+	orders  map[uuid.UUID]*Trigger
+}
+
+func NewTriggerBook() *TriggerBook {
+	return &TriggerBook{orders: make(map[uuid.UUID]*Trigger)}
+}
+
+// Add puts a trigger in the book
+func (b *TriggerBook) Add(trigger *Trigger) {
+	b.orders[trigger.OrderID] = trigger
+
+	if trigger.Direction == Falling {
+		b.falling = insertTrigger(b.falling, trigger, func(a, c *Trigger) bool { return a.Price.Cmp(c.Price) > 0 })
+	} else {
+		b.rising = insertTrigger(b.rising, trigger, func(a, c *Trigger) bool { return a.Price.Cmp(c.Price) < 0 })
+	}
+}
+
+// Remove takes a trigger out of the book; it returns nil if the order is not in it
+func (b *TriggerBook) Remove(orderID uuid.UUID) *Trigger {
+	trigger, ok := b.orders[orderID]
+	if !ok {
+		return nil
+	}
+	delete(b.orders, orderID)
+	if trigger.Direction == Falling {
+		b.falling = removeTrigger(b.falling, trigger)
+	} else {
+		b.rising = removeTrigger(b.rising, trigger)
+	}
+	return trigger
+}
+
+// Has reports whether an order is waiting in the book
+func (b *TriggerBook) Has(orderID uuid.UUID) bool {
+	_, ok := b.orders[orderID]
+	return ok
+}
+
+// Len returns the number of waiting orders
+func (b *TriggerBook) Len() int {
+	return len(b.orders)
+}
+
+// Fire removes and returns the triggers reached by price, in trigger price order and, at the
+// same price, in the order they were added
+func (b *TriggerBook) Fire(price *big.Rat) []*Trigger {
+	var fired []*Trigger
+	for len(b.falling) > 0 && price.Cmp(b.falling[0].Price) <= 0 {
+		fired = append(fired, b.falling[0])
+		delete(b.orders, b.falling[0].OrderID)
+		b.falling = b.falling[1:]
+	}
+	for len(b.rising) > 0 && price.Cmp(b.rising[0].Price) >= 0 {
+		fired = append(fired, b.rising[0])
+		delete(b.orders, b.rising[0].OrderID)
+		b.rising = b.rising[1:]
+	}
+	return fired
+}
+
+// Reached reports whether a trigger at price in direction would fire at the reference price
+func Reached(direction Direction, trigger, reference *big.Rat) bool {
+	if direction == Falling {
+		return reference.Cmp(trigger) <= 0
+	}
+	return reference.Cmp(trigger) >= 0
+}
+
+// insertTrigger keeps triggers sorted by before, after the triggers with the same price
+func insertTrigger(triggers []*Trigger, trigger *Trigger, before func(a, b *Trigger) bool) []*Trigger {
+	i := sort.Search(len(triggers), func(i int) bool { return before(trigger, triggers[i]) })
+	triggers = append(triggers, nil)
+	copy(triggers[i+1:], triggers[i:])
+	triggers[i] = trigger
+	return triggers
+}
+
+func removeTrigger(triggers []*Trigger, trigger *Trigger) []*Trigger {
+	for i, t := range triggers {
+		if t == trigger {
+			return append(triggers[:i], triggers[i+1:]...)
+		}
+	}
+	return triggers
+}
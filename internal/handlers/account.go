@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var errWalletsNotEmpty = errors.New("wallets are not empty")
+
+type AccountHandler struct {
+	DB      *sql.DB
+	Storage *services.StorageService
+}
+
+func NewAccountHandler(db *sql.DB, storage *services.StorageService) *AccountHandler {
+	return &AccountHandler{DB: db, Storage: storage}
+}
+
+// getAccountRetention returns how long the personal data of a closed account is kept
+// before it is anonymized
+func getAccountRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ACCOUNT_RETENTION_DAYS"))
+	if err != nil || days < 0 {
+		return 5 * 365 * 24 * time.Hour // Default 5 years
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// getDataExportTTL returns how long a finished data export can be downloaded
+func getDataExportTTL() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("DATA_EXPORT_TTL_HOURS"))
+	if err != nil || hours <= 0 {
+		return 72 * time.Hour // Default 3 days
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// CloseAccount godoc
+// @Summary Close the account
+// @Description Close the caller's account. Requires the password and a 2fa OTP code (request one with type "2fa"). All wallets must be empty and no deposit or withdrawal may be pending. The account can no longer sign in; personal data is kept for ACCOUNT_RETENTION_DAYS and then anonymized. Request a data export first if you want a copy of your data.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.CloseAccountRequest true "Closure confirmation"
+// @Success 200 {object} map[string]interface{} "Account closed"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid password or OTP"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Account is restricted"
+// @Failure 409 {object} map[string]interface{} "Wallets are not empty"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/account/close [post]
+func (h *AccountHandler) CloseAccount(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CloseAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// 1. Account checks; restricted accounts stay open until compliance has reviewed them
+	var passwordHash, status string
+	err := h.DB.QueryRow(`
+		SELECT password, status FROM users WHERE id = $1 AND deleted_at IS NULL
+	`, userID).Scan(&passwordHash, &status)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_not_found", "message": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	if status != "active" && status != "pending" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "account_restricted", "message": "This account can not be closed. Please contact support."})
+		return
+	}
+
+	// 2. Password and second factor
+	match, err := models.VerifyPassword(req.Password, passwordHash)
+	if err != nil || !match {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_password", "message": "Password is incorrect"})
+		return
+	}
+
+	if err := consumeOTP(h.DB, userID, "2fa", req.Code); err != nil {
+		respondOTPError(c, err)
+		return
+	}
+
+	// 3. Close the account
+	closedAt, err := h.closeAccount(c, userID, status, strings.TrimSpace(req.Reason))
+	if err == errWalletsNotEmpty {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "wallets_not_empty",
+			"message": "Withdraw all funds and wait for pending transactions to complete before closing your account",
+		})
+		return
+	}
+	if err != nil {
+		fmt.Printf("Failed to close account %s: %v\n", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to close account"})
+		return
+	}
+
+	anonymizeAfter := closedAt.Add(getAccountRetention())
+	h.sendAccountClosedEmail(userID, closedAt, anonymizeAfter)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Account closed",
+		"closed_at":       closedAt,
+		"anonymize_after": anonymizeAfter,
+	})
+}
+
+// closeAccount sets deleted_at after checking, with the wallets locked, that every balance
+// is zero and no transaction is pending. Pending email changes, address book entries, unused codes
+// and queued data exports are dropped.
+func (h *AccountHandler) closeAccount(c *gin.Context, userID uuid.UUID, status, reason string) (time.Time, error) {
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", userID); err != nil {
+		return time.Time{}, err
+	}
+
+	var funded, pending int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT id FROM wallets WHERE user_id = $1 AND (balance <> 0 OR frozen_balance <> 0) FOR UPDATE
+		) w
+	`, userID).Scan(&funded)
+	if err != nil {
+		return time.Time{}, err
+	}
+	err = tx.QueryRow("SELECT COUNT(*) FROM transactions WHERE user_id = $1 AND status = 'pending'", userID).Scan(&pending)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if funded > 0 || pending > 0 {
+		return time.Time{}, errWalletsNotEmpty
+	}
+
+	var closedAt time.Time
+	err = tx.QueryRow(`
+		UPDATE users SET status = 'inactive', deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING deleted_at
+	`, userID).Scan(&closedAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	statements := []string{
+		"UPDATE email_changes SET status = 'cancelled', cancelled_at = NOW(), updated_at = NOW() WHERE user_id = $1 AND status = 'pending'",
+		"UPDATE withdrawal_addresses SET status = 'removed', removed_at = NOW(), updated_at = NOW() WHERE user_id = $1 AND status <> 'removed'",
+		"UPDATE otps SET used = TRUE, updated_at = NOW() WHERE user_id = $1 AND used = FALSE",
+		"UPDATE data_exports SET status = 'expired', updated_at = NOW() WHERE user_id = $1 AND status IN ('pending', 'processing')",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, userID); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &userID,
+		ActorRole:  "user",
+		Action:     "user.close",
+		TargetType: "user",
+		TargetID:   userID.String(),
+		Reason:     reason,
+		Before:     gin.H{"status": status, "deleted_at": nil},
+		After:      gin.H{"status": "inactive", "deleted_at": closedAt},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return closedAt, tx.Commit()
+}
+
+// sendAccountClosedEmail confirms a closure to the user. Failures are only logged.
+func (h *AccountHandler) sendAccountClosedEmail(userID uuid.UUID, closedAt, anonymizeAfter time.Time) {
+	email, userName, err := accountEmailRecipient(h.DB, userID)
+	if err != nil {
+		fmt.Printf("Failed to load user for account closure email: %v\n", err)
+		return
+	}
+
+	emailService := userEmailService(h.DB, userID)
+	if !emailService.IsEnabled() {
+		return
+	}
+
+	err = emailService.SendAccountClosed(email, userName, closedAt.UTC().Format("2006-01-02 15:04 UTC"), anonymizeAfter.UTC().Format("2006-01-02"))
+	if err != nil {
+		fmt.Printf("Failed to send account closure email: %v\n", err)
+	}
+}
+
+// accountEmailRecipient returns the address and display name of a user, closed or not
+func accountEmailRecipient(db *sql.DB, userID uuid.UUID) (string, string, error) {
+	var email, firstName, lastName, username string
+	err := db.QueryRow("SELECT email, first_name, last_name, username FROM users WHERE id = $1", userID).
+		Scan(&email, &firstName, &lastName, &username)
+	if err != nil {
+		return "", "", err
+	}
+
+	userName := fmt.Sprintf("%s %s", firstName, lastName)
+	if userName == " " {
+		userName = username
+	}
+	return email, userName, nil
+}
+
+// RequestDataExport godoc
+// @Summary Request a data export
+// @Description Start an export of the caller's personal data: profile, login history, transactions and KYC metadata (identity documents themselves are not included). The archive is a zip with one JSON or CSV file per section, built in the background. Poll GET /api/v1/account/exports for a download link; it can be downloaded for DATA_EXPORT_TTL_HOURS. Only one export can be in progress at a time.
+// @Tags Account
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.DataExportRequest false "Export format"
+// @Success 202 {object} models.DataExport "Export queued"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "An export is already in progress"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/account/exports [post]
+func (h *AccountHandler) RequestDataExport(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.DataExportRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "validation_failed",
+				"message": "Invalid request data",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+	if req.Format == "" {
+		req.Format = "json"
+	}
+
+	export := models.DataExport{UserID: userID, Format: req.Format, Status: "pending"}
+	err := h.DB.QueryRow(`
+		INSERT INTO data_exports (user_id, format)
+		SELECT id, $2 FROM users WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id, created_at
+	`, userID, req.Format).Scan(&export.ID, &export.CreatedAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_not_found", "message": "User not found"})
+		return
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		c.JSON(http.StatusConflict, gin.H{"error": "export_in_progress", "message": "A data export is already in progress"})
+		return
+	}
+	if err != nil {
+		fmt.Printf("Failed to queue data export: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to queue data export"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, export)
+}
+
+// GetDataExports godoc
+// @Summary List data exports
+// @Description List the caller's data exports, newest first. Ready exports include a signed, short-lived download URL.
+// @Tags Account
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Data exports"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/account/exports [get]
+func (h *AccountHandler) GetDataExports(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.DB.Query(`
+		SELECT id, user_id, format, status, storage_key, size_bytes, sha256, error, completed_at, expires_at, created_at
+		FROM data_exports
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 20
+	`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch data exports"})
+		return
+	}
+	defer rows.Close()
+
+	exports := []models.DataExport{}
+	for rows.Next() {
+		var e models.DataExport
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Format, &e.Status, &e.StorageKey, &e.SizeBytes, &e.SHA256,
+			&e.Error, &e.CompletedAt, &e.ExpiresAt, &e.CreatedAt); err != nil {
+			continue
+		}
+		if e.Status == "ready" && e.StorageKey != nil {
+			if url, _, err := h.Storage.SignedURL(*e.StorageKey, dataExportFileName(&e)); err == nil {
+				e.DownloadURL = url
+			}
+		}
+		exports = append(exports, e)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": exports})
+}
+
+// dataExportFileName returns the download name of an export archive
+func dataExportFileName(e *models.DataExport) string {
+	return fmt.Sprintf("bixor-data-export-%s-%s.zip", e.CreatedAt.UTC().Format("20060102"), e.Format)
+}
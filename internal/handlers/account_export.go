@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dataExportSection is one file of a data export archive
+type dataExportSection struct {
+	Name   string
+	Single bool   // Exported as one JSON object instead of a list
+	Query  string // Selects the user's rows; $1 is the user ID
+}
+
+// dataExportSections lists what a data export contains. Identity documents are described
+// but not included, and secrets (password hash, anti-phishing code, document numbers) are left out.
+var dataExportSections = []dataExportSection{
+	{
+		Name:   "profile",
+		Single: true,
+		Query: `
+			SELECT id, first_name, last_name, username, email, email_status, phone_number, phone_status,
+				   address, city, country, role, status, kyc_status, twofa_enabled, language, timezone,
+				   referral_code, last_login_at, last_login_ip, created_at, updated_at
+			FROM users WHERE id = $1`,
+	},
+	{
+		Name: "login_history",
+		Query: `
+			SELECT created_at, ip_address, user_agent, new_device
+			FROM login_events WHERE user_id = $1
+			ORDER BY created_at DESC`,
+	},
+	{
+		Name: "transactions",
+		Query: `
+			SELECT t.id, c.ticker AS coin, t.type, t.amount, t.fee, t.status, t.address, t.memo,
+				   t.reference_id, t.description, t.created_at, t.updated_at
+			FROM transactions t
+			JOIN wallets w ON w.id = t.wallet_id
+			JOIN coins c ON c.id = w.coin_id
+			WHERE t.user_id = $1
+			ORDER BY t.created_at`,
+	},
+	{
+		Name: "kyc_submissions",
+		Query: `
+			SELECT id, status, first_name, last_name, date_of_birth::text AS date_of_birth, nationality, country,
+				   city, address, postal_code, document_type, reviewed_at, rejection_reason, created_at, updated_at
+			FROM kyc_submissions WHERE user_id = $1
+			ORDER BY created_at`,
+	},
+	{
+		Name: "kyc_documents",
+		Query: `
+			SELECT d.submission_id, d.kind, d.file_name, d.content_type, d.size_bytes, d.sha256, d.created_at
+			FROM kyc_documents d
+			JOIN kyc_submissions s ON s.id = d.submission_id
+			WHERE s.user_id = $1
+			ORDER BY d.created_at`,
+	},
+	{
+		Name: "kyc_history",
+		Query: `
+			SELECT submission_id, action, from_status, to_status, reason, created_at
+			FROM kyc_history WHERE user_id = $1
+			ORDER BY created_at`,
+	},
+}
+
+// buildDataExport packages the personal data of a user into a zip archive with one
+// JSON or CSV file per section
+func buildDataExport(db *sql.DB, userID uuid.UUID, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, section := range dataExportSections {
+		columns, records, err := queryDataExportSection(db, section.Query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", section.Name, err)
+		}
+
+		file, err := archive.Create(section.Name + "." + format)
+		if err != nil {
+			return nil, err
+		}
+
+		if format == "csv" {
+			err = writeDataExportCSV(file, columns, records)
+		} else {
+			err = writeDataExportJSON(file, columns, records, section.Single)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", section.Name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// queryDataExportSection runs a section query and returns its column names and rows
+func queryDataExportSection(db *sql.DB, query string, userID uuid.UUID) ([]string, [][]interface{}, error) {
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records := [][]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, err
+		}
+		// NUMERIC and UUID columns are returned as bytes
+		for i, value := range values {
+			if raw, ok := value.([]byte); ok {
+				values[i] = string(raw)
+			}
+		}
+		records = append(records, values)
+	}
+	return columns, records, rows.Err()
+}
+
+// writeDataExportJSON writes the rows as a list of objects, or the first row as an object
+func writeDataExportJSON(w io.Writer, columns []string, records [][]interface{}, single bool) error {
+	objects := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		object := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			object[column] = record[i]
+		}
+		objects = append(objects, object)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if single {
+		if len(objects) == 0 {
+			return encoder.Encode(nil)
+		}
+		return encoder.Encode(objects[0])
+	}
+	return encoder.Encode(objects)
+}
+
+// writeDataExportCSV writes the rows with a header line
+func writeDataExportCSV(w io.Writer, columns []string, records [][]interface{}) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	line := make([]string, len(columns))
+	for _, record := range records {
+		for i, value := range record {
+			line[i] = csvExportValue(value)
+		}
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvExportValue formats a value for CSV. Text that a spreadsheet would run as a formula
+// (user agents, names) is prefixed with a quote.
+func csvExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return "'" + v
+			}
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
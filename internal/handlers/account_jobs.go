@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/google/uuid"
+)
+
+// dataExportStaleAfter is how long an export may stay in processing before another
+// worker picks it up again (the worker that claimed it is assumed to have died)
+const dataExportStaleAfter = 15 * time.Minute
+
+// AccountJobs runs the background work behind account closure and data exports: building
+// requested exports, deleting expired archives and anonymizing closed accounts once the
+// retention period has passed. Rows are claimed with SKIP LOCKED, so every API instance
+// can run the jobs.
+type AccountJobs struct {
+	DB      *sql.DB
+	Storage *services.StorageService
+}
+
+func NewAccountJobs(db *sql.DB, storage *services.StorageService) *AccountJobs {
+	return &AccountJobs{DB: db, Storage: storage}
+}
+
+// getAccountJobsInterval returns the pause between two runs of the account jobs
+func getAccountJobsInterval() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("ACCOUNT_JOBS_INTERVAL_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return time.Minute // Default 60 seconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Start runs the jobs in the background until the process exits. Set
+// ACCOUNT_JOBS_ENABLED=false to leave them to other instances.
+func (j *AccountJobs) Start() {
+	if os.Getenv("ACCOUNT_JOBS_ENABLED") == "false" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(getAccountJobsInterval())
+		defer ticker.Stop()
+		for {
+			j.RunOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+// RunOnce builds all queued data exports, removes expired archives and anonymizes closed
+// accounts past the retention period. Failures are logged and retried on the next run.
+func (j *AccountJobs) RunOnce() {
+	for {
+		processed, err := j.processNextDataExport()
+		if err != nil {
+			fmt.Printf("Failed to process data export: %v\n", err)
+			break
+		}
+		if !processed {
+			break
+		}
+	}
+
+	if err := j.expireDataExports(); err != nil {
+		fmt.Printf("Failed to expire data exports: %v\n", err)
+	}
+
+	cutoff := time.Now().Add(-getAccountRetention())
+	for {
+		anonymized, err := j.anonymizeNextAccount(cutoff)
+		if err != nil {
+			fmt.Printf("Failed to anonymize closed account: %v\n", err)
+			break
+		}
+		if !anonymized {
+			break
+		}
+	}
+}
+
+// processNextDataExport claims the oldest queued export and builds its archive. It returns
+// false when there is nothing to do.
+func (j *AccountJobs) processNextDataExport() (bool, error) {
+	var export models.DataExport
+	var startedAt time.Time
+	err := j.DB.QueryRow(`
+		UPDATE data_exports SET status = 'processing', started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = 'pending' OR (status = 'processing' AND started_at < $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, format, started_at, created_at
+	`, time.Now().Add(-dataExportStaleAfter)).Scan(&export.ID, &export.UserID, &export.Format, &startedAt, &export.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	archive, err := buildDataExport(j.DB, export.UserID, export.Format)
+	var stored *services.StoredObject
+	if err == nil {
+		stored, err = j.Storage.SaveGenerated(context.Background(), "exports/"+export.UserID.String(), ".zip", archive)
+	}
+	if err != nil {
+		fmt.Printf("Failed to build data export %s: %v\n", export.ID, err)
+		_, err = j.DB.Exec(`
+			UPDATE data_exports SET status = 'failed', error = 'The archive could not be created. Please request a new export.',
+				completed_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND status = 'processing' AND started_at = $2
+		`, export.ID, startedAt)
+		return true, err
+	}
+
+	// The claim is checked again, the export may have been cancelled or reclaimed meanwhile
+	expiresAt := time.Now().Add(getDataExportTTL())
+	result, err := j.DB.Exec(`
+		UPDATE data_exports SET status = 'ready', storage_key = $3, size_bytes = $4, sha256 = $5,
+			completed_at = NOW(), expires_at = $6, updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND started_at = $2
+	`, export.ID, startedAt, stored.Key, stored.Size, stored.SHA256, expiresAt)
+	if err == nil {
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			err = fmt.Errorf("export %s is no longer claimed by this worker", export.ID)
+		}
+	}
+	if err != nil {
+		j.deleteBlob(stored.Key)
+		return true, err
+	}
+
+	j.sendDataExportReadyEmail(export.UserID, expiresAt)
+	return true, nil
+}
+
+// expireDataExports deletes the archives of exports past their download period
+func (j *AccountJobs) expireDataExports() error {
+	rows, err := j.DB.Query(`
+		SELECT id, storage_key FROM data_exports
+		WHERE status = 'ready' AND expires_at < NOW()
+		ORDER BY expires_at
+		LIMIT 100
+	`)
+	if err != nil {
+		return err
+	}
+
+	type expiredExport struct {
+		ID         uuid.UUID
+		StorageKey *string
+	}
+	var expired []expiredExport
+	for rows.Next() {
+		var e expiredExport
+		if err := rows.Scan(&e.ID, &e.StorageKey); err != nil {
+			rows.Close()
+			return err
+		}
+		expired = append(expired, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range expired {
+		// Keep the row ready if the archive is still there, so the next run tries again
+		if e.StorageKey != nil {
+			if err := j.Storage.Delete(context.Background(), *e.StorageKey); err != nil && err != services.ErrBlobNotFound {
+				fmt.Printf("Failed to delete data export %s: %v\n", e.ID, err)
+				continue
+			}
+		}
+		_, err := j.DB.Exec(`
+			UPDATE data_exports SET status = 'expired', storage_key = NULL, updated_at = NOW()
+			WHERE id = $1 AND status = 'ready'
+		`, e.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// anonymizeNextAccount replaces the personal data of the longest closed account past the
+// retention cutoff. Financial records (wallets, transactions, commissions) are kept under the
+// anonymous user ID; the append-only audit log and KYC history are not changed.
+func (j *AccountJobs) anonymizeNextAccount(cutoff time.Time) (bool, error) {
+	tx, err := j.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	var closedAt time.Time
+	err = tx.QueryRow(`
+		SELECT id, deleted_at FROM users
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1 AND anonymized_at IS NULL
+		ORDER BY deleted_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, cutoff).Scan(&userID, &closedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Files to delete once the transaction has committed
+	var storageKeys, legacyPaths []string
+	collect := func(query string) error {
+		rows, err := tx.Query(query, userID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var storageKey, filePath sql.NullString
+			if err := rows.Scan(&storageKey, &filePath); err != nil {
+				return err
+			}
+			if storageKey.Valid {
+				storageKeys = append(storageKeys, storageKey.String)
+			}
+			if filePath.Valid {
+				legacyPaths = append(legacyPaths, filePath.String)
+			}
+		}
+		return rows.Err()
+	}
+
+	err = collect(`
+		DELETE FROM kyc_documents
+		WHERE submission_id IN (SELECT id FROM kyc_submissions WHERE user_id = $1)
+		RETURNING storage_key, file_path
+	`)
+	if err != nil {
+		return false, err
+	}
+	err = collect("DELETE FROM data_exports WHERE user_id = $1 RETURNING storage_key, NULL::text")
+	if err != nil {
+		return false, err
+	}
+
+	statements := []string{
+		`UPDATE users SET
+			first_name = 'Deleted', last_name = 'User',
+			username = 'deleted-' || id::text, email = 'deleted-' || id::text || '@anonymized.invalid',
+			password = '', email_status = FALSE, phone_number = NULL, phone_status = FALSE,
+			address = NULL, city = NULL, country = NULL, last_login_ip = NULL, device_info = NULL,
+			anti_phishing_code = NULL, anonymized_at = NOW(), updated_at = NOW()
+		WHERE id = $1`,
+		`UPDATE kyc_submissions SET
+			first_name = 'Deleted', last_name = 'User', date_of_birth = '1900-01-01',
+			city = '', address = '', postal_code = NULL, document_number = ''
+		WHERE user_id = $1`,
+		"UPDATE login_events SET ip_address = NULL, user_agent = NULL WHERE user_id = $1",
+		"UPDATE screening_cases SET screened_value = 'anonymized-' || id::text WHERE user_id = $1",
+		"DELETE FROM email_changes WHERE user_id = $1",
+		"DELETE FROM withdrawal_addresses WHERE user_id = $1",
+		"DELETE FROM otps WHERE user_id = $1",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, userID); err != nil {
+			return false, err
+		}
+	}
+
+	err = recordAuditEvent(tx, nil, auditEvent{
+		Action:     "user.anonymize",
+		TargetType: "user",
+		TargetID:   userID.String(),
+		Details: map[string]interface{}{
+			"closed_at":      closedAt,
+			"files_deleted":  len(storageKeys) + len(legacyPaths),
+			"retention_days": int(getAccountRetention().Hours() / 24),
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	for _, key := range storageKeys {
+		j.deleteBlob(key)
+	}
+	for _, path := range legacyPaths {
+		if err := os.Remove(filepath.Join(getKYCUploadDir(), path)); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Failed to delete KYC file %s: %v\n", path, err)
+		}
+	}
+	return true, nil
+}
+
+// deleteBlob removes a stored object. Failures are only logged.
+func (j *AccountJobs) deleteBlob(key string) {
+	if err := j.Storage.Delete(context.Background(), key); err != nil && err != services.ErrBlobNotFound {
+		fmt.Printf("Failed to delete stored file %s: %v\n", key, err)
+	}
+}
+
+// sendDataExportReadyEmail tells the user that an export can be downloaded. Failures are only logged.
+func (j *AccountJobs) sendDataExportReadyEmail(userID uuid.UUID, expiresAt time.Time) {
+	email, userName, err := accountEmailRecipient(j.DB, userID)
+	if err != nil {
+		fmt.Printf("Failed to load user for data export email: %v\n", err)
+		return
+	}
+
+	emailService := userEmailService(j.DB, userID)
+	if !emailService.IsEnabled() {
+		return
+	}
+
+	exportURL := fmt.Sprintf("%s/settings/privacy", getFrontendURL())
+	if err := emailService.SendDataExportReady(email, userName, exportURL, expiresAt.UTC().Format("2006-01-02 15:04 UTC")); err != nil {
+		fmt.Printf("Failed to send data export email: %v\n", err)
+	}
+}
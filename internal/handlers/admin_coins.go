@@ -0,0 +1,590 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// Placeholders in the explorer URL templates, replaced with the transaction hash or address
+const (
+	explorerTxPlaceholder      = "{txid}"
+	explorerAddressPlaceholder = "{address}"
+)
+
+type CoinAdminHandler struct {
+	DB    *sql.DB
+	Coins *CoinCache
+}
+
+func NewCoinAdminHandler(db *sql.DB, coins *CoinCache) *CoinAdminHandler {
+	return &CoinAdminHandler{
+		DB:    db,
+		Coins: coins,
+	}
+}
+
+// ListCoins godoc
+// @Summary List all coins
+// @Description All coins including inactive ones, ordered by name. Requires the coins.manage permission (superadmin, admin).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} models.CoinListResponse "List of coins"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/coins [get]
+func (h *CoinAdminHandler) ListCoins(c *gin.Context) {
+	rows, err := h.DB.Query("SELECT " + coinColumns + " FROM coins ORDER BY name ASC")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve coins"})
+		return
+	}
+	defer rows.Close()
+
+	coins := make([]models.Coin, 0)
+	for rows.Next() {
+		coin, err := scanCoin(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to scan coin data"})
+			return
+		}
+		coins = append(coins, *coin)
+	}
+
+	c.JSON(http.StatusOK, models.CoinListResponse{
+		Coins: coins,
+		Total: len(coins),
+	})
+}
+
+// GetCoin godoc
+// @Summary Get a coin
+// @Description Get a coin by ID, including inactive coins. Requires the coins.manage permission (superadmin, admin).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path int true "Coin ID"
+// @Success 200 {object} models.CoinResponse "Coin information"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Coin not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/coins/{id} [get]
+func (h *CoinAdminHandler) GetCoin(c *gin.Context) {
+	coinID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid coin ID"})
+		return
+	}
+
+	coin, err := scanCoin(h.DB.QueryRow("SELECT "+coinColumns+" FROM coins WHERE id = $1", coinID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "coin_not_found", "message": "Coin not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve coin"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CoinResponse{Coin: *coin})
+}
+
+// CreateCoin godoc
+// @Summary Add a coin
+// @Description Add a coin. Fee types are 0 (fixed) or 1 (percentage); statuses are 0 (disabled) or 1 (enabled). explorer_tx must contain {txid} and explorer_address {address}. Requires the coins.manage permission (superadmin, admin).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.CreateCoinRequest true "Coin"
+// @Success 201 {object} models.CoinResponse "Coin created"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 409 {object} map[string]interface{} "Ticker already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/coins [post]
+func (h *CoinAdminHandler) CreateCoin(c *gin.Context) {
+	actorID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateCoinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	coin := &models.Coin{
+		Name:            req.Name,
+		Ticker:          req.Ticker,
+		Decimal:         *req.Decimal,
+		PriceDecimal:    *req.PriceDecimal,
+		Logo:            req.Logo,
+		Price:           req.Price,
+		DepositGateway:  req.DepositGateway,
+		WithdrawGateway: req.WithdrawGateway,
+		DepositFee:      req.DepositFee,
+		WithdrawFee:     req.WithdrawFee,
+		DepositFeeType:  req.DepositFeeType,
+		WithdrawFeeType: req.WithdrawFeeType,
+		Confirmation:    req.Confirmation,
+		Status:          1,
+		DepositStatus:   req.DepositStatus,
+		WithdrawStatus:  req.WithdrawStatus,
+		Website:         req.Website,
+		Explorer:        req.Explorer,
+		ExplorerTx:      req.ExplorerTx,
+		ExplorerAddress: req.ExplorerAddress,
+	}
+	if req.Status != nil {
+		coin.Status = *req.Status
+	}
+	if coin.Price == "" {
+		coin.Price = "0"
+	}
+
+	if err := validateCoin(coin); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_coin", "message": err.Error()})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to create coin"})
+		return
+	}
+	defer tx.Rollback()
+
+	created, err := scanCoin(tx.QueryRow(`
+		INSERT INTO coins (
+			name, ticker, decimal, price_decimal, logo, price, deposit_gateway, withdraw_gateway,
+			deposit_fee, withdraw_fee, deposit_fee_type, withdraw_fee_type, confirmation, status,
+			withdraw_status, deposit_status, website, explorer, explorer_tx, explorer_address
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		RETURNING `+coinColumns,
+		coin.Name, coin.Ticker, coin.Decimal, coin.PriceDecimal, coin.Logo, coin.Price,
+		pq.Array(coin.DepositGateway), pq.Array(coin.WithdrawGateway), coin.DepositFee, coin.WithdrawFee,
+		coin.DepositFeeType, coin.WithdrawFeeType, coin.Confirmation, coin.Status,
+		coin.WithdrawStatus, coin.DepositStatus, coin.Website, coin.Explorer, coin.ExplorerTx, coin.ExplorerAddress,
+	))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{"error": "ticker_exists", "message": "A coin with this ticker already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to create coin"})
+		return
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &actorID,
+		ActorRole:  c.GetString("role"),
+		Action:     "coin.create",
+		TargetType: "coin",
+		TargetID:   strconv.Itoa(created.ID),
+		After:      created,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to create coin"})
+		return
+	}
+	h.Coins.Invalidate()
+
+	c.JSON(http.StatusCreated, models.CoinResponse{Coin: *created})
+}
+
+// UpdateCoin godoc
+// @Summary Update a coin
+// @Description Change a coin's details, fees, fee types, confirmations or explorer URL templates. Omitted fields are kept; an empty string clears an optional text or fee field. Use the status endpoint to enable or disable the coin. Requires the coins.manage permission (superadmin, admin).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path int true "Coin ID"
+// @Param request body models.UpdateCoinRequest true "Changed fields"
+// @Success 200 {object} models.CoinResponse "Coin updated"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Coin not found"
+// @Failure 409 {object} map[string]interface{} "Ticker already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/coins/{id} [put]
+func (h *CoinAdminHandler) UpdateCoin(c *gin.Context) {
+	var req models.UpdateCoinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.changeCoin(c, "coin.update", func(coin *models.Coin) {
+		if req.Name != nil {
+			coin.Name = *req.Name
+		}
+		if req.Ticker != nil {
+			coin.Ticker = *req.Ticker
+		}
+		if req.Decimal != nil {
+			coin.Decimal = *req.Decimal
+		}
+		if req.PriceDecimal != nil {
+			coin.PriceDecimal = *req.PriceDecimal
+		}
+		if req.Logo != nil {
+			coin.Logo = req.Logo
+		}
+		if req.Price != nil {
+			coin.Price = *req.Price
+		}
+		if req.DepositGateway != nil {
+			coin.DepositGateway = req.DepositGateway
+		}
+		if req.WithdrawGateway != nil {
+			coin.WithdrawGateway = req.WithdrawGateway
+		}
+		if req.DepositFee != nil {
+			coin.DepositFee = req.DepositFee
+		}
+		if req.WithdrawFee != nil {
+			coin.WithdrawFee = req.WithdrawFee
+		}
+		if req.DepositFeeType != nil {
+			coin.DepositFeeType = req.DepositFeeType
+		}
+		if req.WithdrawFeeType != nil {
+			coin.WithdrawFeeType = req.WithdrawFeeType
+		}
+		if req.Confirmation != nil {
+			coin.Confirmation = req.Confirmation
+		}
+		if req.Website != nil {
+			coin.Website = req.Website
+		}
+		if req.Explorer != nil {
+			coin.Explorer = req.Explorer
+		}
+		if req.ExplorerTx != nil {
+			coin.ExplorerTx = req.ExplorerTx
+		}
+		if req.ExplorerAddress != nil {
+			coin.ExplorerAddress = req.ExplorerAddress
+		}
+	})
+}
+
+// UpdateCoinStatus godoc
+// @Summary Enable or disable a coin
+// @Description Toggle a coin (status), its deposits (deposit_status) or its withdrawals (withdraw_status); 0 disables, 1 enables. Omitted fields are kept. Requires the coins.manage permission (superadmin, admin).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path int true "Coin ID"
+// @Param request body models.CoinStatusRequest true "Statuses"
+// @Success 200 {object} models.CoinResponse "Coin updated"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Coin not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/coins/{id}/status [post]
+func (h *CoinAdminHandler) UpdateCoinStatus(c *gin.Context) {
+	var req models.CoinStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	if req.Status == nil && req.DepositStatus == nil && req.WithdrawStatus == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation_failed", "message": "Provide status, deposit_status or withdraw_status"})
+		return
+	}
+
+	h.changeCoin(c, "coin.status", func(coin *models.Coin) {
+		if req.Status != nil {
+			coin.Status = *req.Status
+		}
+		if req.DepositStatus != nil {
+			coin.DepositStatus = req.DepositStatus
+		}
+		if req.WithdrawStatus != nil {
+			coin.WithdrawStatus = req.WithdrawStatus
+		}
+	})
+}
+
+// changeCoin loads the coin in the :id parameter, applies the change, validates and stores it,
+// records an audit event and invalidates the coin cache. The response is written.
+func (h *CoinAdminHandler) changeCoin(c *gin.Context, action string, apply func(coin *models.Coin)) {
+	actorID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	coinID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid coin ID"})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update coin"})
+		return
+	}
+	defer tx.Rollback()
+
+	before, err := scanCoin(tx.QueryRow("SELECT "+coinColumns+" FROM coins WHERE id = $1 FOR UPDATE", coinID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "coin_not_found", "message": "Coin not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve coin"})
+		return
+	}
+
+	coin := *before
+	apply(&coin)
+	if err := validateCoin(&coin); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_coin", "message": err.Error()})
+		return
+	}
+
+	updated, err := scanCoin(tx.QueryRow(`
+		UPDATE coins
+		SET name = $1, ticker = $2, decimal = $3, price_decimal = $4, logo = $5, price = $6,
+		    deposit_gateway = $7, withdraw_gateway = $8, deposit_fee = $9, withdraw_fee = $10,
+		    deposit_fee_type = $11, withdraw_fee_type = $12, confirmation = $13, status = $14,
+		    withdraw_status = $15, deposit_status = $16, website = $17, explorer = $18,
+		    explorer_tx = $19, explorer_address = $20, updated_at = NOW()
+		WHERE id = $21
+		RETURNING `+coinColumns,
+		coin.Name, coin.Ticker, coin.Decimal, coin.PriceDecimal, coin.Logo, coin.Price,
+		pq.Array(coin.DepositGateway), pq.Array(coin.WithdrawGateway), coin.DepositFee, coin.WithdrawFee,
+		coin.DepositFeeType, coin.WithdrawFeeType, coin.Confirmation, coin.Status,
+		coin.WithdrawStatus, coin.DepositStatus, coin.Website, coin.Explorer, coin.ExplorerTx, coin.ExplorerAddress,
+		coinID,
+	))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{"error": "ticker_exists", "message": "A coin with this ticker already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update coin"})
+		return
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &actorID,
+		ActorRole:  c.GetString("role"),
+		Action:     action,
+		TargetType: "coin",
+		TargetID:   strconv.Itoa(coinID),
+		Before:     before,
+		After:      updated,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update coin"})
+		return
+	}
+	h.Coins.Invalidate()
+
+	c.JSON(http.StatusOK, models.CoinResponse{Coin: *updated})
+}
+
+// validateCoin normalizes a coin and checks it against the constraints of the coins table
+// (migration 003). Empty optional strings are stored as NULL.
+func validateCoin(coin *models.Coin) error {
+	coin.Name = strings.TrimSpace(coin.Name)
+	if coin.Name == "" || utf8.RuneCountInString(coin.Name) > 255 {
+		return errors.New("name is required and must be at most 255 characters")
+	}
+
+	coin.Ticker = strings.ToUpper(strings.TrimSpace(coin.Ticker))
+	if coin.Ticker == "" || len(coin.Ticker) > 10 {
+		return errors.New("ticker is required and must be at most 10 characters")
+	}
+	for _, r := range coin.Ticker {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return errors.New("ticker may only contain letters and digits")
+		}
+	}
+
+	if coin.Decimal < 0 || coin.Decimal > 18 {
+		return errors.New("decimal must be between 0 and 18")
+	}
+	if coin.PriceDecimal < 0 || coin.PriceDecimal > 18 {
+		return errors.New("price_decimal must be between 0 and 18")
+	}
+
+	if err := validateCoinNumeric("price", coin.Price); err != nil {
+		return err
+	}
+	for _, fee := range []struct {
+		field string
+		value **string
+	}{{"deposit_fee", &coin.DepositFee}, {"withdraw_fee", &coin.WithdrawFee}} {
+		*fee.value = nullIfEmpty(*fee.value)
+		if *fee.value != nil {
+			if err := validateCoinNumeric(fee.field, **fee.value); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, feeType := range []struct {
+		field string
+		value *int
+	}{{"deposit_fee_type", coin.DepositFeeType}, {"withdraw_fee_type", coin.WithdrawFeeType}} {
+		if feeType.value != nil && *feeType.value != 0 && *feeType.value != 1 {
+			return fmt.Errorf("%s must be 0 (fixed) or 1 (percentage)", feeType.field)
+		}
+	}
+
+	if coin.Confirmation != nil && *coin.Confirmation < 0 {
+		return errors.New("confirmation must not be negative")
+	}
+
+	if coin.Status != 0 && coin.Status != 1 {
+		return errors.New("status must be 0 or 1")
+	}
+	for _, status := range []struct {
+		field string
+		value *int
+	}{{"deposit_status", coin.DepositStatus}, {"withdraw_status", coin.WithdrawStatus}} {
+		if status.value != nil && *status.value != 0 && *status.value != 1 {
+			return fmt.Errorf("%s must be 0 or 1", status.field)
+		}
+	}
+
+	for _, gateways := range []struct {
+		field string
+		value []string
+	}{{"deposit_gateway", coin.DepositGateway}, {"withdraw_gateway", coin.WithdrawGateway}} {
+		for i, gateway := range gateways.value {
+			gateways.value[i] = strings.TrimSpace(gateway)
+			if gateways.value[i] == "" {
+				return fmt.Errorf("%s must not contain empty entries", gateways.field)
+			}
+		}
+	}
+
+	for _, link := range []struct {
+		field       string
+		value       **string
+		placeholder string
+	}{
+		{"logo", &coin.Logo, ""},
+		{"website", &coin.Website, ""},
+		{"explorer", &coin.Explorer, ""},
+		{"explorer_tx", &coin.ExplorerTx, explorerTxPlaceholder},
+		{"explorer_address", &coin.ExplorerAddress, explorerAddressPlaceholder},
+	} {
+		*link.value = nullIfEmpty(*link.value)
+		if *link.value == nil {
+			continue
+		}
+		if err := validateCoinURL(link.field, **link.value, link.placeholder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateCoinNumeric checks that a value fits a non-negative NUMERIC(20, 8) column
+func validateCoinNumeric(field, raw string) error {
+	raw = strings.TrimSpace(raw)
+	value, ok := new(big.Rat).SetString(raw)
+	if raw == "" || strings.ContainsAny(raw, "eE/+") || !ok {
+		return fmt.Errorf("%s must be a decimal number", field)
+	}
+	if value.Sign() < 0 {
+		return fmt.Errorf("%s must not be negative", field)
+	}
+
+	integer, fraction, _ := strings.Cut(raw, ".")
+	if len(fraction) > 8 {
+		return fmt.Errorf("%s must have at most 8 decimal places", field)
+	}
+	if len(strings.TrimLeft(integer, "0")) > 12 {
+		return fmt.Errorf("%s must be less than 10^12", field)
+	}
+	return nil
+}
+
+// validateCoinURL checks a coin link column (VARCHAR(500)). Explorer templates must contain their placeholder.
+func validateCoinURL(field, raw, placeholder string) error {
+	if len(raw) > 500 {
+		return fmt.Errorf("%s must be at most 500 characters", field)
+	}
+	if placeholder != "" && !strings.Contains(raw, placeholder) {
+		return fmt.Errorf("%s must contain %s", field, placeholder)
+	}
+
+	if placeholder != "" {
+		raw = strings.ReplaceAll(raw, placeholder, "x")
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("%s must be an http or https URL", field)
+	}
+	return nil
+}
+
+// nullIfEmpty trims an optional string and returns nil when it is empty
+func nullIfEmpty(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,828 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// feeRateDecimals is the scale of the fee rate columns (NUMERIC(10, 6))
+const feeRateDecimals = 6
+
+const feeTierColumns = `tier, name, min_volume_usd, created_at, updated_at`
+
+const feeScheduleColumns = `s.market_id, m.symbol, s.tier, s.maker_fee_rate, s.taker_fee_rate, s.created_at, s.updated_at`
+
+const feeOverrideColumns = `o.id, o.user_id, o.market_id, m.symbol, o.maker_fee_rate, o.taker_fee_rate, o.reason,
+	o.created_by, o.expires_at, o.created_at, o.updated_at`
+
+type FeeAdminHandler struct {
+	DB *sql.DB
+}
+
+func NewFeeAdminHandler(db *sql.DB) *FeeAdminHandler {
+	return &FeeAdminHandler{DB: db}
+}
+
+// ListFeeTiers godoc
+// @Summary List fee tiers
+// @Description All VIP fee tiers ordered by tier. A user reaches the highest tier whose min_volume_usd is at most their rolling 30-day trading volume. Requires the fees.manage permission (superadmin, admin).
+// @Tags Admin
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} models.FeeTierListResponse "List of fee tiers"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/fees/tiers [get]
+func (h *FeeAdminHandler) ListFeeTiers(c *gin.Context) {
+	rows, err := h.DB.Query("SELECT " + feeTierColumns + " FROM fee_tiers ORDER BY tier")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee tiers"})
+		return
+	}
+	defer rows.Close()
+
+	tiers := make([]models.FeeTier, 0)
+	for rows.Next() {
+		tier, err := scanFeeTier(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to scan fee tier"})
+			return
+		}
+		tiers = append(tiers, *tier)
+	}
+
+	c.JSON(http.StatusOK, models.FeeTierListResponse{Tiers: tiers, Total: len(tiers)})
+}
+
+// UpsertFeeTier godoc
+// @Summary Set a fee tier
+// @Description Add a fee tier or change its name and 30-day volume threshold. Tier 0 is the default tier and must have a threshold of 0. Users move between tiers on the next fee tier refresh. Requires the fees.manage permission (superadmin, admin).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param tier path int true "Tier number"
+// @Param request body models.UpsertFeeTierRequest true "Fee tier"
+// @Success 200 {object} models.FeeTier "Fee tier saved"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/fees/tiers/{tier} [put]
+func (h *FeeAdminHandler) UpsertFeeTier(c *gin.Context) {
+	actorID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	tierNumber, err := strconv.Atoi(c.Param("tier"))
+	if err != nil || tierNumber < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_tier", "message": "Invalid tier number"})
+		return
+	}
+
+	var req models.UpsertFeeTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_fee_tier", "message": "name is required and must be at most 100 characters"})
+		return
+	}
+	minVolume, err := parseFeeDecimal("min_volume_usd", req.MinVolumeUSD, usdDecimals)
+	if err == nil && minVolume.Sign() < 0 {
+		err = errors.New("min_volume_usd must not be negative")
+	}
+	if err == nil && tierNumber == 0 && minVolume.Sign() != 0 {
+		err = errors.New("tier 0 must have a min_volume_usd of 0")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_fee_tier", "message": err.Error()})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save fee tier"})
+		return
+	}
+	defer tx.Rollback()
+
+	before, err := scanFeeTier(tx.QueryRow("SELECT "+feeTierColumns+" FROM fee_tiers WHERE tier = $1 FOR UPDATE", tierNumber))
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee tier"})
+		return
+	}
+
+	saved, err := scanFeeTier(tx.QueryRow(`
+		INSERT INTO fee_tiers (tier, name, min_volume_usd) VALUES ($1, $2, $3)
+		ON CONFLICT (tier) DO UPDATE SET name = EXCLUDED.name, min_volume_usd = EXCLUDED.min_volume_usd
+		RETURNING `+feeTierColumns,
+		tierNumber, req.Name, minVolume.FloatString(usdDecimals)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save fee tier"})
+		return
+	}
+
+	if !h.audit(c, tx, actorID, "fee_tier.update", "fee_tier", strconv.Itoa(tierNumber), "", before, saved) {
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save fee tier"})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteFeeTier godoc
+// @Summary Delete a fee tier
+// @Description Delete a fee tier and its fee schedules. Users in the tier pay the rates of the next lower scheduled tier until the next fee tier refresh moves them. Tier 0 cannot be deleted. Requires the fees.manage permission (superadmin, admin).
+// @Tags Admin
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param tier path int true "Tier number"
+// @Success 200 {object} map[string]interface{} "Fee tier deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid tier"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Fee tier not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/fees/tiers/{tier} [delete]
+func (h *FeeAdminHandler) DeleteFeeTier(c *gin.Context) {
+	actorID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	tierNumber, err := strconv.Atoi(c.Param("tier"))
+	if err != nil || tierNumber < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_tier", "message": "Invalid tier number"})
+		return
+	}
+	if tierNumber == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_tier", "message": "Tier 0 is the default tier and cannot be deleted"})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete fee tier"})
+		return
+	}
+	defer tx.Rollback()
+
+	before, err := scanFeeTier(tx.QueryRow("DELETE FROM fee_tiers WHERE tier = $1 RETURNING "+feeTierColumns, tierNumber))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee_tier_not_found", "message": "Fee tier not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete fee tier"})
+		return
+	}
+
+	if !h.audit(c, tx, actorID, "fee_tier.delete", "fee_tier", strconv.Itoa(tierNumber), "", before, nil) {
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete fee tier"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fee tier deleted"})
+}
+
+// ListFeeSchedules godoc
+// @Summary List fee schedules
+// @Description Maker and taker rates per market and tier, optionally for one market. A user pays the rates of the highest scheduled tier at or below their own; markets without a schedule for it charge their default rates. Requires the fees.manage permission (superadmin, admin).
+// @Tags Admin
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param market query string false "Market symbol, e.g. BTC-USDT"
+// @Success 200 {object} models.FeeScheduleListResponse "List of fee schedules"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/fees/schedules [get]
+func (h *FeeAdminHandler) ListFeeSchedules(c *gin.Context) {
+	query := "SELECT " + feeScheduleColumns + " FROM fee_schedules s JOIN markets m ON m.id = s.market_id"
+	args := []interface{}{}
+	if market := strings.ToUpper(strings.TrimSpace(c.Query("market"))); market != "" {
+		query += " WHERE m.symbol = $1"
+		args = append(args, market)
+	}
+
+	rows, err := h.DB.Query(query+" ORDER BY m.symbol, s.tier", args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee schedules"})
+		return
+	}
+	defer rows.Close()
+
+	schedules := make([]models.FeeSchedule, 0)
+	for rows.Next() {
+		schedule, err := scanFeeSchedule(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to scan fee schedule"})
+			return
+		}
+		schedules = append(schedules, *schedule)
+	}
+
+	c.JSON(http.StatusOK, models.FeeScheduleListResponse{Schedules: schedules, Total: len(schedules)})
+}
+
+// UpsertFeeSchedule godoc
+// @Summary Set a fee schedule
+// @Description Set the maker and taker rates of a tier on a market. Rates are fractions with up to 6 decimal places (0.001 is 0.1%). The taker rate must be between 0 and 1; a negative maker rate is a rebate and may not exceed the taker rate. Applies to trades from the next execution. Requires the fees.manage permission (superadmin, admin).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param market path string true "Market symbol, e.g. BTC-USDT"
+// @Param tier path int true "Tier number"
+// @Param request body models.UpsertFeeScheduleRequest true "Fee rates"
+// @Success 200 {object} models.FeeSchedule "Fee schedule saved"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Market or fee tier not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/fees/schedules/{market}/{tier} [put]
+func (h *FeeAdminHandler) UpsertFeeSchedule(c *gin.Context) {
+	actorID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	tierNumber, err := strconv.Atoi(c.Param("tier"))
+	if err != nil || tierNumber < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_tier", "message": "Invalid tier number"})
+		return
+	}
+
+	var req models.UpsertFeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	maker, taker, err := validateFeeRates(req.MakerFeeRate, req.TakerFeeRate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_fee_rate", "message": err.Error()})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save fee schedule"})
+		return
+	}
+	defer tx.Rollback()
+
+	marketID, ok := h.findMarket(c, tx, c.Param("market"))
+	if !ok {
+		return
+	}
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM fee_tiers WHERE tier = $1)", tierNumber).Scan(&exists); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee tier"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee_tier_not_found", "message": "Fee tier not found"})
+		return
+	}
+
+	before, err := scanFeeSchedule(tx.QueryRow(`
+		SELECT `+feeScheduleColumns+` FROM fee_schedules s JOIN markets m ON m.id = s.market_id
+		WHERE s.market_id = $1 AND s.tier = $2
+		FOR UPDATE OF s
+	`, marketID, tierNumber))
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee schedule"})
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO fee_schedules (market_id, tier, maker_fee_rate, taker_fee_rate) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (market_id, tier) DO UPDATE SET
+			maker_fee_rate = EXCLUDED.maker_fee_rate, taker_fee_rate = EXCLUDED.taker_fee_rate
+	`, marketID, tierNumber, maker.FloatString(feeRateDecimals), taker.FloatString(feeRateDecimals))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save fee schedule"})
+		return
+	}
+	saved, err := scanFeeSchedule(tx.QueryRow(`
+		SELECT `+feeScheduleColumns+` FROM fee_schedules s JOIN markets m ON m.id = s.market_id
+		WHERE s.market_id = $1 AND s.tier = $2
+	`, marketID, tierNumber))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee schedule"})
+		return
+	}
+
+	targetID := fmt.Sprintf("%s/%d", saved.Market, tierNumber)
+	if !h.audit(c, tx, actorID, "fee_schedule.update", "fee_schedule", targetID, "", before, saved) {
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save fee schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteFeeSchedule godoc
+// @Summary Delete a fee schedule
+// @Description Delete the rates of a tier on a market; users in the tier pay the rates of the next lower scheduled tier. Requires the fees.manage permission (superadmin, admin).
+// @Tags Admin
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param market path string true "Market symbol, e.g. BTC-USDT"
+// @Param tier path int true "Tier number"
+// @Success 200 {object} map[string]interface{} "Fee schedule deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid tier"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Fee schedule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/fees/schedules/{market}/{tier} [delete]
+func (h *FeeAdminHandler) DeleteFeeSchedule(c *gin.Context) {
+	actorID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	tierNumber, err := strconv.Atoi(c.Param("tier"))
+	if err != nil || tierNumber < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_tier", "message": "Invalid tier number"})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete fee schedule"})
+		return
+	}
+	defer tx.Rollback()
+
+	marketID, ok := h.findMarket(c, tx, c.Param("market"))
+	if !ok {
+		return
+	}
+	before, err := scanFeeSchedule(tx.QueryRow(`
+		SELECT `+feeScheduleColumns+` FROM fee_schedules s JOIN markets m ON m.id = s.market_id
+		WHERE s.market_id = $1 AND s.tier = $2
+		FOR UPDATE OF s
+	`, marketID, tierNumber))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee_schedule_not_found", "message": "Fee schedule not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee schedule"})
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM fee_schedules WHERE market_id = $1 AND tier = $2", marketID, tierNumber); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete fee schedule"})
+		return
+	}
+
+	targetID := fmt.Sprintf("%s/%d", before.Market, tierNumber)
+	if !h.audit(c, tx, actorID, "fee_schedule.delete", "fee_schedule", targetID, "", before, nil) {
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete fee schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fee schedule deleted"})
+}
+
+// ListFeeOverrides godoc
+// @Summary List fee overrides
+// @Description Per-user rates set by admins, including expired ones, optionally for one user. Requires the fees.manage permission (superadmin, admin).
+// @Tags Admin
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param user_id query string false "User ID"
+// @Success 200 {object} models.UserFeeOverrideListResponse "List of fee overrides"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/fees/overrides [get]
+func (h *FeeAdminHandler) ListFeeOverrides(c *gin.Context) {
+	query := "SELECT " + feeOverrideColumns + " FROM user_fee_overrides o LEFT JOIN markets m ON m.id = o.market_id"
+	args := []interface{}{}
+	if raw := c.Query("user_id"); raw != "" {
+		userID, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid user ID"})
+			return
+		}
+		query += " WHERE o.user_id = $1"
+		args = append(args, userID)
+	}
+
+	rows, err := h.DB.Query(query+" ORDER BY o.created_at DESC", args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee overrides"})
+		return
+	}
+	defer rows.Close()
+
+	overrides := make([]models.UserFeeOverride, 0)
+	for rows.Next() {
+		override, err := scanFeeOverride(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to scan fee override"})
+			return
+		}
+		overrides = append(overrides, *override)
+	}
+
+	c.JSON(http.StatusOK, models.UserFeeOverrideListResponse{Overrides: overrides, Total: len(overrides)})
+}
+
+// UpsertFeeOverride godoc
+// @Summary Set a fee override
+// @Description Set the rates a user pays on one market, or on all markets when market is empty, replacing the existing override for the same user and market. An override wins over the user's tier; one for a market wins over one for all markets. Rates follow the fee schedule rules. expires_at is optional and must be in the future. Requires the fees.manage permission (superadmin, admin).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.UpsertUserFeeOverrideRequest true "Fee override"
+// @Success 200 {object} models.UserFeeOverride "Fee override saved"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "User or market not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/fees/overrides [post]
+func (h *FeeAdminHandler) UpsertFeeOverride(c *gin.Context) {
+	actorID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpsertUserFeeOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	maker, taker, err := validateFeeRates(req.MakerFeeRate, req.TakerFeeRate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_fee_rate", "message": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_expires_at", "message": "expires_at must be in the future"})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save fee override"})
+		return
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)", req.UserID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user_not_found", "message": "User not found"})
+		return
+	}
+
+	var marketID *int
+	if strings.TrimSpace(req.Market) != "" {
+		id, ok := h.findMarket(c, tx, req.Market)
+		if !ok {
+			return
+		}
+		marketID = &id
+	}
+
+	before, err := scanFeeOverride(tx.QueryRow(`
+		SELECT `+feeOverrideColumns+` FROM user_fee_overrides o LEFT JOIN markets m ON m.id = o.market_id
+		WHERE o.user_id = $1 AND COALESCE(o.market_id, 0) = COALESCE($2::integer, 0)
+		FOR UPDATE OF o
+	`, req.UserID, marketID))
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee override"})
+		return
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	var overrideID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO user_fee_overrides (user_id, market_id, maker_fee_rate, taker_fee_rate, reason, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, (COALESCE(market_id, 0))) DO UPDATE SET
+			maker_fee_rate = EXCLUDED.maker_fee_rate, taker_fee_rate = EXCLUDED.taker_fee_rate,
+			reason = EXCLUDED.reason, created_by = EXCLUDED.created_by, expires_at = EXCLUDED.expires_at
+		RETURNING id
+	`, req.UserID, marketID, maker.FloatString(feeRateDecimals), taker.FloatString(feeRateDecimals),
+		reason, actorID, req.ExpiresAt).Scan(&overrideID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save fee override"})
+		return
+	}
+	saved, err := scanFeeOverride(tx.QueryRow(`
+		SELECT `+feeOverrideColumns+` FROM user_fee_overrides o LEFT JOIN markets m ON m.id = o.market_id
+		WHERE o.id = $1
+	`, overrideID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee override"})
+		return
+	}
+
+	if !h.audit(c, tx, actorID, "fee_override.update", "user", req.UserID.String(), reason, before, saved) {
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save fee override"})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteFeeOverride godoc
+// @Summary Delete a fee override
+// @Description Remove a user's fee override; the user pays their tier's rates again. Requires the fees.manage permission (superadmin, admin).
+// @Tags Admin
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Fee override ID"
+// @Success 200 {object} map[string]interface{} "Fee override deleted"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Fee override not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/fees/overrides/{id} [delete]
+func (h *FeeAdminHandler) DeleteFeeOverride(c *gin.Context) {
+	actorID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	overrideID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid fee override ID"})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete fee override"})
+		return
+	}
+	defer tx.Rollback()
+
+	before, err := scanFeeOverride(tx.QueryRow(`
+		SELECT `+feeOverrideColumns+` FROM user_fee_overrides o LEFT JOIN markets m ON m.id = o.market_id
+		WHERE o.id = $1
+		FOR UPDATE OF o
+	`, overrideID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee_override_not_found", "message": "Fee override not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee override"})
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM user_fee_overrides WHERE id = $1", overrideID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete fee override"})
+		return
+	}
+
+	if !h.audit(c, tx, actorID, "fee_override.delete", "user", before.UserID.String(), "", before, nil) {
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete fee override"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fee override deleted"})
+}
+
+// GetFeeRevenue godoc
+// @Summary Get fee revenue
+// @Description Balance of the fee revenue account per coin: trading fees collected minus maker rebates and referral commissions paid since the start, with the fees, rebates and commissions of the last days. Requires the fees.manage permission (superadmin, admin).
+// @Tags Admin
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param days query int false "Days of fees, rebates and commissions totals (default 30, max 365)"
+// @Success 200 {object} models.FeeRevenueResponse "Fee revenue"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/fees/revenue [get]
+func (h *FeeAdminHandler) GetFeeRevenue(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 {
+		days = 30
+	}
+	if days > 365 {
+		days = 365
+	}
+
+	rows, err := h.DB.Query(`
+		SELECT r.coin_id, c.ticker, r.balance,
+			COALESCE(SUM(e.amount) FILTER (WHERE e.entry_type = 'trade_fee'), 0),
+			COALESCE(-SUM(e.amount) FILTER (WHERE e.entry_type = 'maker_rebate'), 0),
+			COALESCE(-SUM(e.amount) FILTER (WHERE e.entry_type = 'referral_commission'), 0),
+			r.updated_at
+		FROM fee_revenue r
+		JOIN coins c ON c.id = r.coin_id
+		LEFT JOIN fee_revenue_entries e ON e.coin_id = r.coin_id AND e.created_at >= NOW() - make_interval(days => $1)
+		GROUP BY r.coin_id, c.ticker, r.balance, r.updated_at
+		ORDER BY c.ticker
+	`, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee revenue"})
+		return
+	}
+	defer rows.Close()
+
+	balances := make([]models.FeeRevenueBalance, 0)
+	for rows.Next() {
+		var balance models.FeeRevenueBalance
+		var fees, rebates, commissions string
+		err := rows.Scan(&balance.CoinID, &balance.Ticker, &balance.Balance, &fees, &rebates, &commissions, &balance.UpdatedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to scan fee revenue"})
+			return
+		}
+		balance.Fees = ratOrZero(fees).FloatString(ledgerDecimals)
+		balance.Rebates = ratOrZero(rebates).FloatString(ledgerDecimals)
+		balance.ReferralCommissions = ratOrZero(commissions).FloatString(ledgerDecimals)
+		balances = append(balances, balance)
+	}
+
+	c.JSON(http.StatusOK, models.FeeRevenueResponse{Days: days, Balances: balances})
+}
+
+// findMarket resolves a market symbol inside the transaction. Writes a 404 and returns false if
+// the market does not exist.
+func (h *FeeAdminHandler) findMarket(c *gin.Context, tx *sql.Tx, symbol string) (int, bool) {
+	var marketID int
+	err := tx.QueryRow("SELECT id FROM markets WHERE symbol = $1", strings.ToUpper(strings.TrimSpace(symbol))).Scan(&marketID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "market_not_found", "message": "Market not found"})
+		return 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve market"})
+		return 0, false
+	}
+	return marketID, true
+}
+
+// audit records a fee change in the audit log. Writes a 500 and returns false on failure.
+func (h *FeeAdminHandler) audit(c *gin.Context, tx *sql.Tx, actorID uuid.UUID, action, targetType, targetID, reason string, before, after interface{}) bool {
+	err := recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &actorID,
+		ActorRole:  c.GetString("role"),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Before:     before,
+		After:      after,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return false
+	}
+	return true
+}
+
+// validateFeeRates checks a maker and taker rate against the fee rate constraints of migration
+// 027: the taker rate is in [0, 1) and a maker rebate may not exceed the taker rate
+func validateFeeRates(makerRaw, takerRaw string) (*big.Rat, *big.Rat, error) {
+	maker, err := parseFeeDecimal("maker_fee_rate", makerRaw, feeRateDecimals)
+	if err != nil {
+		return nil, nil, err
+	}
+	taker, err := parseFeeDecimal("taker_fee_rate", takerRaw, feeRateDecimals)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	one := big.NewRat(1, 1)
+	if taker.Sign() < 0 || taker.Cmp(one) >= 0 {
+		return nil, nil, errors.New("taker_fee_rate must be at least 0 and less than 1")
+	}
+	if maker.Cmp(one) >= 0 {
+		return nil, nil, errors.New("maker_fee_rate must be less than 1")
+	}
+	if new(big.Rat).Add(maker, taker).Sign() < 0 {
+		return nil, nil, errors.New("a maker rebate must not exceed the taker_fee_rate")
+	}
+	return maker, taker, nil
+}
+
+// parseFeeDecimal parses a signed decimal with at most the given number of decimal places
+func parseFeeDecimal(field, raw string, decimals int) (*big.Rat, error) {
+	raw = strings.TrimSpace(raw)
+	value, ok := new(big.Rat).SetString(raw)
+	if raw == "" || strings.ContainsAny(raw, "eE/+") || !ok {
+		return nil, fmt.Errorf("%s must be a decimal number", field)
+	}
+
+	integer, fraction, _ := strings.Cut(strings.TrimPrefix(raw, "-"), ".")
+	if len(fraction) > decimals {
+		return nil, fmt.Errorf("%s must have at most %d decimal places", field, decimals)
+	}
+	if len(strings.TrimLeft(integer, "0")) > 18-decimals {
+		return nil, fmt.Errorf("%s is too large", field)
+	}
+	return value, nil
+}
+
+func scanFeeTier(row rowScanner) (*models.FeeTier, error) {
+	var t models.FeeTier
+	if err := row.Scan(&t.Tier, &t.Name, &t.MinVolumeUSD, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func scanFeeSchedule(row rowScanner) (*models.FeeSchedule, error) {
+	var s models.FeeSchedule
+	err := row.Scan(&s.MarketID, &s.Market, &s.Tier, &s.MakerFeeRate, &s.TakerFeeRate, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func scanFeeOverride(row rowScanner) (*models.UserFeeOverride, error) {
+	var o models.UserFeeOverride
+	err := row.Scan(&o.ID, &o.UserID, &o.MarketID, &o.Market, &o.MakerFeeRate, &o.TakerFeeRate, &o.Reason,
+		&o.CreatedBy, &o.ExpiresAt, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
@@ -0,0 +1,733 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/middleware"
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// userStatusAction describes an account status change available to staff
+type userStatusAction struct {
+	To         string
+	From       []string
+	Permission string
+}
+
+var userStatusActions = map[string]userStatusAction{
+	"activate":   {To: "active", From: []string{"pending"}, Permission: "users.status"},
+	"deactivate": {To: "pending", From: []string{"active"}, Permission: "users.status"},
+	"suspend":    {To: "suspended", From: []string{"active", "pending"}, Permission: "users.status"},
+	"ban":        {To: "banned", From: []string{"active", "pending", "suspended", "locked", "frozen", "inactive"}, Permission: "users.status"},
+	"freeze":     {To: "frozen", From: []string{"active", "pending"}, Permission: "users.freeze"},
+	"unlock":     {To: "active", From: []string{"suspended", "locked", "frozen", "banned", "inactive"}, Permission: "users.status"},
+	"unfreeze":   {To: "active", From: []string{"frozen"}, Permission: "users.freeze"},
+}
+
+// roleRank orders roles for admin actions; staff can only act on users ranked below them
+var roleRank = map[string]int{
+	"superadmin": 3,
+	"admin":      2,
+	"technical":  1,
+	"accountant": 1,
+	"compliance": 1,
+	"support":    1,
+	"user":       0,
+}
+
+type AdminUserHandler struct {
+	DB *sql.DB
+}
+
+func NewAdminUserHandler(db *sql.DB) *AdminUserHandler {
+	return &AdminUserHandler{DB: db}
+}
+
+// ListUsers godoc
+// @Summary Search users
+// @Description Filtered, paginated user search, newest first. Requires the users.view permission (superadmin, admin, compliance, support).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param q query string false "Matches username, email, first or last name"
+// @Param status query string false "Account status"
+// @Param role query string false "Role"
+// @Param kyc_status query string false "KYC status"
+// @Param include_deleted query bool false "Include closed accounts"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{} "List of users with pagination"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/users [get]
+func (h *AdminUserHandler) ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if c.Query("include_deleted") != "true" {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		addCondition("(username ILIKE ? OR email ILIKE ? OR first_name ILIKE ? OR last_name ILIKE ?)", "%"+q+"%")
+	}
+	if status := c.Query("status"); status != "" {
+		addCondition("status = ?", status)
+	}
+	if role := c.Query("role"); role != "" {
+		addCondition("role = ?", role)
+	}
+	if kycStatus := c.Query("kyc_status"); kycStatus != "" {
+		addCondition("kyc_status = ?", kycStatus)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := h.DB.Query(`
+		SELECT id, first_name, last_name, username, email, email_status, role, status, kyc_status,
+		       twofa_enabled, last_login_at, created_at, deleted_at
+		FROM users
+		`+where+`
+		ORDER BY created_at DESC
+		LIMIT `+strconv.Itoa(limit)+` OFFSET `+strconv.Itoa(offset), args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch users"})
+		return
+	}
+	defer rows.Close()
+
+	users := []models.AdminUserListItem{}
+	for rows.Next() {
+		var u models.AdminUserListItem
+		err := rows.Scan(&u.ID, &u.FirstName, &u.LastName, &u.Username, &u.Email, &u.EmailStatus, &u.Role, &u.Status,
+			&u.KYCStatus, &u.TwoFAEnabled, &u.LastLoginAt, &u.CreatedAt, &u.DeletedAt)
+		if err != nil {
+			continue
+		}
+		users = append(users, u)
+	}
+
+	var total int
+	if err := h.DB.QueryRow("SELECT COUNT(*) FROM users "+where, args...).Scan(&total); err != nil {
+		total = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  users,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetUser godoc
+// @Summary Get user details
+// @Description View a user with wallets, recent logins, open compliance items and recent admin actions. Requires the users.view permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{} "User details"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/users/{id} [get]
+func (h *AdminUserHandler) GetUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid user ID"})
+		return
+	}
+
+	user, err := userByID(h.DB, userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user_not_found", "message": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	wallets := []models.AdminWallet{}
+	rows, err := h.DB.Query(`
+		SELECT w.coin_id, c.ticker, w.balance, w.frozen_balance, w.updated_at
+		FROM wallets w
+		JOIN coins c ON c.id = w.coin_id
+		WHERE w.user_id = $1
+		ORDER BY c.ticker ASC
+	`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve wallets"})
+		return
+	}
+	for rows.Next() {
+		var w models.AdminWallet
+		if err := rows.Scan(&w.CoinID, &w.Ticker, &w.Balance, &w.FrozenBalance, &w.UpdatedAt); err == nil {
+			wallets = append(wallets, w)
+		}
+	}
+	rows.Close()
+
+	logins := []models.LoginEvent{}
+	rows, err = h.DB.Query(`
+		SELECT id, ip_address, user_agent, new_device, created_at
+		FROM login_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT 20
+	`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve logins"})
+		return
+	}
+	for rows.Next() {
+		var l models.LoginEvent
+		if err := rows.Scan(&l.ID, &l.IPAddress, &l.UserAgent, &l.NewDevice, &l.CreatedAt); err == nil {
+			logins = append(logins, l)
+		}
+	}
+	rows.Close()
+
+	actions, err := queryAuditEvents(h.DB, "WHERE target_type = 'user' AND target_id = $1 ORDER BY id DESC LIMIT 20", userID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve admin actions"})
+		return
+	}
+
+	var openScreeningCases, openMonitoringAlerts int
+	err = h.DB.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM screening_cases WHERE user_id = $1 AND status = 'open'),
+			(SELECT COUNT(*) FROM monitoring_alerts WHERE user_id = $1 AND status = 'open')
+	`, userID).Scan(&openScreeningCases, &openMonitoringAlerts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve compliance items"})
+		return
+	}
+
+	var lockedUntil sql.NullTime
+	if err := h.DB.QueryRow("SELECT withdrawals_locked_until FROM users WHERE id = $1", userID).Scan(&lockedUntil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+	var withdrawalsLockedUntil *time.Time
+	if lockedUntil.Valid && lockedUntil.Time.After(time.Now()) {
+		withdrawalsLockedUntil = &lockedUntil.Time
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":                     user,
+		"withdrawals_locked_until": withdrawalsLockedUntil,
+		"wallets":                  wallets,
+		"recent_logins":            logins,
+		"open_screening_cases":     openScreeningCases,
+		"open_monitoring_alerts":   openMonitoringAlerts,
+		"recent_admin_actions":     actions,
+	})
+}
+
+// ChangeUserStatus godoc
+// @Summary Change a user's account status
+// @Description Activate (pending to active), deactivate (active to pending), suspend, ban, freeze or unlock (back to active) an account. Requires the users.status permission (superadmin, admin); freezing and unlocking a frozen account also require users.freeze, which compliance uses through POST /api/v1/admin/users/{id}/freeze. Staff can only act on users with a lower role.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body models.AdminUserStatusRequest true "Action and reason"
+// @Success 200 {object} map[string]interface{} "Status changed"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "Action not possible from the current status"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/users/{id}/status [post]
+func (h *AdminUserHandler) ChangeUserStatus(c *gin.Context) {
+	var req models.AdminUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	h.changeUserStatus(c, req.Action, req.Reason)
+}
+
+// FreezeUser godoc
+// @Summary Freeze or unfreeze a user's account
+// @Description Freeze an active or pending account, or unfreeze a frozen one back to active. Requires the users.freeze permission (superadmin, admin, compliance). Staff can only act on users with a lower role.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body models.AdminUserFreezeRequest true "Action and reason"
+// @Success 200 {object} map[string]interface{} "Status changed"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "Action not possible from the current status"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/users/{id}/freeze [post]
+func (h *AdminUserHandler) FreezeUser(c *gin.Context) {
+	var req models.AdminUserFreezeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.changeUserStatus(c, req.Action, req.Reason)
+}
+
+// changeUserStatus applies a status action to the user in the path and records it in the audit log
+func (h *AdminUserHandler) changeUserStatus(c *gin.Context, actionName, reason string) {
+	action := userStatusActions[actionName]
+
+	tx, actor, target, ok := h.beginUserAction(c)
+	if !ok {
+		return
+	}
+	defer tx.Rollback()
+
+	permission := action.Permission
+	if actionName == "unlock" && target.Status == "frozen" {
+		permission = "users.freeze"
+	}
+	if !middleware.HasPermission(actor.Role, permission) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": "You do not have permission to perform this action"})
+		return
+	}
+
+	allowed := false
+	for _, from := range action.From {
+		if target.Status == from {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "invalid_status_transition",
+			"message": fmt.Sprintf("Can not %s an account with status %s", actionName, target.Status),
+		})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE users SET status = $1, updated_at = NOW() WHERE id = $2", action.To, target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update status"})
+		return
+	}
+
+	err := recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &actor.ID,
+		ActorRole:  actor.Role,
+		Action:     "user." + actionName,
+		TargetType: "user",
+		TargetID:   target.ID.String(),
+		Reason:     reason,
+		Before:     map[string]interface{}{"status": target.Status},
+		After:      map[string]interface{}{"status": action.To},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Account status updated",
+		"id":              target.ID,
+		"previous_status": target.Status,
+		"status":          action.To,
+	})
+}
+
+// ForcePasswordReset godoc
+// @Summary Force a password reset
+// @Description Block login and token refresh until the user sets a new password with a reset code, which is emailed now and can be requested again with /auth/password/forgot. Requires the users.credentials permission (superadmin, admin, support).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body models.AdminUserActionRequest true "Reason"
+// @Success 200 {object} map[string]interface{} "Password reset required"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/users/{id}/password-reset [post]
+func (h *AdminUserHandler) ForcePasswordReset(c *gin.Context) {
+	var req models.AdminUserActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tx, actor, target, ok := h.beginUserAction(c)
+	if !ok {
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE users SET password_reset_required = TRUE, updated_at = NOW() WHERE id = $1", target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to require password reset"})
+		return
+	}
+
+	err := recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &actor.ID,
+		ActorRole:  actor.Role,
+		Action:     "user.password_reset",
+		TargetType: "user",
+		TargetID:   target.ID.String(),
+		Reason:     req.Reason,
+		Before:     map[string]interface{}{"password_reset_required": target.PasswordResetRequired},
+		After:      map[string]interface{}{"password_reset_required": true},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to require password reset"})
+		return
+	}
+
+	emailSent := true
+	if err := sendPasswordResetCode(h.DB, target); err != nil {
+		fmt.Printf("Failed to send password reset code: %v\n", err)
+		emailSent = false
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Password reset required",
+		"id":         target.ID,
+		"email_sent": emailSent,
+	})
+}
+
+// ResetTwoFA godoc
+// @Summary Reset two-factor authentication
+// @Description Disable 2FA, invalidate pending 2FA codes and lock withdrawals for EMAIL_CHANGE_COOLDOWN_HOURS, e.g. after the user lost access to their second factor. Requires the users.credentials permission (superadmin, admin, support).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body models.AdminUserActionRequest true "Reason"
+// @Success 200 {object} map[string]interface{} "2FA reset"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/users/{id}/2fa-reset [post]
+func (h *AdminUserHandler) ResetTwoFA(c *gin.Context) {
+	var req models.AdminUserActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tx, actor, target, ok := h.beginUserAction(c)
+	if !ok {
+		return
+	}
+	defer tx.Rollback()
+
+	lockedUntil := time.Now().Add(getEmailChangeCooldown())
+	_, err := tx.Exec(`
+		UPDATE users
+		SET twofa_enabled = FALSE,
+		    withdrawals_locked_until = GREATEST(COALESCE(withdrawals_locked_until, $1), $1), updated_at = NOW()
+		WHERE id = $2
+	`, lockedUntil, target.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to reset 2FA"})
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE otps SET used = TRUE, updated_at = NOW()
+		WHERE user_id = $1 AND type = '2fa' AND used = FALSE
+	`, target.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to reset 2FA"})
+		return
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &actor.ID,
+		ActorRole:  actor.Role,
+		Action:     "user.2fa_reset",
+		TargetType: "user",
+		TargetID:   target.ID.String(),
+		Reason:     req.Reason,
+		Before:     map[string]interface{}{"twofa_enabled": target.TwoFAEnabled},
+		After:      map[string]interface{}{"twofa_enabled": false, "withdrawals_locked_until": lockedUntil},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to reset 2FA"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                  "Two-factor authentication reset",
+		"id":                       target.ID,
+		"withdrawals_locked_until": lockedUntil,
+	})
+}
+
+// CleanupTestUsers godoc
+// @Summary Delete test accounts
+// @Description Permanently delete the accounts created by API tests: regular users whose username or email contains "test" followed by more characters and whose wallets are all empty. Each deletion is recorded in the audit log. Set dry_run to only list the matching accounts. Requires the users.delete permission (superadmin).
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.AdminTestUserCleanupRequest true "Reason and dry run"
+// @Success 200 {object} map[string]interface{} "Deleted or matching accounts"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/admin/users/test-cleanup [post]
+func (h *AdminUserHandler) CleanupTestUsers(c *gin.Context) {
+	actorID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminTestUserCleanupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to start admin action"})
+		return
+	}
+	defer tx.Rollback()
+
+	// Staff accounts and accounts holding funds are never matched
+	rows, err := tx.Query(`
+		SELECT u.id, u.username, u.email, u.status
+		FROM users u
+		WHERE u.role = 'user' AND (u.username LIKE '%test_%' OR u.email LIKE '%test_%@%')
+		  AND NOT EXISTS (
+			SELECT 1 FROM wallets w WHERE w.user_id = u.id AND (w.balance <> 0 OR w.frozen_balance <> 0)
+		  )
+		ORDER BY u.created_at
+		FOR UPDATE OF u
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to find test users"})
+		return
+	}
+
+	type testUser struct {
+		ID       uuid.UUID `json:"id"`
+		Username string    `json:"username"`
+		Email    string    `json:"email"`
+		Status   string    `json:"status"`
+	}
+	users := []testUser{}
+	for rows.Next() {
+		var u testUser
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Status); err != nil {
+			rows.Close()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to find test users"})
+			return
+		}
+		users = append(users, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to find test users"})
+		return
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "total": len(users), "users": users})
+		return
+	}
+
+	for _, u := range users {
+		if _, err := tx.Exec("DELETE FROM users WHERE id = $1", u.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete test users"})
+			return
+		}
+
+		err := recordAuditEvent(tx, c, auditEvent{
+			ActorID:    &actorID,
+			ActorRole:  c.GetString("role"),
+			Action:     "user.delete_test",
+			TargetType: "user",
+			TargetID:   u.ID.String(),
+			Reason:     req.Reason,
+			Before:     map[string]interface{}{"username": u.Username, "email": u.Email, "status": u.Status},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to delete test users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Deleted %d test users", len(users)),
+		"total":   len(users),
+		"users":   users,
+	})
+}
+
+// beginUserAction starts a transaction for an admin action on the user in the :id parameter. The
+// target row is locked. Staff can not act on themselves or on users with the same or a higher role.
+// On failure the response is written and ok is false.
+func (h *AdminUserHandler) beginUserAction(c *gin.Context) (*sql.Tx, *models.User, *models.User, bool) {
+	actorID, ok := contextUserID(c)
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid user ID"})
+		return nil, nil, nil, false
+	}
+	if targetID == actorID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "self_action", "message": "You can not perform admin actions on your own account"})
+		return nil, nil, nil, false
+	}
+
+	actor := &models.User{ID: actorID, Role: c.GetString("role")}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to start admin action"})
+		return nil, nil, nil, false
+	}
+
+	if _, err := tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", targetID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to lock user"})
+		return nil, nil, nil, false
+	}
+	target, err := userByID(tx, targetID)
+	if err == sql.ErrNoRows || (err == nil && target.DeletedAt != nil) {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "user_not_found", "message": "User not found"})
+		return nil, nil, nil, false
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return nil, nil, nil, false
+	}
+
+	if roleRank[actor.Role] <= roleRank[target.Role] {
+		tx.Rollback()
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": "You can not perform admin actions on this account"})
+		return nil, nil, nil, false
+	}
+
+	return tx, actor, target, true
+}
+
+// userByID loads a user including closed (soft-deleted) accounts
+func userByID(q limitQueryer, userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := q.QueryRow(`
+		SELECT id, first_name, last_name, username, email, password,
+			   email_status, phone_number, phone_status, referred_by, referral_code,
+			   address, city, country, role, status, kyc_status,
+			   twofa_enabled, password_reset_required, last_login_at, last_login_ip, device_info,
+			   language, timezone, global_balance, created_at, updated_at, deleted_at
+		FROM users
+		WHERE id = $1
+	`, userID).Scan(
+		&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Email, &user.Password,
+		&user.EmailStatus, &user.PhoneNumber, &user.PhoneStatus, &user.ReferredBy, &user.ReferralCode,
+		&user.Address, &user.City, &user.Country, &user.Role, &user.Status, &user.KYCStatus,
+		&user.TwoFAEnabled, &user.PasswordResetRequired, &user.LastLoginAt, &user.LastLoginIP, &user.DeviceInfo,
+		&user.Language, &user.Timezone, &user.GlobalBalance, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
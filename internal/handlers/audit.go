@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// auditChainLockKey is the transaction-level advisory lock that serializes appends to the
+// audit hash chain
+const auditChainLockKey = 4_217_306_155
+
+// auditExecer is implemented by *sql.DB and *sql.Tx, so events can be written in the same
+// transaction as the change they describe
+type auditExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// auditEvent describes a staff action or security relevant change
+type auditEvent struct {
+	ActorID    *uuid.UUID // nil for system actions
+	ActorRole  string
+	Action     string // e.g. user.suspend
+	TargetType string // e.g. user
+	TargetID   string
+	Reason     string
+	Details    map[string]interface{}
+	Before     interface{} // State before the change; only fields that differ from After are stored
+	After      interface{} // State after the change
+}
+
+// recordAuditEvent appends an event to the audit hash chain. The IP address, user agent and
+// request ID are taken from the request when c is not nil. Pass the transaction making the
+// change, so the event is stored only if the change is; the chain stays locked until it ends.
+func recordAuditEvent(db auditExecer, c *gin.Context, event auditEvent) error {
+	if database, ok := db.(*sql.DB); ok {
+		tx, err := database.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := recordAuditEvent(tx, c, event); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	record := models.AuditEvent{
+		ActorID:    event.ActorID,
+		ActorRole:  auditText(event.ActorRole),
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Reason:     auditText(event.Reason),
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if c != nil {
+		record.IPAddress = auditText(c.ClientIP())
+		record.UserAgent = auditText(c.Request.UserAgent())
+		record.RequestID = auditText(c.GetString("requestID"))
+	}
+
+	var err error
+	if event.Details != nil {
+		if record.Details, err = json.Marshal(event.Details); err != nil {
+			return err
+		}
+	}
+	if record.Before, record.After, err = auditDiff(event.Before, event.After); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec("SELECT pg_advisory_xact_lock($1)", auditChainLockKey); err != nil {
+		return err
+	}
+
+	var prevHash string
+	err = db.QueryRow("SELECT hash FROM audit_events WHERE hash IS NOT NULL ORDER BY id DESC LIMIT 1").Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	hash, err := models.AuditEventHash(prevHash, &record)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO audit_events (
+			actor_id, actor_role, action, target_type, target_id, reason, details, before, after,
+			ip_address, user_agent, request_id, prev_hash, hash, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`, record.ActorID, record.ActorRole, record.Action, record.TargetType, record.TargetID, record.Reason,
+		auditJSON(record.Details), auditJSON(record.Before), auditJSON(record.After),
+		record.IPAddress, record.UserAgent, record.RequestID, prevHash, hash, record.CreatedAt)
+	return err
+}
+
+// auditDiff returns the top-level fields of before and after that differ. Both are encoded
+// as JSON objects first, so structs are compared by their JSON fields.
+func auditDiff(before, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changedBefore := map[string]interface{}{}
+	changedAfter := map[string]interface{}{}
+	for key, value := range beforeFields {
+		if other, ok := afterFields[key]; !ok || !reflect.DeepEqual(value, other) {
+			changedBefore[key] = value
+		}
+	}
+	for key, value := range afterFields {
+		if other, ok := beforeFields[key]; !ok || !reflect.DeepEqual(value, other) {
+			changedAfter[key] = value
+		}
+	}
+
+	var beforeJSON, afterJSON json.RawMessage
+	if len(changedBefore) > 0 {
+		if beforeJSON, err = json.Marshal(changedBefore); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(changedAfter) > 0 {
+		if afterJSON, err = json.Marshal(changedAfter); err != nil {
+			return nil, nil, err
+		}
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+// auditFields encodes a value as a JSON object and decodes it into a map
+func auditFields(value interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// auditText returns nil for empty strings and makes the rest storable as TEXT
+func auditText(value string) *string {
+	if value == "" {
+		return nil
+	}
+	value = strings.ToValidUTF8(strings.ReplaceAll(value, "\x00", ""), "�")
+	return &value
+}
+
+// auditJSON converts an optional JSON value into a JSONB query argument
+func auditJSON(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return []byte(raw)
+}
@@ -16,12 +16,14 @@ import (
 )
 
 type AuthHandler struct {
-	DB *sql.DB
+	DB        *sql.DB
+	Screening *services.ScreeningService
 }
 
-func NewAuthHandler(db *sql.DB) *AuthHandler {
+func NewAuthHandler(db *sql.DB, screening *services.ScreeningService) *AuthHandler {
 	return &AuthHandler{
-		DB: db,
+		DB:        db,
+		Screening: screening,
 	}
 }
 
@@ -76,18 +78,34 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Parse referred_by UUID if provided
-	var referredByUUID *uuid.UUID
-	if req.ReferredBy != nil && *req.ReferredBy != "" {
-		parsedUUID, err := uuid.Parse(*req.ReferredBy)
+	// Normalize phone number to E.164 if provided
+	if req.PhoneNumber != nil && *req.PhoneNumber != "" {
+		normalized, err := services.NormalizePhoneNumber(*req.PhoneNumber)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"error":   "invalid_referral",
-				"message": "Invalid referral UUID format",
+				"error":   "invalid_phone_number",
+				"message": err.Error(),
 			})
 			return
 		}
-		referredByUUID = &parsedUUID
+		req.PhoneNumber = &normalized
+	}
+
+	// Resolve the referrer from the referral code, or the deprecated referred_by UUID
+	referredByUUID, err := resolveReferrer(h.DB, req.ReferralCode, req.ReferredBy)
+	if err == errInvalidReferral {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_referral",
+			"message": "Invalid referral code",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to check referral code",
+		})
+		return
 	}
 
 	// Set default values for optional fields
@@ -136,6 +154,13 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	// Screen the name against sanctions lists. A hit opens a compliance case that blocks login;
+	// the response is unchanged so the registrant is not tipped off. The user is created with
+	// name_screening_pending set, so a failed screening is retried at login.
+	if _, err := screenUserName(h.DB, h.Screening, user.ID, user.FirstName, user.LastName); err != nil {
+		fmt.Printf("Failed to screen registration: %v\n", err)
+	}
+
 	// Create response (excluding sensitive data)
 	userResponse := models.UserResponse{
 		ID:            user.ID,
@@ -153,6 +178,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Status:        user.Status,
 		KYCStatus:     user.KYCStatus,
 		TwoFAEnabled:  user.TwoFAEnabled,
+		ReferralCode:  user.ReferralCode,
 		Language:      user.Language,
 		Timezone:      user.Timezone,
 		GlobalBalance: user.GlobalBalance,
@@ -178,6 +204,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
 // @Failure 401 {object} map[string]interface{} "Unauthorized - invalid credentials"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 503 {object} map[string]interface{} "Sanctions screening of the account not completed yet"
 // @Router /api/v1/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
@@ -241,6 +268,67 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// A password reset forced by an administrator has to be completed first
+	if user.PasswordResetRequired {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "password_reset_required",
+			"message": "A password reset is required. Request a reset code and set a new password.",
+		})
+		return
+	}
+
+	// Names whose screening did not complete are screened again; sign-in waits until it succeeds
+	var screeningPending bool
+	if err := h.DB.QueryRow("SELECT name_screening_pending FROM users WHERE id = $1", user.ID).Scan(&screeningPending); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to authenticate user",
+		})
+		return
+	}
+	if screeningPending {
+		if _, err := screenUserName(h.DB, h.Screening, user.ID, user.FirstName, user.LastName); err != nil {
+			fmt.Printf("Failed to screen user %s at login: %v\n", user.ID, err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "account_verification_pending",
+				"message": "Your account is still being verified. Please try again shortly.",
+			})
+			return
+		}
+	}
+
+	// Registrations that hit a sanctions list can not sign in until compliance clears them
+	if screeningCase, err := getBlockingScreeningCase(h.DB, user.ID, "registration", ""); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to authenticate user",
+		})
+		return
+	} else if screeningCase != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "account_under_review",
+			"message": "Your account is under review. Please contact support.",
+		})
+		return
+	}
+
+	// Record the sign-in and run the login monitoring rules; a freeze rule can block the sign-in
+	if event, err := recordLoginEvent(h.DB, user.ID, c.ClientIP(), c.GetHeader("User-Agent"), c.GetHeader("X-Device-ID")); err != nil {
+		fmt.Printf("Failed to record login event: %v\n", err)
+	} else {
+		frozen, err := evaluateMonitoringEvent(h.DB, event)
+		if err != nil {
+			fmt.Printf("Failed to evaluate monitoring rules for login %s: %v\n", event.LoginEventID, err)
+		}
+		if frozen {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "account_inactive",
+				"message": "Account is not active. Please contact support.",
+			})
+			return
+		}
+	}
+
 	// Generate JWT tokens
 	tokens, err := models.GenerateTokens(user)
 	if err != nil {
@@ -274,6 +362,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		Status:        user.Status,
 		KYCStatus:     user.KYCStatus,
 		TwoFAEnabled:  user.TwoFAEnabled,
+		ReferralCode:  user.ReferralCode,
 		LastLoginAt:   user.LastLoginAt,
 		Language:      user.Language,
 		Timezone:      user.Timezone,
@@ -361,6 +450,14 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
+	if user.PasswordResetRequired {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "password_reset_required",
+			"message": "A password reset is required",
+		})
+		return
+	}
+
 	// Generate new tokens
 	newTokens, err := models.RefreshTokens(req.RefreshToken, user)
 	if err != nil {
@@ -468,6 +565,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		Status:        user.Status,
 		KYCStatus:     user.KYCStatus,
 		TwoFAEnabled:  user.TwoFAEnabled,
+		ReferralCode:  user.ReferralCode,
 		LastLoginAt:   user.LastLoginAt,
 		Language:      user.Language,
 		Timezone:      user.Timezone,
@@ -507,19 +605,22 @@ func (h *AuthHandler) createUser(user *models.User) error {
 			id, first_name, last_name, username, email, password,
 			email_status, phone_number, phone_status, referred_by,
 			address, city, country, role, status, kyc_status,
-			twofa_enabled, language, timezone, global_balance, created_at, updated_at
+			twofa_enabled, language, timezone, global_balance, created_at, updated_at,
+			name_screening_pending
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
-			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22
+			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22,
+			TRUE
 		)
+		RETURNING referral_code
 	`
 
-	_, err := h.DB.Exec(query,
+	err := h.DB.QueryRow(query,
 		user.ID, user.FirstName, user.LastName, user.Username, user.Email, user.Password,
 		user.EmailStatus, user.PhoneNumber, user.PhoneStatus, user.ReferredBy,
 		user.Address, user.City, user.Country, user.Role, user.Status, user.KYCStatus,
 		user.TwoFAEnabled, user.Language, user.Timezone, user.GlobalBalance, user.CreatedAt, user.UpdatedAt,
-	)
+	).Scan(&user.ReferralCode)
 
 	return err
 }
@@ -529,9 +630,9 @@ func (h *AuthHandler) getUserByEmail(email string) (*models.User, error) {
 	var user models.User
 	query := `
 		SELECT id, first_name, last_name, username, email, password,
-			   email_status, phone_number, phone_status, referred_by,
+			   email_status, phone_number, phone_status, referred_by, referral_code,
 			   address, city, country, role, status, kyc_status,
-			   twofa_enabled, last_login_at, last_login_ip, device_info,
+			   twofa_enabled, password_reset_required, last_login_at, last_login_ip, device_info,
 			   language, timezone, global_balance, created_at, updated_at, deleted_at
 		FROM users 
 		WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL
@@ -539,9 +640,9 @@ func (h *AuthHandler) getUserByEmail(email string) (*models.User, error) {
 
 	err := h.DB.QueryRow(query, email).Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Email, &user.Password,
-		&user.EmailStatus, &user.PhoneNumber, &user.PhoneStatus, &user.ReferredBy,
+		&user.EmailStatus, &user.PhoneNumber, &user.PhoneStatus, &user.ReferredBy, &user.ReferralCode,
 		&user.Address, &user.City, &user.Country, &user.Role, &user.Status, &user.KYCStatus,
-		&user.TwoFAEnabled, &user.LastLoginAt, &user.LastLoginIP, &user.DeviceInfo,
+		&user.TwoFAEnabled, &user.PasswordResetRequired, &user.LastLoginAt, &user.LastLoginIP, &user.DeviceInfo,
 		&user.Language, &user.Timezone, &user.GlobalBalance, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
 	)
 
@@ -557,9 +658,9 @@ func (h *AuthHandler) getUserByID(userID uuid.UUID) (*models.User, error) {
 	var user models.User
 	query := `
 		SELECT id, first_name, last_name, username, email, password,
-			   email_status, phone_number, phone_status, referred_by,
+			   email_status, phone_number, phone_status, referred_by, referral_code,
 			   address, city, country, role, status, kyc_status,
-			   twofa_enabled, last_login_at, last_login_ip, device_info,
+			   twofa_enabled, password_reset_required, last_login_at, last_login_ip, device_info,
 			   language, timezone, global_balance, created_at, updated_at, deleted_at
 		FROM users 
 		WHERE id = $1 AND deleted_at IS NULL
@@ -567,9 +668,9 @@ func (h *AuthHandler) getUserByID(userID uuid.UUID) (*models.User, error) {
 
 	err := h.DB.QueryRow(query, userID).Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Email, &user.Password,
-		&user.EmailStatus, &user.PhoneNumber, &user.PhoneStatus, &user.ReferredBy,
+		&user.EmailStatus, &user.PhoneNumber, &user.PhoneStatus, &user.ReferredBy, &user.ReferralCode,
 		&user.Address, &user.City, &user.Country, &user.Role, &user.Status, &user.KYCStatus,
-		&user.TwoFAEnabled, &user.LastLoginAt, &user.LastLoginIP, &user.DeviceInfo,
+		&user.TwoFAEnabled, &user.PasswordResetRequired, &user.LastLoginAt, &user.LastLoginIP, &user.DeviceInfo,
 		&user.Language, &user.Timezone, &user.GlobalBalance, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
 	)
 
@@ -594,7 +695,7 @@ func (h *AuthHandler) updateLastLogin(userID uuid.UUID, clientIP string) error {
 
 // RequestOTP godoc
 // @Summary Request an OTP code
-// @Description Generate and send an OTP code for email verification or other purposes
+// @Description Generate and send an OTP code for email verification or other purposes. Phone verification codes are sent by SMS, and 2fa codes can be sent by SMS with channel "sms" once the phone number is verified.
 // @Tags Authorization
 // @Accept json
 // @Produce json
@@ -656,6 +757,34 @@ func (h *AuthHandler) RequestOTP(c *gin.Context) {
 		return
 	}
 
+	// Phone verification codes and SMS second-factor codes are sent by SMS
+	useSMS := req.Type == "phone-verification" || (req.Type == "2fa" && req.Channel == "sms")
+	if useSMS && (user.PhoneNumber == nil || *user.PhoneNumber == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "phone_required",
+			"message": "Add a phone number to your profile first",
+		})
+		return
+	}
+
+	// For phone-verification, check if already verified
+	if req.Type == "phone-verification" && user.PhoneStatus {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "already_verified",
+			"message": "Phone number is already verified",
+		})
+		return
+	}
+
+	// SMS can only be used as a second factor once the number is verified
+	if req.Type == "2fa" && req.Channel == "sms" && !user.PhoneStatus {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "phone_not_verified",
+			"message": "Verify your phone number before using SMS codes",
+		})
+		return
+	}
+
 	// Generate 6-digit OTP code
 	otpCode, err := h.generateOTPCode()
 	if err != nil {
@@ -697,15 +826,19 @@ func (h *AuthHandler) RequestOTP(c *gin.Context) {
 		return
 	}
 
+	if useSMS {
+		h.sendOTPSMS(c, req.Type, *user.PhoneNumber, otpCode)
+		return
+	}
+
 	// Send email with OTP code (for all types that use email)
-	emailService := services.NewEmailService()
+	emailService := userEmailService(h.DB, user.ID)
 
 	// Determine if this OTP type should send email
 	emailOTPTypes := map[string]bool{
 		"email-verification": true,
 		"password-reset":     true,
 		"2fa":                true,
-		"phone-verification": true,
 	}
 
 	shouldSendEmail := emailOTPTypes[req.Type]
@@ -873,12 +1006,58 @@ func (h *AuthHandler) VerifyOTP(c *gin.Context) {
 		}
 	}
 
+	// If phone verification, update user's phone_status
+	if req.Type == "phone-verification" {
+		_, err = h.DB.Exec(`
+			UPDATE users 
+			SET phone_status = TRUE, updated_at = NOW()
+			WHERE id = $1
+		`, claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "database_error",
+				"message": "Failed to update phone status",
+			})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "OTP verified successfully",
 		"verified": true,
 	})
 }
 
+// sendOTPSMS delivers an OTP code by SMS and writes the response
+func (h *AuthHandler) sendOTPSMS(c *gin.Context, otpType, phoneNumber, otpCode string) {
+	// Numbers stored before normalization was enforced may need cleaning up
+	normalized, err := services.NormalizePhoneNumber(phoneNumber)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_phone_number",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	smsSender := services.NewSMSSender()
+	if err := smsSender.Send(normalized, services.GetOTPSMSMessage(otpType, otpCode)); err != nil {
+		// Log error but don't fail the request - OTP is already saved
+		fmt.Printf("Failed to send SMS: %v\n", err)
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "OTP code generated, but SMS sending failed. Please contact support.",
+			"error":      "sms_send_failed",
+			"expires_in": 600,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "OTP code sent to your phone",
+		"expires_in": 600, // 10 minutes in seconds
+	})
+}
+
 // Logout godoc
 // @Summary Logout user
 // @Description Logout the current user and invalidate session
@@ -912,6 +1091,11 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 // generateOTPCode generates a random 6-digit OTP code
 func (h *AuthHandler) generateOTPCode() (string, error) {
+	return newOTPCode()
+}
+
+// newOTPCode generates a random 6-digit OTP code
+func newOTPCode() (string, error) {
 	// Generate random 6-digit code (000000 to 999999)
 	// Using crypto/rand for secure random generation
 	max := big.NewInt(1000000) // 0 to 999999
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// antiPhishingCodePattern limits codes to characters that are safe to render in emails
+var antiPhishingCodePattern = regexp.MustCompile(`^[A-Za-z0-9 _-]+$`)
+
+// SetAntiPhishingCode godoc
+// @Summary Set anti-phishing code
+// @Description Set or remove the personal anti-phishing code shown in every email sent by Bixor. The code is stored encrypted.
+// @Tags Authorization
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.SetAntiPhishingCodeRequest true "Anti-phishing code (empty to remove)"
+// @Success 200 {object} map[string]interface{} "Anti-phishing code updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/auth/security/anti-phishing [post]
+func (h *AuthHandler) SetAntiPhishingCode(c *gin.Context) {
+	token, err := h.valToken(c)
+	if err != nil {
+		return
+	}
+
+	var req models.SetAntiPhishingCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	code := strings.TrimSpace(req.AntiPhishingCode)
+
+	// An empty code removes it (stored as NULL)
+	var stored interface{}
+	action, failure := "user.anti_phishing_remove", "Failed to remove anti-phishing code"
+	if code != "" {
+		if !antiPhishingCodePattern.MatchString(code) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_anti_phishing_code",
+				"message": "Anti-phishing code may only contain letters, numbers, spaces, dashes and underscores",
+			})
+			return
+		}
+
+		encrypted, err := services.EncryptString(code)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "encryption_error",
+				"message": "Failed to encrypt anti-phishing code",
+			})
+			return
+		}
+		stored = encrypted
+		action, failure = "user.anti_phishing_set", "Failed to update anti-phishing code"
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE users SET anti_phishing_code = $1, updated_at = NOW() WHERE id = $2", stored, token.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": failure,
+		})
+		return
+	}
+
+	// The code itself is a secret shared with the user and is not recorded
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &token.UserID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   token.UserID.String(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to record audit event",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": failure,
+		})
+		return
+	}
+
+	if code == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Anti-phishing code removed successfully",
+			"enabled": false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Anti-phishing code updated successfully",
+		"enabled":            true,
+		"anti_phishing_code": maskAntiPhishingCode(code),
+	})
+}
+
+// GetAntiPhishingCode godoc
+// @Summary Get anti-phishing code status
+// @Description Check whether an anti-phishing code is set. The code is returned masked.
+// @Tags Authorization
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Anti-phishing code status"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/auth/security/anti-phishing [get]
+func (h *AuthHandler) GetAntiPhishingCode(c *gin.Context) {
+	token, err := h.valToken(c)
+	if err != nil {
+		return
+	}
+
+	code, err := getAntiPhishingCode(h.DB, token.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to retrieve anti-phishing code",
+		})
+		return
+	}
+
+	if code == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"enabled": false,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":            true,
+		"anti_phishing_code": maskAntiPhishingCode(code),
+	})
+}
+
+// getAntiPhishingCode loads and decrypts a user's anti-phishing code (empty if not set)
+func getAntiPhishingCode(db *sql.DB, userID uuid.UUID) (string, error) {
+	var encrypted sql.NullString
+	err := db.QueryRow("SELECT anti_phishing_code FROM users WHERE id = $1", userID).Scan(&encrypted)
+	if err != nil {
+		return "", err
+	}
+	if !encrypted.Valid || encrypted.String == "" {
+		return "", nil
+	}
+
+	return services.DecryptString(encrypted.String)
+}
+
+// userEmailService returns an email service that shows the user's anti-phishing code
+func userEmailService(db *sql.DB, userID uuid.UUID) *services.EmailService {
+	emailService := services.NewEmailService()
+
+	code, err := getAntiPhishingCode(db, userID)
+	if err != nil {
+		// Still send the email, just without the code
+		fmt.Printf("Failed to load anti-phishing code: %v\n", err)
+		return emailService
+	}
+
+	return emailService.WithAntiPhishingCode(code)
+}
+
+// maskAntiPhishingCode hides all but the first two characters of a code
+func maskAntiPhishingCode(code string) string {
+	if len(code) <= 2 {
+		return strings.Repeat("*", len(code))
+	}
+	return code[:2] + strings.Repeat("*", len(code)-2)
+}
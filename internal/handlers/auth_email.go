@@ -0,0 +1,591 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// getEmailChangeCooldown returns how long withdrawals stay locked after an email change.
+// The old address can cancel the change during the same period.
+func getEmailChangeCooldown() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("EMAIL_CHANGE_COOLDOWN_HOURS"))
+	if err != nil || hours <= 0 {
+		return 24 * time.Hour // Default 24 hours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// getFrontendURL returns the base URL used for links in emails
+func getFrontendURL() string {
+	frontendURL := os.Getenv("FRONTEND_URL")
+	if frontendURL == "" {
+		frontendURL = "http://localhost:3000"
+	}
+	return strings.TrimRight(frontendURL, "/")
+}
+
+// RequestEmailChange godoc
+// @Summary Request an email address change
+// @Description Start an email change. An OTP is sent to the new address and a notice with a cancel link to the current one. The current address and its verification status are kept until the new address is confirmed.
+// @Tags Authorization
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.ChangeEmailRequest true "Email change data"
+// @Success 200 {object} models.RequestOTPResponse "OTP sent to the new address"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors or invalid password"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "Conflict - email already in use"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/auth/email/change [post]
+func (h *AuthHandler) RequestEmailChange(c *gin.Context) {
+	token, err := h.valToken(c)
+	if err != nil {
+		return
+	}
+
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.getUserByID(token.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to retrieve user",
+		})
+		return
+	}
+
+	newEmail := strings.ToLower(strings.TrimSpace(req.NewEmail))
+	if newEmail == strings.ToLower(user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "same_email",
+			"message": "New email must be different from the current email",
+		})
+		return
+	}
+
+	// Verify current password
+	match, err := models.VerifyPassword(req.Password, user.Password)
+	if err != nil || !match {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_password",
+			"message": "Current password is incorrect",
+		})
+		return
+	}
+
+	if exists, err := h.checkEmailInUse(newEmail, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to check email availability",
+		})
+		return
+	} else if exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "email_exists",
+			"message": "Email is already in use",
+		})
+		return
+	}
+
+	// Generate the cancel token for the old address (only its hash is stored)
+	cancelToken, cancelTokenHash, err := generateCancelToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "token_generation_error",
+			"message": "Failed to generate cancel token",
+		})
+		return
+	}
+
+	if err := h.createEmailChange(user, newEmail, cancelTokenHash); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to create email change request",
+		})
+		return
+	}
+
+	otpCode, err := issueOTP(h.DB, user.ID, "email-change")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to save OTP",
+		})
+		return
+	}
+
+	emailService := userEmailService(h.DB, user.ID)
+	if !emailService.IsEnabled() {
+		// SMTP not enabled - return OTP and cancel token in response (development mode)
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Email change requested (SMTP not enabled)",
+			"otp_code":     otpCode, // Only in development
+			"cancel_token": cancelToken,
+			"expires_in":   int(otpTTL.Seconds()),
+		})
+		return
+	}
+
+	userName := fmt.Sprintf("%s %s", user.FirstName, user.LastName)
+	if userName == " " {
+		userName = user.Username
+	}
+
+	// Notify the current address first so the owner can always cancel
+	cancelURL := fmt.Sprintf("%s/cancel-email-change?token=%s", getFrontendURL(), url.QueryEscape(cancelToken))
+	if err := emailService.SendEmailChangeNotice(user.Email, userName, newEmail, cancelURL); err != nil {
+		fmt.Printf("Failed to send email change notice: %v\n", err)
+	}
+
+	if err := emailService.SendOTPEmail("email-change", newEmail, userName, otpCode); err != nil {
+		fmt.Printf("Failed to send email: %v\n", err)
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Email change requested, but email sending failed. Please contact support.",
+			"error":      "email_send_failed",
+			"expires_in": int(otpTTL.Seconds()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Verification code sent to your new email address",
+		"expires_in": int(otpTTL.Seconds()),
+	})
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm an email address change
+// @Description Confirm the new email address with the OTP sent to it. Withdrawals are locked for a cooling-off period afterwards.
+// @Tags Authorization
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.ConfirmEmailChangeRequest true "Email change confirmation data"
+// @Success 200 {object} models.UserResponse "Email changed successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid or expired OTP"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "No pending email change"
+// @Failure 409 {object} map[string]interface{} "Conflict - email already in use"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/auth/email/confirm [post]
+func (h *AuthHandler) ConfirmEmailChange(c *gin.Context) {
+	token, err := h.valToken(c)
+	if err != nil {
+		return
+	}
+
+	var req models.ConfirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	change, err := h.getPendingEmailChange(token.UserID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "no_pending_change",
+			"message": "No pending email change found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to retrieve email change",
+		})
+		return
+	}
+
+	if time.Now().After(change.ExpiresAt) {
+		h.DB.Exec("UPDATE email_changes SET status = 'expired', updated_at = NOW() WHERE id = $1", change.ID)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "change_expired",
+			"message": "Email change request has expired. Please start again.",
+		})
+		return
+	}
+
+	if err := consumeOTP(h.DB, token.UserID, "email-change", req.Code); err != nil {
+		respondOTPError(c, err)
+		return
+	}
+
+	// The address may have been taken since the change was requested
+	if exists, err := h.checkEmailInUse(change.NewEmail, change.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to check email availability",
+		})
+		return
+	} else if exists {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "email_exists",
+			"message": "Email is already in use",
+		})
+		return
+	}
+
+	lockedUntil := time.Now().Add(getEmailChangeCooldown())
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET email = $1, email_status = TRUE, withdrawals_locked_until = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING id, first_name, last_name, username, email, email_status,
+				  phone_number, phone_status, address, city, country,
+				  role, status, kyc_status, twofa_enabled, last_login_at,
+				  language, timezone, global_balance, created_at, updated_at
+	`
+
+	var user models.UserResponse
+	err = tx.QueryRow(query, change.NewEmail, lockedUntil, change.UserID).Scan(
+		&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Email, &user.EmailStatus,
+		&user.PhoneNumber, &user.PhoneStatus, &user.Address, &user.City, &user.Country,
+		&user.Role, &user.Status, &user.KYCStatus, &user.TwoFAEnabled, &user.LastLoginAt,
+		&user.Language, &user.Timezone, &user.GlobalBalance, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		// Another account took the address after the availability check
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "email_exists",
+				"message": "Email is already in use",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to update email",
+		})
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE email_changes
+		SET status = 'confirmed', confirmed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, change.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to update email change",
+		})
+		return
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &token.UserID,
+		Action:     "user.email_change",
+		TargetType: "user",
+		TargetID:   token.UserID.String(),
+		Before:     map[string]interface{}{"email": change.OldEmail, "email_status": change.OldEmailStatus},
+		After:      map[string]interface{}{"email": user.Email, "email_status": user.EmailStatus, "withdrawals_locked_until": lockedUntil},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to record audit event",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to commit email change",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                  "Email changed successfully",
+		"user":                     user,
+		"withdrawals_locked_until": lockedUntil,
+	})
+}
+
+// CancelEmailChange godoc
+// @Summary Cancel an email address change
+// @Description Cancel a pending or recently confirmed email change using the token sent to the old address. A confirmed change is reverted to the old address.
+// @Tags Authorization
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Param request body models.CancelEmailChangeRequest true "Cancel token"
+// @Success 200 {object} map[string]interface{} "Email change cancelled"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 404 {object} map[string]interface{} "Invalid or expired cancel token"
+// @Failure 409 {object} map[string]interface{} "Conflict - old email is no longer available"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/auth/email/cancel [post]
+func (h *AuthHandler) CancelEmailChange(c *gin.Context) {
+	var req models.CancelEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var change models.EmailChange
+	err := h.DB.QueryRow(`
+		SELECT id, user_id, old_email, old_email_status, new_email, status
+		FROM email_changes
+		WHERE cancel_token_hash = $1 AND status IN ('pending', 'confirmed') AND cancel_until > NOW()
+	`, hashToken(req.Token)).Scan(
+		&change.ID, &change.UserID, &change.OldEmail, &change.OldEmailStatus, &change.NewEmail, &change.Status,
+	)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "invalid_cancel_token",
+			"message": "Cancel link is invalid or has expired",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to retrieve email change",
+		})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	// A confirmed change is reverted to the old address and its status; a pending one never
+	// touched the account. Withdrawals stay locked since the account may be compromised.
+	if change.Status == "confirmed" {
+		if exists, err := h.checkEmailInUse(change.OldEmail, change.UserID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "database_error",
+				"message": "Failed to check email availability",
+			})
+			return
+		} else if exists {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "email_exists",
+				"message": "The previous email is no longer available. Please contact support.",
+			})
+			return
+		}
+
+		_, err = tx.Exec(`
+			UPDATE users
+			SET email = $1, email_status = $2, updated_at = NOW()
+			WHERE id = $3
+		`, change.OldEmail, change.OldEmailStatus, change.UserID)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":   "email_exists",
+					"message": "The previous email is no longer available. Please contact support.",
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "database_error",
+				"message": "Failed to restore email",
+			})
+			return
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE email_changes
+		SET status = 'cancelled', cancelled_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, change.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to cancel email change",
+		})
+		return
+	}
+
+	// Invalidate any outstanding code sent to the new address
+	_, err = tx.Exec(`
+		UPDATE otps
+		SET used = TRUE, updated_at = NOW()
+		WHERE user_id = $1 AND type = 'email-change' AND used = FALSE
+	`, change.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to invalidate OTP",
+		})
+		return
+	}
+
+	// The cancel link was sent to the old address, so its owner is the actor
+	event := auditEvent{
+		ActorID:    &change.UserID,
+		Action:     "user.email_change_cancel",
+		TargetType: "user",
+		TargetID:   change.UserID.String(),
+		Details:    map[string]interface{}{"change_status": change.Status},
+	}
+	if change.Status == "confirmed" {
+		event.Before = map[string]interface{}{"email": change.NewEmail}
+		event.After = map[string]interface{}{"email": change.OldEmail}
+	}
+	if err := recordAuditEvent(tx, c, event); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to record audit event",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to commit cancellation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email change cancelled successfully",
+	})
+}
+
+// checkEmailInUse checks if an email belongs to another user or is reserved by
+// another user's pending email change. The users.email unique constraint also
+// covers soft-deleted rows, so those are included.
+func (h *AuthHandler) checkEmailInUse(email string, userID uuid.UUID) (bool, error) {
+	var count int
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE LOWER(email) = LOWER($1) AND id != $2) +
+			(SELECT COUNT(*) FROM email_changes
+			 WHERE LOWER(new_email) = LOWER($1) AND user_id != $2
+			 AND status = 'pending' AND expires_at > NOW())
+	`
+
+	err := h.DB.QueryRow(query, email, userID).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// createEmailChange replaces any pending change for the user. The account keeps its current
+// address and email_status until the change is confirmed.
+func (h *AuthHandler) createEmailChange(user *models.User, newEmail, cancelTokenHash string) error {
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE email_changes
+		SET status = 'cancelled', cancelled_at = NOW(), updated_at = NOW()
+		WHERE user_id = $1 AND status = 'pending'
+	`, user.ID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO email_changes (
+			id, user_id, old_email, old_email_status, new_email, cancel_token_hash,
+			status, expires_at, cancel_until, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, 'pending', $7, $8, NOW(), NOW())
+	`, uuid.New(), user.ID, user.Email, user.EmailStatus, newEmail, cancelTokenHash,
+		now.Add(otpTTL), now.Add(getEmailChangeCooldown()))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getPendingEmailChange retrieves the latest pending email change for a user
+func (h *AuthHandler) getPendingEmailChange(userID uuid.UUID) (*models.EmailChange, error) {
+	var change models.EmailChange
+	err := h.DB.QueryRow(`
+		SELECT id, user_id, old_email, old_email_status, new_email, status,
+			   expires_at, cancel_until, created_at, updated_at
+		FROM email_changes
+		WHERE user_id = $1 AND status = 'pending'
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID).Scan(
+		&change.ID, &change.UserID, &change.OldEmail, &change.OldEmailStatus, &change.NewEmail, &change.Status,
+		&change.ExpiresAt, &change.CancelUntil, &change.CreatedAt, &change.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// generateCancelToken returns a random URL-safe token and its SHA-256 hash
+func generateCancelToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+// hashToken returns the hex-encoded SHA-256 hash of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
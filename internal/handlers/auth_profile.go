@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -38,10 +40,51 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	// Update user in database
+	// Normalize phone number to E.164 if provided
+	if req.PhoneNumber != nil && *req.PhoneNumber != "" {
+		normalized, err := services.NormalizePhoneNumber(*req.PhoneNumber)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_phone_number",
+				"message": err.Error(),
+			})
+			return
+		}
+		req.PhoneNumber = &normalized
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var before models.UserResponse
+	err = tx.QueryRow(`
+		SELECT first_name, last_name, phone_number, address, city, country
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	`, token.UserID).Scan(&before.FirstName, &before.LastName, &before.PhoneNumber, &before.Address, &before.City, &before.Country)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to retrieve user data",
+		})
+		return
+	}
+
+	// Update user in database (a changed phone number must be verified again, a changed name
+	// screened again)
 	query := `
 		UPDATE users 
 		SET first_name = $1, last_name = $2, phone_number = $3, 
+			phone_status = CASE WHEN phone_number IS DISTINCT FROM $3 THEN FALSE ELSE phone_status END,
+			name_screening_pending = name_screening_pending OR first_name IS DISTINCT FROM $1 OR last_name IS DISTINCT FROM $2,
 			address = $4, city = $5, country = $6, updated_at = NOW()
 		WHERE id = $7
 		RETURNING id, first_name, last_name, username, email, email_status, 
@@ -51,7 +94,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	`
 
 	var user models.UserResponse
-	err = h.DB.QueryRow(query,
+	err = tx.QueryRow(query,
 		req.FirstName, req.LastName, req.PhoneNumber,
 		req.Address, req.City, req.Country,
 		token.UserID,
@@ -70,12 +113,74 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	// Codes sent to the old number must not verify the new one
+	phoneChanged := (before.PhoneNumber == nil) != (user.PhoneNumber == nil) ||
+		(before.PhoneNumber != nil && *before.PhoneNumber != *user.PhoneNumber)
+	if phoneChanged {
+		_, err = tx.Exec(`
+			UPDATE otps
+			SET used = TRUE, updated_at = NOW()
+			WHERE user_id = $1 AND type = 'phone-verification' AND used = FALSE
+		`, token.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "database_error",
+				"message": "Failed to invalidate previous OTPs",
+			})
+			return
+		}
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &token.UserID,
+		Action:     "user.profile_update",
+		TargetType: "user",
+		TargetID:   token.UserID.String(),
+		Before:     profileAuditState(&before),
+		After:      profileAuditState(&user),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to record audit event",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to update profile",
+		})
+		return
+	}
+
+	// Screen a changed name against sanctions lists. A hit opens a compliance case that blocks login;
+	// a failed screening leaves name_screening_pending set so login retries it.
+	if before.FirstName != user.FirstName || before.LastName != user.LastName {
+		if _, err := screenUserName(h.DB, h.Screening, token.UserID, user.FirstName, user.LastName); err != nil {
+			fmt.Printf("Failed to screen profile name: %v\n", err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Profile updated successfully",
 		"user":    user,
 	})
 }
 
+// profileAuditState returns the profile fields recorded in audit events
+func profileAuditState(user *models.UserResponse) map[string]interface{} {
+	return map[string]interface{}{
+		"first_name":   user.FirstName,
+		"last_name":    user.LastName,
+		"phone_number": user.PhoneNumber,
+		"address":      user.Address,
+		"city":         user.City,
+		"country":      user.Country,
+	}
+}
+
 // valToken is a helper to validate token and return claims
 func (h *AuthHandler) valToken(c *gin.Context) (*models.JWTClaims, error) {
 	authHeader := c.GetHeader("Authorization")
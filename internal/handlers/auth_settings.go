@@ -39,6 +39,26 @@ func (h *AuthHandler) UpdateSettings(c *gin.Context) {
 		return
 	}
 
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var before models.UserResponse
+	err = tx.QueryRow("SELECT language, timezone FROM users WHERE id = $1 FOR UPDATE", token.UserID).Scan(&before.Language, &before.Timezone)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to retrieve user data",
+		})
+		return
+	}
+
 	query := `
 		UPDATE users 
 		SET language = $1, timezone = $2, updated_at = NOW()
@@ -50,7 +70,7 @@ func (h *AuthHandler) UpdateSettings(c *gin.Context) {
 	`
 
 	var user models.UserResponse
-	err = h.DB.QueryRow(query, req.Language, req.Timezone, token.UserID).Scan(
+	err = tx.QueryRow(query, req.Language, req.Timezone, token.UserID).Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Email, &user.EmailStatus,
 		&user.PhoneNumber, &user.PhoneStatus, &user.Address, &user.City, &user.Country,
 		&user.Role, &user.Status, &user.KYCStatus, &user.TwoFAEnabled, &user.LastLoginAt,
@@ -65,6 +85,30 @@ func (h *AuthHandler) UpdateSettings(c *gin.Context) {
 		return
 	}
 
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &token.UserID,
+		Action:     "user.settings_update",
+		TargetType: "user",
+		TargetID:   token.UserID.String(),
+		Before:     map[string]interface{}{"language": before.Language, "timezone": before.Timezone},
+		After:      map[string]interface{}{"language": user.Language, "timezone": user.Timezone},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to record audit event",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to update settings",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Settings updated successfully",
 		"user":    user,
@@ -133,7 +177,17 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	}
 
 	// 4. Update password
-	_, err = h.DB.Exec("UPDATE users SET password = $1, updated_at = NOW() WHERE id = $2", newHash, token.UserID)
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE users SET password = $1, updated_at = NOW() WHERE id = $2", newHash, token.UserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "database_error",
@@ -142,6 +196,28 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &token.UserID,
+		Action:     "user.password_change",
+		TargetType: "user",
+		TargetID:   token.UserID.String(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to record audit event",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to update password",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Password changed successfully",
 	})
@@ -216,6 +292,25 @@ func (h *AuthHandler) ToggleTwoFA(c *gin.Context) {
 	h.DB.Exec("UPDATE otps SET used = TRUE, updated_at = NOW() WHERE id = $1", otp.ID)
 
 	// 3. Update User 2FA Status
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	var wasEnabled bool
+	if err := tx.QueryRow("SELECT twofa_enabled FROM users WHERE id = $1 FOR UPDATE", token.UserID).Scan(&wasEnabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to retrieve user data",
+		})
+		return
+	}
+
 	query := `
 		UPDATE users 
 		SET twofa_enabled = $1, updated_at = NOW()
@@ -227,7 +322,7 @@ func (h *AuthHandler) ToggleTwoFA(c *gin.Context) {
 	`
 
 	var user models.UserResponse
-	err = h.DB.QueryRow(query, req.Enable, token.UserID).Scan(
+	err = tx.QueryRow(query, req.Enable, token.UserID).Scan(
 		&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Email, &user.EmailStatus,
 		&user.PhoneNumber, &user.PhoneStatus, &user.Address, &user.City, &user.Country,
 		&user.Role, &user.Status, &user.KYCStatus, &user.TwoFAEnabled, &user.LastLoginAt,
@@ -242,6 +337,34 @@ func (h *AuthHandler) ToggleTwoFA(c *gin.Context) {
 		return
 	}
 
+	action := "user.2fa_enable"
+	if !req.Enable {
+		action = "user.2fa_disable"
+	}
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &token.UserID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   token.UserID.String(),
+		Before:     map[string]interface{}{"twofa_enabled": wasEnabled},
+		After:      map[string]interface{}{"twofa_enabled": user.TwoFAEnabled},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to record audit event",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to update 2FA status",
+		})
+		return
+	}
+
 	message := "2FA enabled successfully"
 	if !req.Enable {
 		message = "2FA disabled successfully"
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+)
+
+// candleOrigin aligns candle buckets: midnight UTC on a Monday, so weekly candles start on Mondays
+const candleOrigin = "2000-01-03T00:00:00Z"
+
+// candleRollupOverlap is how far each aggregator run looks back before the previous run, so
+// trades committed by transactions that started before it are not missed
+const candleRollupOverlap = time.Minute
+
+// candleTimeframe is a candle resolution and the lower one it is rebuilt from
+type candleTimeframe struct {
+	Name   string
+	Step   time.Duration
+	Source string // Empty for 1m, which is written with every trade
+}
+
+// candleTimeframes is ordered so every timeframe comes after its source
+var candleTimeframes = []candleTimeframe{
+	{Name: "1m", Step: time.Minute},
+	{Name: "5m", Step: 5 * time.Minute, Source: "1m"},
+	{Name: "15m", Step: 15 * time.Minute, Source: "5m"},
+	{Name: "1h", Step: time.Hour, Source: "15m"},
+	{Name: "4h", Step: 4 * time.Hour, Source: "1h"},
+	{Name: "1d", Step: 24 * time.Hour, Source: "1h"},
+	{Name: "1w", Step: 7 * 24 * time.Hour, Source: "1d"},
+}
+
+// getCandleTimeframe returns a timeframe by name
+func getCandleTimeframe(name string) (candleTimeframe, bool) {
+	for _, tf := range candleTimeframes {
+		if tf.Name == name {
+			return tf, true
+		}
+	}
+	return candleTimeframe{}, false
+}
+
+// recordTradeCandle adds a trade to the 1m candle of its minute. Call it in the transaction
+// that records the trade; trades of a market are settled in order, so the last one closes.
+func recordTradeCandle(tx *sql.Tx, marketID int, trade *models.PublicTrade) error {
+	_, err := tx.Exec(`
+		INSERT INTO candles (market_id, timeframe, open_time, open, high, low, close, volume, quote_volume, trade_count)
+		VALUES ($1, '1m', date_bin('1 minute', $2::timestamptz, $3::timestamptz), $4, $4, $4, $4, $5, $6, 1)
+		ON CONFLICT (market_id, timeframe, open_time) DO UPDATE SET
+			high = GREATEST(candles.high, EXCLUDED.high),
+			low = LEAST(candles.low, EXCLUDED.low),
+			close = EXCLUDED.close,
+			volume = candles.volume + EXCLUDED.volume,
+			quote_volume = candles.quote_volume + EXCLUDED.quote_volume,
+			trade_count = candles.trade_count + 1,
+			updated_at = NOW()
+	`, marketID, trade.CreatedAt, candleOrigin, trade.Price, trade.Quantity, trade.QuoteQuantity)
+	return err
+}
+
+// CandleAggregator rebuilds the 5m to 1w candles from the next lower timeframe. Each run only
+// recomputes the buckets whose source candles changed, and recomputing is idempotent, so any
+// number of API instances can run it.
+type CandleAggregator struct {
+	DB    *sql.DB
+	since time.Time
+}
+
+func NewCandleAggregator(db *sql.DB) *CandleAggregator {
+	return &CandleAggregator{DB: db}
+}
+
+// getCandleRollupInterval returns the pause between two aggregator runs, which is how long
+// higher timeframes can lag behind the 1m candles
+func getCandleRollupInterval() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("CANDLES_ROLLUP_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return 5 * time.Second // Default 5 seconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Start runs the aggregator in the background until the process exits. Set
+// CANDLES_ROLLUP_ENABLED=false to leave it to other instances.
+func (a *CandleAggregator) Start() {
+	if os.Getenv("CANDLES_ROLLUP_ENABLED") == "false" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(getCandleRollupInterval())
+		defer ticker.Stop()
+		for {
+			a.RunOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+// RunOnce brings every higher timeframe up to date. The first run continues from the newest
+// rebuilt candle, so candles missed while no instance was running are caught up.
+func (a *CandleAggregator) RunOnce() {
+	if a.since.IsZero() {
+		var last sql.NullTime
+		err := a.DB.QueryRow("SELECT MAX(updated_at) FROM candles WHERE timeframe <> '1m'").Scan(&last)
+		if err != nil {
+			fmt.Printf("Failed to load candle aggregation state: %v\n", err)
+			return
+		}
+		a.since = time.Unix(0, 0)
+		if last.Valid {
+			a.since = last.Time.Add(-candleRollupOverlap)
+		}
+	}
+
+	started := time.Now()
+	for _, tf := range candleTimeframes {
+		if tf.Source == "" {
+			continue
+		}
+		if err := a.rollup(tf, a.since); err != nil {
+			// The next run starts from the same point again
+			fmt.Printf("Failed to aggregate %s candles: %v\n", tf.Name, err)
+			return
+		}
+	}
+	a.since = started.Add(-candleRollupOverlap)
+}
+
+// rollup recomputes the candles of a timeframe whose source candles changed since the given time
+func (a *CandleAggregator) rollup(tf candleTimeframe, since time.Time) error {
+	step := fmt.Sprintf("%d seconds", int64(tf.Step/time.Second))
+	_, err := a.DB.Exec(`
+		INSERT INTO candles (market_id, timeframe, open_time, open, high, low, close, volume, quote_volume, trade_count)
+		SELECT c.market_id, $1, b.open_time,
+			(array_agg(c.open ORDER BY c.open_time))[1],
+			MAX(c.high), MIN(c.low),
+			(array_agg(c.close ORDER BY c.open_time DESC))[1],
+			SUM(c.volume), SUM(c.quote_volume), SUM(c.trade_count)
+		FROM (
+			SELECT DISTINCT market_id, date_bin($3::interval, open_time, $4::timestamptz) AS open_time
+			FROM candles
+			WHERE timeframe = $2 AND updated_at >= $5
+		) b
+		JOIN candles c ON c.market_id = b.market_id AND c.timeframe = $2
+			AND c.open_time >= b.open_time AND c.open_time < b.open_time + $3::interval
+		GROUP BY c.market_id, b.open_time
+		ON CONFLICT (market_id, timeframe, open_time) DO UPDATE SET
+			open = EXCLUDED.open,
+			high = EXCLUDED.high,
+			low = EXCLUDED.low,
+			close = EXCLUDED.close,
+			volume = EXCLUDED.volume,
+			quote_volume = EXCLUDED.quote_volume,
+			trade_count = EXCLUDED.trade_count,
+			updated_at = NOW()
+	`, tf.Name, tf.Source, step, candleOrigin, since)
+	return err
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"database/sql"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/lib/pq"
+)
+
+const coinColumns = `id, name, ticker, decimal, price_decimal, logo, price,
+	deposit_gateway, withdraw_gateway, deposit_fee, withdraw_fee,
+	deposit_fee_type, withdraw_fee_type, confirmation, status,
+	withdraw_status, deposit_status, website, explorer, explorer_tx,
+	explorer_address, created_at, updated_at`
+
+// CoinCache keeps the coin list in memory for the public currency endpoints. It is
+// reloaded when stale and invalidated whenever an admin changes a coin.
+type CoinCache struct {
+	db       *sql.DB
+	ttl      time.Duration
+	mu       sync.RWMutex
+	coins    []models.Coin
+	loadedAt time.Time
+}
+
+// NewCoinCache creates a cache backed by the coins table
+func NewCoinCache(db *sql.DB) *CoinCache {
+	return &CoinCache{
+		db:  db,
+		ttl: getCoinsCacheTTL(),
+	}
+}
+
+// getCoinsCacheTTL retrieves how long the coin list is cached
+func getCoinsCacheTTL() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("COINS_CACHE_SECONDS"))
+	if err != nil || seconds < 0 {
+		return 60 * time.Second // Default 60 seconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Coins returns all coins, including inactive ones, ordered by name
+func (cc *CoinCache) Coins() ([]models.Coin, error) {
+	cc.mu.RLock()
+	if !cc.loadedAt.IsZero() && time.Since(cc.loadedAt) < cc.ttl {
+		coins := cc.coins
+		cc.mu.RUnlock()
+		return coins, nil
+	}
+	cc.mu.RUnlock()
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	// Another request may have reloaded while we waited for the lock
+	if !cc.loadedAt.IsZero() && time.Since(cc.loadedAt) < cc.ttl {
+		return cc.coins, nil
+	}
+
+	coins, err := cc.load()
+	if err != nil {
+		return nil, err
+	}
+
+	cc.coins = coins
+	cc.loadedAt = time.Now()
+	return cc.coins, nil
+}
+
+// ActiveCoin returns an active coin by ticker, or nil if there is none
+func (cc *CoinCache) ActiveCoin(ticker string) (*models.Coin, error) {
+	coins, err := cc.Coins()
+	if err != nil {
+		return nil, err
+	}
+	for i := range coins {
+		if coins[i].Status == 1 && strings.EqualFold(coins[i].Ticker, ticker) {
+			coin := coins[i]
+			return &coin, nil
+		}
+	}
+	return nil, nil
+}
+
+// Invalidate drops the cached list so the next read loads it from the database
+func (cc *CoinCache) Invalidate() {
+	cc.mu.Lock()
+	cc.coins = nil
+	cc.loadedAt = time.Time{}
+	cc.mu.Unlock()
+}
+
+// load reads all coins from the database
+func (cc *CoinCache) load() ([]models.Coin, error) {
+	rows, err := cc.db.Query("SELECT " + coinColumns + " FROM coins ORDER BY name ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coins := make([]models.Coin, 0)
+	for rows.Next() {
+		coin, err := scanCoin(rows)
+		if err != nil {
+			return nil, err
+		}
+		coins = append(coins, *coin)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return coins, nil
+}
+
+// scanCoin scans a row selected with coinColumns
+func scanCoin(row rowScanner) (*models.Coin, error) {
+	var coin models.Coin
+	var depositGateway, withdrawGateway pq.StringArray
+	var price sql.NullString
+	var depositFee, withdrawFee sql.NullString
+
+	err := row.Scan(
+		&coin.ID, &coin.Name, &coin.Ticker, &coin.Decimal, &coin.PriceDecimal,
+		&coin.Logo, &price, &depositGateway, &withdrawGateway,
+		&depositFee, &withdrawFee, &coin.DepositFeeType, &coin.WithdrawFeeType,
+		&coin.Confirmation, &coin.Status, &coin.WithdrawStatus, &coin.DepositStatus,
+		&coin.Website, &coin.Explorer, &coin.ExplorerTx, &coin.ExplorerAddress,
+		&coin.CreatedAt, &coin.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert arrays
+	coin.DepositGateway = []string(depositGateway)
+	coin.WithdrawGateway = []string(withdrawGateway)
+
+	// Convert price and fees
+	if price.Valid {
+		coin.Price = price.String
+	} else {
+		coin.Price = "0"
+	}
+
+	if depositFee.Valid {
+		coin.DepositFee = &depositFee.String
+	}
+
+	if withdrawFee.Valid {
+		coin.WithdrawFee = &withdrawFee.String
+	}
+
+	return &coin, nil
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const auditEventColumns = `id, actor_id, actor_role, action, target_type, target_id, reason, details, before, after,
+	ip_address, user_agent, request_id, prev_hash, hash, created_at`
+
+type AuditHandler struct {
+	DB *sql.DB
+}
+
+func NewAuditHandler(db *sql.DB) *AuditHandler {
+	return &AuditHandler{DB: db}
+}
+
+// ListAuditEvents godoc
+// @Summary Query the audit log
+// @Description Security and staff actions with actor, target, before/after diff, IP address and request ID, newest first. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param actor_id query string false "User who performed the action"
+// @Param target_type query string false "Target type, e.g. user or coin"
+// @Param target_id query string false "Target ID"
+// @Param action query string false "Action, e.g. user.suspend; a trailing * matches a prefix, e.g. user.*"
+// @Param request_id query string false "Request ID (X-Request-ID)"
+// @Param from query string false "Events at or after this time (RFC 3339)"
+// @Param to query string false "Events before this time (RFC 3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{} "List of audit events with pagination"
+// @Failure 400 {object} map[string]interface{} "Invalid filter"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/audit [get]
+func (h *AuditHandler) ListAuditEvents(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	offset := (page - 1) * limit
+
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_filter", "message": "Invalid actor_id"})
+			return
+		}
+		addCondition("actor_id = ?", id)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		addCondition("target_type = ?", targetType)
+	}
+	if targetID := c.Query("target_id"); targetID != "" {
+		addCondition("target_id = ?", targetID)
+	}
+	if action := c.Query("action"); action != "" {
+		if prefix, ok := strings.CutSuffix(action, "*"); ok {
+			addCondition("starts_with(action, ?)", prefix)
+		} else {
+			addCondition("action = ?", action)
+		}
+	}
+	if requestID := c.Query("request_id"); requestID != "" {
+		addCondition("request_id = ?", requestID)
+	}
+	for _, bound := range []struct {
+		param     string
+		condition string
+	}{{"from", "created_at >= ?"}, {"to", "created_at < ?"}} {
+		if raw := c.Query(bound.param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_filter", "message": "Invalid " + bound.param + ", expected RFC 3339"})
+				return
+			}
+			addCondition(bound.condition, t)
+		}
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	events, err := queryAuditEvents(h.DB, where+" ORDER BY id DESC LIMIT "+strconv.Itoa(limit)+" OFFSET "+strconv.Itoa(offset), args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch audit events"})
+		return
+	}
+
+	var total int
+	if err := h.DB.QueryRow("SELECT COUNT(*) FROM audit_events "+where, args...).Scan(&total); err != nil {
+		total = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  events,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// queryAuditEvents selects audit events; clause holds the WHERE, ORDER BY and LIMIT parts
+func queryAuditEvents(db *sql.DB, clause string, args ...interface{}) ([]models.AuditEvent, error) {
+	rows, err := db.Query("SELECT "+auditEventColumns+" FROM audit_events "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	return events, rows.Err()
+}
+
+// scanAuditEvent scans a row selected with auditEventColumns
+func scanAuditEvent(row rowScanner) (*models.AuditEvent, error) {
+	var event models.AuditEvent
+	var details, before, after []byte
+	err := row.Scan(&event.ID, &event.ActorID, &event.ActorRole, &event.Action, &event.TargetType, &event.TargetID,
+		&event.Reason, &details, &before, &after, &event.IPAddress, &event.UserAgent, &event.RequestID,
+		&event.PrevHash, &event.Hash, &event.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	event.Details = details
+	event.Before = before
+	event.After = after
+	return &event, nil
+}
@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errKYCNotPending = errors.New("submission is not pending")
+
+// ListKYCSubmissions godoc
+// @Summary List KYC submissions
+// @Description Review queue of KYC submissions, oldest first. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param status query string false "pending (default), approved, rejected or all"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{} "List of submissions with pagination"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/kyc [get]
+func (h *KYCHandler) ListKYCSubmissions(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+	if status == "all" {
+		status = ""
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := `
+		SELECT s.id, s.user_id, u.username, u.email, s.status, s.first_name, s.last_name,
+			   s.country, s.document_type, s.reviewed_by, s.reviewed_at, s.created_at
+		FROM kyc_submissions s
+		JOIN users u ON s.user_id = u.id
+		WHERE ($1 = '' OR s.status = $1)
+		ORDER BY s.created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := h.DB.Query(query, status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch KYC submissions"})
+		return
+	}
+	defer rows.Close()
+
+	type submissionSummary struct {
+		ID           uuid.UUID  `json:"id"`
+		UserID       uuid.UUID  `json:"user_id"`
+		Username     string     `json:"username"`
+		Email        string     `json:"email"`
+		Status       string     `json:"status"`
+		FirstName    string     `json:"first_name"`
+		LastName     string     `json:"last_name"`
+		Country      string     `json:"country"`
+		DocumentType string     `json:"document_type"`
+		ReviewedBy   *uuid.UUID `json:"reviewed_by,omitempty"`
+		ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+		CreatedAt    time.Time  `json:"created_at"`
+	}
+
+	submissions := []submissionSummary{}
+	for rows.Next() {
+		var s submissionSummary
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Username, &s.Email, &s.Status, &s.FirstName, &s.LastName,
+			&s.Country, &s.DocumentType, &s.ReviewedBy, &s.ReviewedAt, &s.CreatedAt); err != nil {
+			continue
+		}
+		submissions = append(submissions, s)
+	}
+
+	var total int
+	err = h.DB.QueryRow("SELECT COUNT(*) FROM kyc_submissions WHERE ($1 = '' OR status = $1)", status).Scan(&total)
+	if err != nil {
+		total = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  submissions,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetKYCSubmission godoc
+// @Summary Get a KYC submission
+// @Description View a submission with its documents and the user's KYC history. Stored documents include a signed, short-lived download_url. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Submission ID"
+// @Success 200 {object} map[string]interface{} "Submission details"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Submission not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/kyc/{id} [get]
+func (h *KYCHandler) GetKYCSubmission(c *gin.Context) {
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid submission ID"})
+		return
+	}
+
+	submission, err := getKYCSubmission(h.DB, submissionID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "submission_not_found", "message": "KYC submission not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve KYC submission"})
+		return
+	}
+
+	var username, email, kycStatus string
+	err = h.DB.QueryRow("SELECT username, email, kyc_status FROM users WHERE id = $1", submission.UserID).
+		Scan(&username, &email, &kycStatus)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	history, err := getKYCHistory(h.DB, submission.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve KYC history"})
+		return
+	}
+
+	// Short-lived links let reviewers open documents without re-sending credentials
+	for i := range submission.Documents {
+		doc := &submission.Documents[i]
+		if doc.StorageKey == nil {
+			continue
+		}
+		if url, _, err := h.Storage.SignedURL(*doc.StorageKey, doc.FileName); err == nil {
+			doc.DownloadURL = url
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"submission": submission,
+		"user": gin.H{
+			"id":         submission.UserID,
+			"username":   username,
+			"email":      email,
+			"kyc_status": kycStatus,
+		},
+		"history": history,
+	})
+}
+
+// GetKYCDocument godoc
+// @Summary Download a KYC document
+// @Description Download a document attached to a submission. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Produce octet-stream
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Submission ID"
+// @Param documentId path string true "Document ID"
+// @Success 200 {file} file "Document content"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Document not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/kyc/{id}/documents/{documentId} [get]
+func (h *KYCHandler) GetKYCDocument(c *gin.Context) {
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid submission ID"})
+		return
+	}
+	documentID, err := uuid.Parse(c.Param("documentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid document ID"})
+		return
+	}
+
+	var doc models.KYCDocument
+	err = h.DB.QueryRow(`
+		SELECT id, submission_id, kind, storage_key, file_path, file_name, content_type, size_bytes, sha256, created_at
+		FROM kyc_documents
+		WHERE id = $1 AND submission_id = $2
+	`, documentID, submissionID).Scan(&doc.ID, &doc.SubmissionID, &doc.Kind, &doc.StorageKey, &doc.FilePath, &doc.FileName,
+		&doc.ContentType, &doc.SizeBytes, &doc.SHA256, &doc.CreatedAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "document_not_found", "message": "Document not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve document"})
+		return
+	}
+
+	content, size, err := h.openKYCDocument(c.Request.Context(), &doc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "storage_error", "message": "Failed to read document"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", doc.FileName))
+	c.DataFromReader(http.StatusOK, size, doc.ContentType, content, nil)
+}
+
+// ApproveKYC godoc
+// @Summary Approve a KYC submission
+// @Description Approve a pending submission. The user's KYC status becomes verified, the decision is recorded in the history and the user is notified by email.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Submission ID"
+// @Param request body models.ApproveKYCRequest false "Optional internal note"
+// @Success 200 {object} map[string]interface{} "Submission approved"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Submission not found"
+// @Failure 409 {object} map[string]interface{} "Submission was already reviewed or has an unresolved screening case"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/kyc/{id}/approve [post]
+func (h *KYCHandler) ApproveKYC(c *gin.Context) {
+	var req models.ApproveKYCRequest
+	// The body is optional
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "validation_failed",
+				"message": "Invalid request data",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	var note *string
+	if req.Note != "" {
+		note = &req.Note
+	}
+
+	h.decideKYC(c, true, note)
+}
+
+// RejectKYC godoc
+// @Summary Reject a KYC submission
+// @Description Reject a pending submission with a reason that is shown to the user. The decision is recorded in the history and the user is notified by email.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Submission ID"
+// @Param request body models.RejectKYCRequest true "Rejection reason"
+// @Success 200 {object} map[string]interface{} "Submission rejected"
+// @Failure 400 {object} map[string]interface{} "Bad request - reason missing"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Submission not found"
+// @Failure 409 {object} map[string]interface{} "Submission was already reviewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/kyc/{id}/reject [post]
+func (h *KYCHandler) RejectKYC(c *gin.Context) {
+	var req models.RejectKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.decideKYC(c, false, &req.Reason)
+}
+
+// decideKYC records an approval or rejection and notifies the user
+func (h *KYCHandler) decideKYC(c *gin.Context, approve bool, reason *string) {
+	reviewerID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	submissionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid submission ID"})
+		return
+	}
+
+	var userID uuid.UUID
+	var status, firstName, lastName string
+	err = h.DB.QueryRow("SELECT user_id, status, first_name, last_name FROM kyc_submissions WHERE id = $1", submissionID).
+		Scan(&userID, &status, &firstName, &lastName)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "submission_not_found", "message": "KYC submission not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve KYC submission"})
+		return
+	}
+
+	if userID == reviewerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "self_review", "message": "You can not review your own submission"})
+		return
+	}
+
+	// The identity must pass sanctions screening before it can be verified
+	if approve {
+		screeningCase, err := screenSubject(h.DB, h.Screening, userID, "kyc_submission", &submissionID, firstName+" "+lastName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "screening_error", "message": "Failed to screen submission"})
+			return
+		}
+		if screeningCase != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "screening_case_open",
+				"message": "A sanctions screening case must be cleared before this submission can be approved",
+				"case_id": screeningCase.ID,
+				"status":  screeningCase.Status,
+			})
+			return
+		}
+	}
+
+	submissionStatus, kycStatus, action := "approved", "verified", "approved"
+	if !approve {
+		submissionStatus, kycStatus, action = "rejected", "rejected", "rejected"
+	}
+
+	err = h.recordKYCDecision(c, submissionID, userID, reviewerID, submissionStatus, kycStatus, action, approve, reason)
+	if err == errKYCNotPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "already_reviewed", "message": "This submission was already reviewed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record KYC decision"})
+		return
+	}
+
+	h.sendKYCDecisionEmail(userID, approve, reason)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    fmt.Sprintf("KYC submission %s", submissionStatus),
+		"id":         submissionID,
+		"status":     submissionStatus,
+		"kyc_status": kycStatus,
+	})
+}
+
+// recordKYCDecision updates the submission and user and appends the history entry and audit event in one transaction
+func (h *KYCHandler) recordKYCDecision(c *gin.Context, submissionID, userID, reviewerID uuid.UUID, submissionStatus, kycStatus, action string, approve bool, reason *string) error {
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Internal approval notes go to the history only; rejection reasons are shown to the user
+	var rejectionReason *string
+	if !approve {
+		rejectionReason = reason
+	}
+
+	result, err := tx.Exec(`
+		UPDATE kyc_submissions
+		SET status = $1, reviewed_by = $2, reviewed_at = NOW(), rejection_reason = $3, updated_at = NOW()
+		WHERE id = $4 AND status = 'pending'
+	`, submissionStatus, reviewerID, rejectionReason, submissionID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return errKYCNotPending
+	}
+
+	_, err = tx.Exec("UPDATE users SET kyc_status = $1, updated_at = NOW() WHERE id = $2", kycStatus, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertKYCHistory(tx, submissionID, userID, &reviewerID, action, "pending", kycStatus, reason); err != nil {
+		return err
+	}
+
+	auditReason := ""
+	if reason != nil {
+		auditReason = *reason
+	}
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &reviewerID,
+		ActorRole:  c.GetString("role"),
+		Action:     "kyc." + action,
+		TargetType: "kyc_submission",
+		TargetID:   submissionID.String(),
+		Reason:     auditReason,
+		Details:    map[string]interface{}{"user_id": userID},
+		Before:     map[string]interface{}{"status": "pending"},
+		After:      map[string]interface{}{"status": submissionStatus, "kyc_status": kycStatus},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// sendKYCDecisionEmail notifies the user about a review outcome. Failures are only logged.
+func (h *KYCHandler) sendKYCDecisionEmail(userID uuid.UUID, approved bool, reason *string) {
+	var email, firstName, lastName, username string
+	err := h.DB.QueryRow("SELECT email, first_name, last_name, username FROM users WHERE id = $1", userID).
+		Scan(&email, &firstName, &lastName, &username)
+	if err != nil {
+		fmt.Printf("Failed to load user for KYC email: %v\n", err)
+		return
+	}
+
+	emailService := userEmailService(h.DB, userID)
+	if !emailService.IsEnabled() {
+		return
+	}
+
+	userName := fmt.Sprintf("%s %s", firstName, lastName)
+	if userName == " " {
+		userName = username
+	}
+
+	rejectionReason := ""
+	if !approved && reason != nil {
+		rejectionReason = *reason
+	}
+
+	kycURL := fmt.Sprintf("%s/settings/verification", getFrontendURL())
+	if err := emailService.SendKYCDecision(email, userName, approved, rejectionReason, kycURL); err != nil {
+		fmt.Printf("Failed to send KYC decision email: %v\n", err)
+	}
+}
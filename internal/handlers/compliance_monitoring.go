@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MonitoringHandler struct {
+	DB *sql.DB
+}
+
+func NewMonitoringHandler(db *sql.DB) *MonitoringHandler {
+	return &MonitoringHandler{DB: db}
+}
+
+// ListMonitoringRules godoc
+// @Summary List monitoring rules
+// @Description Transaction and sign-in monitoring rules with their parameters. Amounts are USD at the coin's current price. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "List of rules"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/monitoring/rules [get]
+func (h *MonitoringHandler) ListMonitoringRules(c *gin.Context) {
+	rows, err := h.DB.Query("SELECT " + monitoringRuleColumns + " FROM monitoring_rules ORDER BY id")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch monitoring rules"})
+		return
+	}
+	defer rows.Close()
+
+	rules := []models.MonitoringRule{}
+	for rows.Next() {
+		rule, err := scanMonitoringRule(rows)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, *rule)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// UpdateMonitoringRule godoc
+// @Summary Update a monitoring rule
+// @Description Change the parameters, severity or action of a rule, or enable and disable it. A freeze action also sets the account status to frozen when the rule raises an alert. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path int true "Rule ID"
+// @Param request body models.UpdateMonitoringRuleRequest true "Changed fields"
+// @Success 200 {object} models.MonitoringRule "Updated rule"
+// @Failure 400 {object} map[string]interface{} "Bad request - invalid parameters"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/monitoring/rules/{id} [put]
+func (h *MonitoringHandler) UpdateMonitoringRule(c *gin.Context) {
+	reviewerID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	ruleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid rule ID"})
+		return
+	}
+
+	var req models.UpdateMonitoringRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update monitoring rule"})
+		return
+	}
+	defer tx.Rollback()
+
+	rule, err := scanMonitoringRule(tx.QueryRow("SELECT "+monitoringRuleColumns+" FROM monitoring_rules WHERE id = $1 FOR UPDATE", ruleID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "rule_not_found", "message": "Monitoring rule not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve monitoring rule"})
+		return
+	}
+	before := *rule
+
+	if req.ThresholdUSD != nil {
+		rule.ThresholdUSD = req.ThresholdUSD
+	}
+	if req.WindowMinutes != nil {
+		rule.WindowMinutes = req.WindowMinutes
+	}
+	if req.MinCount != nil {
+		rule.MinCount = req.MinCount
+	}
+	if req.Ratio != nil {
+		rule.Ratio = req.Ratio
+	}
+	if req.Severity != nil {
+		rule.Severity = *req.Severity
+	}
+	if req.Action != nil {
+		rule.Action = *req.Action
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+
+	if err := validateMonitoringRule(rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_rule", "message": err.Error()})
+		return
+	}
+
+	updated, err := scanMonitoringRule(tx.QueryRow(`
+		UPDATE monitoring_rules
+		SET threshold_usd = $1, window_minutes = $2, min_count = $3, ratio = $4, severity = $5, action = $6,
+		    enabled = $7, updated_at = NOW()
+		WHERE id = $8
+		RETURNING `+monitoringRuleColumns,
+		rule.ThresholdUSD, rule.WindowMinutes, rule.MinCount, rule.Ratio, rule.Severity, rule.Action, rule.Enabled, ruleID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update monitoring rule"})
+		return
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &reviewerID,
+		ActorRole:  c.GetString("role"),
+		Action:     "monitoring_rule.update",
+		TargetType: "monitoring_rule",
+		TargetID:   strconv.Itoa(ruleID),
+		Before:     before,
+		After:      updated,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update monitoring rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// ListMonitoringAlerts godoc
+// @Summary List monitoring alerts
+// @Description Alerts raised by the monitoring rules, oldest first. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param status query string false "open (default), cleared, confirmed or all"
+// @Param severity query string false "low, medium, high or critical"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{} "List of alerts with pagination"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/monitoring/alerts [get]
+func (h *MonitoringHandler) ListMonitoringAlerts(c *gin.Context) {
+	status := c.DefaultQuery("status", "open")
+	if status == "all" {
+		status = ""
+	}
+	severity := c.Query("severity")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	rows, err := h.DB.Query(`
+		SELECT `+monitoringAlertColumns+`
+		FROM monitoring_alerts a
+		JOIN monitoring_rules r ON r.id = a.rule_id
+		WHERE ($1 = '' OR a.status = $1) AND ($2 = '' OR a.severity = $2)
+		ORDER BY a.created_at ASC
+		LIMIT $3 OFFSET $4
+	`, status, severity, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch monitoring alerts"})
+		return
+	}
+	defer rows.Close()
+
+	alerts := []models.MonitoringAlert{}
+	for rows.Next() {
+		alert, err := scanMonitoringAlert(rows)
+		if err != nil {
+			continue
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	var total int
+	err = h.DB.QueryRow(`
+		SELECT COUNT(*) FROM monitoring_alerts
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR severity = $2)
+	`, status, severity).Scan(&total)
+	if err != nil {
+		total = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  alerts,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetMonitoringAlert godoc
+// @Summary Get a monitoring alert
+// @Description View an alert with its rule, the user and the transaction that triggered it. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Alert ID"
+// @Success 200 {object} map[string]interface{} "Alert details"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Alert not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/monitoring/alerts/{id} [get]
+func (h *MonitoringHandler) GetMonitoringAlert(c *gin.Context) {
+	alertID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid alert ID"})
+		return
+	}
+
+	alert, err := scanMonitoringAlert(h.DB.QueryRow(`
+		SELECT `+monitoringAlertColumns+`
+		FROM monitoring_alerts a
+		JOIN monitoring_rules r ON r.id = a.rule_id
+		WHERE a.id = $1
+	`, alertID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "alert_not_found", "message": "Monitoring alert not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve monitoring alert"})
+		return
+	}
+
+	rule, err := scanMonitoringRule(h.DB.QueryRow("SELECT "+monitoringRuleColumns+" FROM monitoring_rules WHERE id = $1", alert.RuleID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve monitoring rule"})
+		return
+	}
+
+	var username, email, status, kycStatus string
+	err = h.DB.QueryRow("SELECT username, email, status, kyc_status FROM users WHERE id = $1", alert.UserID).
+		Scan(&username, &email, &status, &kycStatus)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	response := gin.H{
+		"alert": alert,
+		"rule":  rule,
+		"user": gin.H{
+			"id":         alert.UserID,
+			"username":   username,
+			"email":      email,
+			"status":     status,
+			"kyc_status": kycStatus,
+		},
+	}
+
+	if alert.TransactionID != nil {
+		var t models.Transaction
+		var ticker string
+		err := h.DB.QueryRow(`
+			SELECT t.id, t.user_id, t.wallet_id, t.type, t.amount, t.fee, t.description, t.address, t.memo, t.status,
+			       t.created_at, t.updated_at, c.ticker
+			FROM transactions t
+			JOIN wallets w ON w.id = t.wallet_id
+			JOIN coins c ON c.id = w.coin_id
+			WHERE t.id = $1
+		`, *alert.TransactionID).Scan(&t.ID, &t.UserID, &t.WalletID, &t.Type, &t.Amount, &t.Fee, &t.Description,
+			&t.Address, &t.Memo, &t.Status, &t.CreatedAt, &t.UpdatedAt, &ticker)
+		if err != nil && err != sql.ErrNoRows {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve transaction"})
+			return
+		}
+		if err == nil {
+			response["transaction"] = gin.H{"transaction": t, "coin": ticker}
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ClearMonitoringAlert godoc
+// @Summary Clear a monitoring alert
+// @Description Close an open alert as no concern. When the alert froze the account and no other freezing alert remains open or confirmed, the account status before the freeze is restored. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Alert ID"
+// @Param request body models.ReviewMonitoringAlertRequest true "Review note"
+// @Success 200 {object} map[string]interface{} "Alert cleared"
+// @Failure 400 {object} map[string]interface{} "Bad request - note missing"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Alert not found"
+// @Failure 409 {object} map[string]interface{} "Alert was already reviewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/monitoring/alerts/{id}/clear [post]
+func (h *MonitoringHandler) ClearMonitoringAlert(c *gin.Context) {
+	h.reviewMonitoringAlert(c, "cleared")
+}
+
+// ConfirmMonitoringAlert godoc
+// @Summary Confirm a monitoring alert
+// @Description Close an open alert as suspicious activity. A frozen account stays frozen. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Alert ID"
+// @Param request body models.ReviewMonitoringAlertRequest true "Review note"
+// @Success 200 {object} map[string]interface{} "Alert confirmed"
+// @Failure 400 {object} map[string]interface{} "Bad request - note missing"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Alert not found"
+// @Failure 409 {object} map[string]interface{} "Alert was already reviewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/monitoring/alerts/{id}/confirm [post]
+func (h *MonitoringHandler) ConfirmMonitoringAlert(c *gin.Context) {
+	h.reviewMonitoringAlert(c, "confirmed")
+}
+
+// reviewMonitoringAlert closes an open alert as cleared or confirmed
+func (h *MonitoringHandler) reviewMonitoringAlert(c *gin.Context, status string) {
+	reviewerID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	alertID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid alert ID"})
+		return
+	}
+
+	var req models.ReviewMonitoringAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var userID uuid.UUID
+	err = h.DB.QueryRow("SELECT user_id FROM monitoring_alerts WHERE id = $1", alertID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "alert_not_found", "message": "Monitoring alert not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve monitoring alert"})
+		return
+	}
+
+	if userID == reviewerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "self_review", "message": "You can not review your own monitoring alert"})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update monitoring alert"})
+		return
+	}
+	defer tx.Rollback()
+
+	var accountFrozen bool
+	var previousStatus sql.NullString
+	err = tx.QueryRow(`
+		UPDATE monitoring_alerts
+		SET status = $1, reviewed_by = $2, reviewed_at = NOW(), review_note = $3, updated_at = NOW()
+		WHERE id = $4 AND status = 'open'
+		RETURNING account_frozen, previous_status
+	`, status, reviewerID, req.Note, alertID).Scan(&accountFrozen, &previousStatus)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusConflict, gin.H{"error": "already_reviewed", "message": "This monitoring alert was already reviewed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update monitoring alert"})
+		return
+	}
+
+	// Unfreeze only when no other alert keeps the account frozen
+	unfrozen := false
+	if status == "cleared" && accountFrozen && previousStatus.Valid {
+		result, err := tx.Exec(`
+			UPDATE users SET status = $1, updated_at = NOW()
+			WHERE id = $2 AND status = 'frozen'
+			  AND NOT EXISTS (
+				SELECT 1 FROM monitoring_alerts
+				WHERE user_id = $2 AND account_frozen AND status IN ('open', 'confirmed')
+			  )
+		`, previousStatus.String, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to restore account status"})
+			return
+		}
+		rows, _ := result.RowsAffected()
+		unfrozen = rows > 0
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &reviewerID,
+		ActorRole:  c.GetString("role"),
+		Action:     "monitoring_alert." + status,
+		TargetType: "monitoring_alert",
+		TargetID:   alertID.String(),
+		Reason:     req.Note,
+		Details:    map[string]interface{}{"user_id": userID, "account_unfrozen": unfrozen},
+		Before:     map[string]interface{}{"status": "open"},
+		After:      map[string]interface{}{"status": status},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update monitoring alert"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          fmt.Sprintf("Monitoring alert %s", status),
+		"id":               alertID,
+		"status":           status,
+		"account_unfrozen": unfrozen,
+	})
+}
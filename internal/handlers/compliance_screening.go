@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ScreeningHandler struct {
+	DB        *sql.DB
+	Screening *services.ScreeningService
+}
+
+func NewScreeningHandler(db *sql.DB, screening *services.ScreeningService) *ScreeningHandler {
+	return &ScreeningHandler{DB: db, Screening: screening}
+}
+
+// ListScreeningCases godoc
+// @Summary List screening cases
+// @Description Sanctions screening hits, oldest first. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param status query string false "open (default), cleared, confirmed or all"
+// @Param subject_type query string false "registration, kyc_submission or withdrawal_address"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} map[string]interface{} "List of cases with pagination"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/screening [get]
+func (h *ScreeningHandler) ListScreeningCases(c *gin.Context) {
+	status := c.DefaultQuery("status", "open")
+	if status == "all" {
+		status = ""
+	}
+	subjectType := c.Query("subject_type")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	rows, err := h.DB.Query(`
+		SELECT `+screeningCaseColumns+`
+		FROM screening_cases
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR subject_type = $2)
+		ORDER BY created_at ASC
+		LIMIT $3 OFFSET $4
+	`, status, subjectType, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch screening cases"})
+		return
+	}
+	defer rows.Close()
+
+	cases := []models.ScreeningCase{}
+	for rows.Next() {
+		sc, err := scanScreeningCase(rows)
+		if err != nil {
+			continue
+		}
+		cases = append(cases, *sc)
+	}
+
+	var total int
+	err = h.DB.QueryRow(`
+		SELECT COUNT(*) FROM screening_cases
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR subject_type = $2)
+	`, status, subjectType).Scan(&total)
+	if err != nil {
+		total = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  cases,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetScreeningCase godoc
+// @Summary Get a screening case
+// @Description View a screening case with its list matches and the user. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Case ID"
+// @Success 200 {object} map[string]interface{} "Case details"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Case not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/screening/{id} [get]
+func (h *ScreeningHandler) GetScreeningCase(c *gin.Context) {
+	caseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid case ID"})
+		return
+	}
+
+	sc, err := scanScreeningCase(h.DB.QueryRow("SELECT "+screeningCaseColumns+" FROM screening_cases WHERE id = $1", caseID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "case_not_found", "message": "Screening case not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve screening case"})
+		return
+	}
+
+	var username, email, firstName, lastName, country sql.NullString
+	err = h.DB.QueryRow("SELECT username, email, first_name, last_name, country FROM users WHERE id = $1", sc.UserID).
+		Scan(&username, &email, &firstName, &lastName, &country)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"case": sc,
+		"user": gin.H{
+			"id":         sc.UserID,
+			"username":   username.String,
+			"email":      email.String,
+			"first_name": firstName.String,
+			"last_name":  lastName.String,
+			"country":    country.String,
+		},
+	})
+}
+
+// ClearScreeningCase godoc
+// @Summary Clear a screening case
+// @Description Mark an open case as a false positive. The blocked action can be retried; the cleared list entries are not raised again for the same user and value. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Case ID"
+// @Param request body models.ReviewScreeningCaseRequest true "Review note"
+// @Success 200 {object} map[string]interface{} "Case cleared"
+// @Failure 400 {object} map[string]interface{} "Bad request - note missing"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Case not found"
+// @Failure 409 {object} map[string]interface{} "Case was already reviewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/screening/{id}/clear [post]
+func (h *ScreeningHandler) ClearScreeningCase(c *gin.Context) {
+	h.reviewScreeningCase(c, "cleared")
+}
+
+// ConfirmScreeningCase godoc
+// @Summary Confirm a screening case
+// @Description Confirm an open case as a true match. The action stays blocked permanently. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Case ID"
+// @Param request body models.ReviewScreeningCaseRequest true "Review note"
+// @Success 200 {object} map[string]interface{} "Case confirmed"
+// @Failure 400 {object} map[string]interface{} "Bad request - note missing"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Case not found"
+// @Failure 409 {object} map[string]interface{} "Case was already reviewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/compliance/screening/{id}/confirm [post]
+func (h *ScreeningHandler) ConfirmScreeningCase(c *gin.Context) {
+	h.reviewScreeningCase(c, "confirmed")
+}
+
+// reviewScreeningCase closes an open case as cleared or confirmed
+func (h *ScreeningHandler) reviewScreeningCase(c *gin.Context, status string) {
+	reviewerID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	caseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid case ID"})
+		return
+	}
+
+	var req models.ReviewScreeningCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var userID uuid.UUID
+	var currentStatus string
+	err = h.DB.QueryRow("SELECT user_id, status FROM screening_cases WHERE id = $1", caseID).Scan(&userID, &currentStatus)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "case_not_found", "message": "Screening case not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve screening case"})
+		return
+	}
+
+	if userID == reviewerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "self_review", "message": "You can not review your own screening case"})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update screening case"})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE screening_cases
+		SET status = $1, reviewed_by = $2, reviewed_at = NOW(), review_note = $3, updated_at = NOW()
+		WHERE id = $4 AND status = 'open'
+	`, status, reviewerID, req.Note, caseID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update screening case"})
+		return
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "already_reviewed", "message": "This screening case was already reviewed"})
+		return
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &reviewerID,
+		ActorRole:  c.GetString("role"),
+		Action:     "screening." + status,
+		TargetType: "screening_case",
+		TargetID:   caseID.String(),
+		Reason:     req.Note,
+		Details:    map[string]interface{}{"user_id": userID},
+		Before:     map[string]interface{}{"status": currentStatus},
+		After:      map[string]interface{}{"status": status},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update screening case"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Screening case %s", status),
+		"id":      caseID,
+		"status":  status,
+	})
+}
+
+// GetScreeningLists godoc
+// @Summary Get loaded sanctions lists
+// @Description Files, entry counts and the name match threshold of the loaded lists. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} services.ScreeningListStats "Loaded lists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Router /api/v1/compliance/screening/lists [get]
+func (h *ScreeningHandler) GetScreeningLists(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Screening.Stats())
+}
+
+// ReloadScreeningLists godoc
+// @Summary Reload sanctions lists
+// @Description Read the list files in SCREENING_LIST_DIR again, e.g. after downloading a new SDN file. Requires the compliance, admin or superadmin role.
+// @Tags Compliance
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} services.ScreeningListStats "Reloaded lists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden"
+// @Failure 500 {object} map[string]interface{} "List files could not be loaded; the previous lists stay active"
+// @Router /api/v1/compliance/screening/lists/reload [post]
+func (h *ScreeningHandler) ReloadScreeningLists(c *gin.Context) {
+	reviewerID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	before := h.Screening.Stats()
+	if err := h.Screening.Reload(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "list_load_failed", "message": err.Error()})
+		return
+	}
+	after := h.Screening.Stats()
+
+	err := recordAuditEvent(h.DB, c, auditEvent{
+		ActorID:    &reviewerID,
+		ActorRole:  c.GetString("role"),
+		Action:     "screening.lists_reload",
+		TargetType: "screening_lists",
+		TargetID:   "sanctions",
+		Before:     before,
+		After:      after,
+	})
+	if err != nil {
+		fmt.Printf("Failed to record audit event: %v\n", err)
+	}
+
+	c.JSON(http.StatusOK, after)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"database/sql"
+	"math/big"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// creditDeposit credits amount to the user's wallet and records a completed deposit transaction.
+// Every path that credits a deposit goes through it, so the deposit gate and caps of the user's
+// KYC tier are always enforced. The caller must hold the user row lock (SELECT ... FOR UPDATE)
+// so concurrent credits can not exceed the caps together.
+func creditDeposit(tx *sql.Tx, userID uuid.UUID, coinID int, amount *big.Rat, description, referenceID string) (*models.Transaction, error) {
+	if err := checkLimit(tx, userID, coinID, "deposit", amount); err != nil {
+		return nil, err
+	}
+
+	amountStr := amount.FloatString(withdrawalDecimals)
+	var walletID uuid.UUID
+	err := tx.QueryRow(`
+		INSERT INTO wallets (user_id, coin_id, balance)
+		VALUES ($1, $2, $3::numeric)
+		ON CONFLICT (user_id, coin_id) DO UPDATE SET balance = wallets.balance + EXCLUDED.balance, updated_at = NOW()
+		RETURNING id
+	`, userID, coinID, amountStr).Scan(&walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := models.Transaction{
+		ID:          uuid.New(),
+		UserID:      userID,
+		WalletID:    walletID,
+		Type:        "deposit",
+		Amount:      amountStr,
+		Fee:         "0",
+		Description: &description,
+		ReferenceID: &referenceID,
+		Status:      "completed",
+	}
+	err = tx.QueryRow(`
+		INSERT INTO transactions (id, user_id, wallet_id, type, amount, fee, description, reference_id, status)
+		VALUES ($1, $2, $3, 'deposit', $4, 0, $5, $6, 'completed')
+		RETURNING created_at, updated_at
+	`, t.ID, userID, walletID, amountStr, description, referenceID).Scan(&t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// feeRateQuery resolves the rates user $1 pays on markets m: an unexpired override (one for the
+// market before one for all markets), else the schedule of the highest tier at or below the
+// user's, else the market's own rates. The maker and taker of a fill resolve their rates
+// separately, so settleFill caps the maker's rebate at the taker's fee.
+const feeRateQuery = `
+	SELECT m.symbol,
+		COALESCE(o.maker_fee_rate, s.maker_fee_rate, m.maker_fee_rate),
+		COALESCE(o.taker_fee_rate, s.taker_fee_rate, m.taker_fee_rate),
+		CASE WHEN o.maker_fee_rate IS NOT NULL THEN 'override'
+			WHEN s.maker_fee_rate IS NOT NULL THEN 'schedule' ELSE 'market' END
+	FROM markets m
+	LEFT JOIN LATERAL (
+		SELECT maker_fee_rate, taker_fee_rate FROM user_fee_overrides
+		WHERE user_id = $1 AND (market_id = m.id OR market_id IS NULL) AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY market_id NULLS LAST
+		LIMIT 1
+	) o ON TRUE
+	LEFT JOIN LATERAL (
+		SELECT maker_fee_rate, taker_fee_rate FROM fee_schedules
+		WHERE market_id = m.id AND tier <= COALESCE((SELECT tier FROM user_fee_tiers WHERE user_id = $1), 0)
+		ORDER BY tier DESC
+		LIMIT 1
+	) s ON TRUE`
+
+// feeRate is what a user pays on a market; a negative maker rate is a rebate
+type feeRate struct {
+	maker, taker *big.Rat
+}
+
+// feeRate returns the rates of a user on the market being executed, resolved once per execution
+func (ex *execution) feeRate(q limitQueryer, marketID int, userID uuid.UUID) (*feeRate, error) {
+	if rate, ok := ex.rates[userID]; ok {
+		return rate, nil
+	}
+
+	var symbol, maker, taker, source string
+	err := q.QueryRow(feeRateQuery+" WHERE m.id = $2", userID, marketID).Scan(&symbol, &maker, &taker, &source)
+	if err != nil {
+		return nil, err
+	}
+	rate := &feeRate{maker: ratOrZero(maker), taker: ratOrZero(taker)}
+	ex.rates[userID] = rate
+	return rate, nil
+}
+
+// clampMakerRebate limits a maker rebate (a negative fee) to the taker fee of the same fill.
+// conversion turns the taker fee into the rebate's coin at the fill price. The limit is truncated,
+// so the exchange keeps a non-negative net fee on every fill.
+func clampMakerRebate(rebate, takerFee, conversion *big.Rat) *big.Rat {
+	if rebate.Sign() >= 0 {
+		return rebate
+	}
+	limit, _ := new(big.Rat).SetString(truncateRat(new(big.Rat).Mul(takerFee, conversion), ledgerDecimals))
+	if new(big.Rat).Neg(rebate).Cmp(limit) > 0 {
+		return limit.Neg(limit)
+	}
+	return rebate
+}
+
+// postFeeRevenue books a fee charged on a trade to the fee revenue account of its coin. A
+// negative fee is a maker rebate paid out of the account.
+func postFeeRevenue(tx *sql.Tx, coinID int, tradeID, userID uuid.UUID, fee *big.Rat) error {
+	entryType := "trade_fee"
+	if fee.Sign() < 0 {
+		entryType = "maker_rebate"
+	}
+	return postFeeRevenueEntry(tx, coinID, tradeID, userID, entryType, fee)
+}
+
+// postFeeRevenueEntry adds a signed amount to the fee revenue account of a coin and records it
+// as an entry of the trade. userID is the user charged or paid.
+func postFeeRevenueEntry(tx *sql.Tx, coinID int, tradeID, userID uuid.UUID, entryType string, value *big.Rat) error {
+	if value.Sign() == 0 {
+		return nil
+	}
+
+	amount := value.FloatString(ledgerDecimals)
+	_, err := tx.Exec(`
+		INSERT INTO fee_revenue_entries (coin_id, trade_id, user_id, entry_type, amount)
+		VALUES ($1, $2, $3, $4, $5)
+	`, coinID, tradeID, userID, entryType, amount)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO fee_revenue (coin_id, balance) VALUES ($1, $2::numeric)
+		ON CONFLICT (coin_id) DO UPDATE SET balance = fee_revenue.balance + EXCLUDED.balance, updated_at = NOW()
+	`, coinID, amount)
+	return err
+}
+
+// FeeTierJobs recomputes every user's rolling 30-day trading volume and the fee tier it reaches.
+// The whole table is rewritten in one statement, so running it on several instances is harmless.
+type FeeTierJobs struct {
+	DB *sql.DB
+}
+
+func NewFeeTierJobs(db *sql.DB) *FeeTierJobs {
+	return &FeeTierJobs{DB: db}
+}
+
+// getFeeTierRefreshInterval returns the pause between two runs, which is how long a user can
+// wait for a new tier after crossing a volume threshold
+func getFeeTierRefreshInterval() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("FEE_TIER_REFRESH_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return 5 * time.Minute // Default 300 seconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Start runs the job in the background until the process exits. Set
+// FEE_TIER_JOBS_ENABLED=false to leave it to other instances.
+func (j *FeeTierJobs) Start() {
+	if os.Getenv("FEE_TIER_JOBS_ENABLED") == "false" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(getFeeTierRefreshInterval())
+		defer ticker.Stop()
+		for {
+			j.RunOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+// RunOnce stores the 30-day volume and tier of every user who traded, and moves users whose
+// trades have all aged out back to zero volume. Trades against oneself do not count.
+func (j *FeeTierJobs) RunOnce() {
+	if err := j.refreshTiers(); err != nil {
+		fmt.Printf("Failed to refresh fee tiers: %v\n", err)
+	}
+}
+
+func (j *FeeTierJobs) refreshTiers() error {
+	tx, err := j.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// NOW() is the transaction start, so rows written here are not older than it
+	_, err = tx.Exec(`
+		INSERT INTO user_fee_tiers (user_id, volume_30d_usd, tier, updated_at)
+		SELECT v.user_id, v.volume,
+			COALESCE((SELECT tier FROM fee_tiers WHERE min_volume_usd <= v.volume
+				ORDER BY min_volume_usd DESC, tier DESC LIMIT 1), 0),
+			NOW()
+		FROM (
+			SELECT user_id, SUM(volume_usd) AS volume
+			FROM (
+				SELECT buyer_id AS user_id, volume_usd FROM trades
+				WHERE created_at >= NOW() - INTERVAL '30 days' AND buyer_id <> seller_id
+				UNION ALL
+				SELECT seller_id, volume_usd FROM trades
+				WHERE created_at >= NOW() - INTERVAL '30 days' AND buyer_id <> seller_id
+			) t
+			GROUP BY user_id
+		) v
+		ON CONFLICT (user_id) DO UPDATE SET
+			volume_30d_usd = EXCLUDED.volume_30d_usd, tier = EXCLUDED.tier, updated_at = EXCLUDED.updated_at
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		WITH base AS (
+			SELECT COALESCE((SELECT tier FROM fee_tiers WHERE min_volume_usd <= 0
+				ORDER BY min_volume_usd DESC, tier DESC LIMIT 1), 0) AS tier
+		)
+		UPDATE user_fee_tiers SET volume_30d_usd = 0, tier = base.tier, updated_at = NOW()
+		FROM base
+		WHERE user_fee_tiers.updated_at < NOW() AND (user_fee_tiers.volume_30d_usd <> 0 OR user_fee_tiers.tier <> base.tier)
+	`)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTradingFees godoc
+// @Summary Get trading fees
+// @Description Get the user's fee tier, the rolling 30-day trading volume that sets it (refreshed every few minutes), the next tier and the maker and taker rates paid on each active market. A negative maker rate is a rebate paid to the maker. source tells whether a rate comes from an override set by an admin, the tier's fee schedule or the market's default rates.
+// @Tags Orders
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} models.TradingFeesResponse "Trading fees"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/trading/fees [get]
+func (h *OrderHandler) GetTradingFees(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	response := models.TradingFeesResponse{Volume30dUSD: "0.00", Markets: make([]models.MarketFeeRate, 0)}
+	err := h.DB.QueryRow(`
+		SELECT tier, volume_30d_usd, updated_at FROM user_fee_tiers WHERE user_id = $1
+	`, userID).Scan(&response.Tier, &response.Volume30dUSD, &response.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee tier"})
+		return
+	}
+
+	err = h.DB.QueryRow("SELECT name FROM fee_tiers WHERE tier = $1", response.Tier).Scan(&response.TierName)
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee tier"})
+		return
+	}
+
+	next, err := scanFeeTier(h.DB.QueryRow(`
+		SELECT `+feeTierColumns+` FROM fee_tiers
+		WHERE min_volume_usd > $1::numeric AND tier > $2
+		ORDER BY min_volume_usd, tier
+		LIMIT 1
+	`, response.Volume30dUSD, response.Tier))
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee tier"})
+		return
+	}
+	response.NextTier = next
+
+	rows, err := h.DB.Query(feeRateQuery+" WHERE m.is_active = true ORDER BY m.symbol", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve fee rates"})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate models.MarketFeeRate
+		if err := rows.Scan(&rate.Market, &rate.MakerFeeRate, &rate.TakerFeeRate, &rate.Source); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to scan fee rates"})
+			return
+		}
+		response.Markets = append(response.Markets, rate)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
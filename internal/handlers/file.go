@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type FileHandler struct {
+	Storage *services.StorageService
+}
+
+func NewFileHandler(storage *services.StorageService) *FileHandler {
+	return &FileHandler{Storage: storage}
+}
+
+// DownloadFile godoc
+// @Summary Download a stored file
+// @Description Download a file through a signed, time-limited URL handed out by another endpoint (for example KYC review). The signature is the only credential.
+// @Tags Files
+// @Produce octet-stream
+// @Param key query string true "Object key"
+// @Param filename query string false "File name for the download"
+// @Param expires query string true "Expiry (unix seconds)"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file "File content"
+// @Failure 403 {object} map[string]interface{} "Invalid or expired link"
+// @Failure 404 {object} map[string]interface{} "File not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/files/download [get]
+func (h *FileHandler) DownloadFile(c *gin.Context) {
+	key := c.Query("key")
+	filename := c.Query("filename")
+
+	err := h.Storage.VerifySignedURL(key, filename, c.Query("expires"), c.Query("signature"))
+	if err == services.ErrSignedURLExpired {
+		c.JSON(http.StatusForbidden, gin.H{"error": "link_expired", "message": "Download link has expired"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid_signature", "message": "Invalid download link"})
+		return
+	}
+
+	data, err := h.Storage.Load(c.Request.Context(), key)
+	if err == services.ErrBlobNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file_not_found", "message": "File not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "storage_error", "message": "Failed to read file"})
+		return
+	}
+
+	if filename == "" {
+		filename = filepath.Base(key)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filepath.Base(filename)))
+	c.Data(http.StatusOK, http.DetectContentType(data), data)
+}
@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// kycAllowedContentTypes are the sniffed content types accepted for KYC documents
+var kycAllowedContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+var (
+	errKYCFileTooLarge = errors.New("file is too large")
+	errKYCFileType     = errors.New("only JPEG, PNG and PDF files are accepted")
+	errKYCUnderage     = errors.New("you must be at least 18 years old")
+)
+
+type KYCHandler struct {
+	DB        *sql.DB
+	Storage   *services.StorageService
+	Screening *services.ScreeningService
+}
+
+func NewKYCHandler(db *sql.DB, storage *services.StorageService, screening *services.ScreeningService) *KYCHandler {
+	return &KYCHandler{DB: db, Storage: storage, Screening: screening}
+}
+
+// getKYCUploadDir returns the directory documents uploaded before the storage service were written to
+func getKYCUploadDir() string {
+	dir := os.Getenv("KYC_UPLOAD_DIR")
+	if dir == "" {
+		dir = "storage/kyc"
+	}
+	return dir
+}
+
+// getKYCMaxFileSize returns the maximum size of a single KYC document in bytes
+func getKYCMaxFileSize() int64 {
+	mb, err := strconv.Atoi(os.Getenv("KYC_MAX_FILE_MB"))
+	if err != nil || mb <= 0 {
+		return 10 << 20 // Default 10 MB
+	}
+	return int64(mb) << 20
+}
+
+// GetKYCStatus godoc
+// @Summary Get KYC status
+// @Description Get the user's KYC status, latest submission and its history
+// @Tags KYC
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "KYC status"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/kyc [get]
+func (h *KYCHandler) GetKYCStatus(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var kycStatus string
+	if err := h.DB.QueryRow("SELECT kyc_status FROM users WHERE id = $1", userID).Scan(&kycStatus); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	var submissionID uuid.UUID
+	err := h.DB.QueryRow(`
+		SELECT id FROM kyc_submissions WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1
+	`, userID).Scan(&submissionID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusOK, gin.H{
+			"kyc_status": kycStatus,
+			"submission": nil,
+			"history":    []models.KYCHistoryEntry{},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve KYC submission"})
+		return
+	}
+
+	submission, err := getKYCSubmission(h.DB, submissionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve KYC submission"})
+		return
+	}
+	// The document number is only shown to reviewers
+	submission.DocumentNumber = ""
+
+	history, err := getKYCHistory(h.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve KYC history"})
+		return
+	}
+	// Reviewer identities and internal approval notes stay internal
+	for i := range history {
+		history[i].ActorID = nil
+		if history[i].Action == "approved" {
+			history[i].Reason = nil
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"kyc_status": kycStatus,
+		"submission": submission,
+		"history":    history,
+	})
+}
+
+// SubmitKYC godoc
+// @Summary Submit KYC
+// @Description Submit identity data and documents for review (multipart/form-data). Documents must be JPEG, PNG or PDF. Moves the KYC status to pending.
+// @Tags KYC
+// @Accept multipart/form-data
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param first_name formData string true "First name"
+// @Param last_name formData string true "Last name"
+// @Param date_of_birth formData string true "Date of birth (YYYY-MM-DD)"
+// @Param nationality formData string true "Nationality (ISO 3166-1 alpha-2)"
+// @Param country formData string true "Country of residence (ISO 3166-1 alpha-2)"
+// @Param city formData string true "City"
+// @Param address formData string true "Street address"
+// @Param postal_code formData string false "Postal code"
+// @Param document_type formData string true "passport, national_id or driving_license"
+// @Param document_number formData string true "Identity document number"
+// @Param document_front formData file true "Front of the identity document"
+// @Param document_back formData file false "Back of the identity document"
+// @Param selfie formData file true "Selfie holding the document"
+// @Param proof_of_address formData file false "Proof of address"
+// @Success 201 {object} models.KYCSubmission "Submission received"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors or invalid files"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "A submission is already pending or KYC is verified"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/kyc [post]
+func (h *KYCHandler) SubmitKYC(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var kycStatus string
+	if err := h.DB.QueryRow("SELECT kyc_status FROM users WHERE id = $1", userID).Scan(&kycStatus); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	switch kycStatus {
+	case "pending":
+		c.JSON(http.StatusConflict, gin.H{"error": "kyc_pending", "message": "Your KYC submission is already under review"})
+		return
+	case "verified":
+		c.JSON(http.StatusConflict, gin.H{"error": "kyc_verified", "message": "Your identity is already verified"})
+		return
+	}
+
+	var req models.SubmitKYCRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	dateOfBirth, _ := time.Parse("2006-01-02", req.DateOfBirth)
+	if dateOfBirth.AddDate(18, 0, 0).After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "underage", "message": errKYCUnderage.Error()})
+		return
+	}
+
+	documentNumber, err := services.EncryptString(strings.TrimSpace(req.DocumentNumber))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "encryption_error", "message": "Failed to protect identity data"})
+		return
+	}
+
+	submissionID := uuid.New()
+	files := map[string]*multipart.FileHeader{
+		"document_front":   req.DocumentFront,
+		"document_back":    req.DocumentBack,
+		"selfie":           req.Selfie,
+		"proof_of_address": req.ProofOfAddress,
+	}
+
+	documents := []models.KYCDocument{}
+	for kind, fh := range files {
+		if fh == nil {
+			continue
+		}
+		doc, err := h.saveKYCDocument(c.Request.Context(), submissionID, kind, fh)
+		if err != nil {
+			h.removeKYCDocuments(documents)
+			if err == errKYCFileTooLarge || err == errKYCFileType {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "invalid_document",
+					"message": fmt.Sprintf("%s: %s", kind, err.Error()),
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "storage_error", "message": "Failed to store documents"})
+			return
+		}
+		documents = append(documents, *doc)
+	}
+
+	var postalCode *string
+	if req.PostalCode != "" {
+		postalCode = &req.PostalCode
+	}
+
+	submission := models.KYCSubmission{
+		ID:           submissionID,
+		UserID:       userID,
+		Status:       "pending",
+		FirstName:    req.FirstName,
+		LastName:     req.LastName,
+		DateOfBirth:  req.DateOfBirth,
+		Nationality:  strings.ToUpper(req.Nationality),
+		Country:      strings.ToUpper(req.Country),
+		City:         req.City,
+		Address:      req.Address,
+		PostalCode:   postalCode,
+		DocumentType: req.DocumentType,
+		Documents:    documents,
+	}
+
+	if err := h.createKYCSubmission(&submission, documentNumber, kycStatus); err != nil {
+		h.removeKYCDocuments(documents)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{"error": "kyc_pending", "message": "Your KYC submission is already under review"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save KYC submission"})
+		return
+	}
+
+	// Raise sanctions hits early; approval screens again and is blocked while a case is unresolved
+	if _, err := screenSubject(h.DB, h.Screening, userID, "kyc_submission", &submissionID, submission.FirstName+" "+submission.LastName); err != nil {
+		fmt.Printf("Failed to screen KYC submission: %v\n", err)
+	}
+
+	c.JSON(http.StatusCreated, submission)
+}
+
+// createKYCSubmission stores the submission, its documents and history entry and marks the user pending
+func (h *KYCHandler) createKYCSubmission(s *models.KYCSubmission, encryptedDocumentNumber, previousStatus string) error {
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO kyc_submissions (
+			id, user_id, status, first_name, last_name, date_of_birth, nationality, country,
+			city, address, postal_code, document_type, document_number
+		) VALUES ($1, $2, 'pending', $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING created_at, updated_at
+	`, s.ID, s.UserID, s.FirstName, s.LastName, s.DateOfBirth, s.Nationality, s.Country,
+		s.City, s.Address, s.PostalCode, s.DocumentType, encryptedDocumentNumber).Scan(&s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range s.Documents {
+		d := &s.Documents[i]
+		err = tx.QueryRow(`
+			INSERT INTO kyc_documents (id, submission_id, kind, storage_key, file_name, content_type, size_bytes, sha256)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING created_at
+		`, d.ID, s.ID, d.Kind, d.StorageKey, d.FileName, d.ContentType, d.SizeBytes, d.SHA256).Scan(&d.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := insertKYCHistory(tx, s.ID, s.UserID, &s.UserID, "submitted", previousStatus, "pending", nil); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE users SET kyc_status = 'pending', updated_at = NOW() WHERE id = $1", s.UserID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// saveKYCDocument validates an uploaded file and stores it encrypted through the storage service
+func (h *KYCHandler) saveKYCDocument(ctx context.Context, submissionID uuid.UUID, kind string, fh *multipart.FileHeader) (*models.KYCDocument, error) {
+	maxSize := getKYCMaxFileSize()
+	if fh.Size > maxSize {
+		return nil, errKYCFileTooLarge
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// The storage service sniffs the content; the client supplied content type and extension are ignored
+	object, err := h.Storage.Save(ctx, "kyc/"+submissionID.String(), f, services.UploadOptions{
+		MaxBytes:     maxSize,
+		AllowedTypes: kycAllowedContentTypes,
+	})
+	switch err {
+	case nil:
+	case services.ErrBlobTooLarge:
+		return nil, errKYCFileTooLarge
+	case services.ErrBlobTypeNotAllowed:
+		return nil, errKYCFileType
+	default:
+		return nil, err
+	}
+
+	return &models.KYCDocument{
+		ID:           uuid.New(),
+		SubmissionID: submissionID,
+		Kind:         kind,
+		StorageKey:   &object.Key,
+		FileName:     filepath.Base(fh.Filename),
+		ContentType:  object.ContentType,
+		SizeBytes:    object.Size,
+		SHA256:       object.SHA256,
+	}, nil
+}
+
+// removeKYCDocuments deletes the stored files of a submission that could not be saved
+func (h *KYCHandler) removeKYCDocuments(documents []models.KYCDocument) {
+	for _, doc := range documents {
+		if doc.StorageKey != nil {
+			h.Storage.Delete(context.Background(), *doc.StorageKey)
+		}
+	}
+}
+
+// openKYCDocument returns the decrypted content of a document. Documents uploaded before
+// the storage service existed are read from KYC_UPLOAD_DIR.
+func (h *KYCHandler) openKYCDocument(ctx context.Context, doc *models.KYCDocument) (io.ReadSeeker, int64, error) {
+	if doc.StorageKey != nil {
+		return h.Storage.Open(ctx, *doc.StorageKey)
+	}
+	if doc.FilePath == nil {
+		return nil, 0, services.ErrBlobNotFound
+	}
+
+	data, err := os.ReadFile(filepath.Join(getKYCUploadDir(), *doc.FilePath))
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// insertKYCHistory appends an entry to the immutable KYC history
+func insertKYCHistory(tx *sql.Tx, submissionID, userID uuid.UUID, actorID *uuid.UUID, action, fromStatus, toStatus string, reason *string) error {
+	_, err := tx.Exec(`
+		INSERT INTO kyc_history (id, submission_id, user_id, actor_id, action, from_status, to_status, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, uuid.New(), submissionID, userID, actorID, action, fromStatus, toStatus, reason)
+	return err
+}
+
+// getKYCSubmission loads a submission with its documents. The document number is decrypted.
+func getKYCSubmission(db *sql.DB, submissionID uuid.UUID) (*models.KYCSubmission, error) {
+	var s models.KYCSubmission
+	var dateOfBirth time.Time
+	err := db.QueryRow(`
+		SELECT id, user_id, status, first_name, last_name, date_of_birth, nationality, country,
+			   city, address, postal_code, document_type, document_number, reviewed_by, reviewed_at,
+			   rejection_reason, created_at, updated_at
+		FROM kyc_submissions
+		WHERE id = $1
+	`, submissionID).Scan(
+		&s.ID, &s.UserID, &s.Status, &s.FirstName, &s.LastName, &dateOfBirth, &s.Nationality, &s.Country,
+		&s.City, &s.Address, &s.PostalCode, &s.DocumentType, &s.DocumentNumber, &s.ReviewedBy, &s.ReviewedAt,
+		&s.RejectionReason, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.DateOfBirth = dateOfBirth.Format("2006-01-02")
+
+	if documentNumber, err := services.DecryptString(s.DocumentNumber); err == nil {
+		s.DocumentNumber = documentNumber
+	} else {
+		s.DocumentNumber = ""
+	}
+
+	rows, err := db.Query(`
+		SELECT id, submission_id, kind, storage_key, file_path, file_name, content_type, size_bytes, sha256, created_at
+		FROM kyc_documents
+		WHERE submission_id = $1
+		ORDER BY kind
+	`, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	s.Documents = []models.KYCDocument{}
+	for rows.Next() {
+		var d models.KYCDocument
+		if err := rows.Scan(&d.ID, &d.SubmissionID, &d.Kind, &d.StorageKey, &d.FilePath, &d.FileName, &d.ContentType,
+			&d.SizeBytes, &d.SHA256, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.Documents = append(s.Documents, d)
+	}
+
+	return &s, rows.Err()
+}
+
+// getKYCHistory returns all KYC history entries of a user, newest first
+func getKYCHistory(db *sql.DB, userID uuid.UUID) ([]models.KYCHistoryEntry, error) {
+	rows, err := db.Query(`
+		SELECT id, submission_id, user_id, actor_id, action, from_status, to_status, reason, created_at
+		FROM kyc_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.KYCHistoryEntry{}
+	for rows.Next() {
+		var e models.KYCHistoryEntry
+		if err := rows.Scan(&e.ID, &e.SubmissionID, &e.UserID, &e.ActorID, &e.Action,
+			&e.FromStatus, &e.ToStatus, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, e)
+	}
+
+	return history, rows.Err()
+}
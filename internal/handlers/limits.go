@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// usdDecimals is the scale of limit values (NUMERIC(20, 2))
+const usdDecimals = 2
+
+var errLimitUnpriced = errors.New("coin has no price to value the limit")
+
+// limitQueryer is implemented by *sql.DB and *sql.Tx so limits can be re-checked inside a transaction
+type limitQueryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// featureDisabledError is returned when the user's KYC tier does not unlock a feature
+type featureDisabledError struct {
+	Feature   string
+	KYCStatus string
+}
+
+func (e *featureDisabledError) Error() string {
+	return fmt.Sprintf("%s is not available for kyc status %s", e.Feature, e.KYCStatus)
+}
+
+// limitExceededError is returned when an amount would exceed a cap. Values are USD.
+type limitExceededError struct {
+	Kind   string // withdraw, transfer or deposit
+	Period string // daily or monthly
+	Limit  *big.Rat
+	Used   *big.Rat
+}
+
+func (e *limitExceededError) Error() string {
+	return fmt.Sprintf("%s %s limit exceeded", e.Period, e.Kind)
+}
+
+// coinCaps holds the USD caps that apply to one coin; nil means unlimited
+type coinCaps struct {
+	WithdrawDaily   *big.Rat
+	WithdrawMonthly *big.Rat
+	DepositDaily    *big.Rat
+	DepositMonthly  *big.Rat
+}
+
+// periods returns the daily and monthly caps for a transaction type. Transfers to other users
+// share the withdrawal caps.
+func (c *coinCaps) periods(kind string) (*big.Rat, *big.Rat) {
+	if kind == "deposit" {
+		return c.DepositDaily, c.DepositMonthly
+	}
+	return c.WithdrawDaily, c.WithdrawMonthly
+}
+
+type LimitsHandler struct {
+	DB *sql.DB
+}
+
+func NewLimitsHandler(db *sql.DB) *LimitsHandler {
+	return &LimitsHandler{DB: db}
+}
+
+// GetLimits godoc
+// @Summary Get account limits
+// @Description Get the features unlocked by the user's KYC status and the daily (UTC day) and monthly (UTC calendar month) deposit and withdrawal caps per coin with used and remaining amounts. Transfers to other users count toward the withdrawal caps and received transfers toward the deposit caps. Caps are USD values at the coin's current price; a null limit means unlimited.
+// @Tags Limits
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} models.LimitsResponse "Account limits"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/limits [get]
+func (h *LimitsHandler) GetLimits(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	kycStatus, features, err := getKYCTier(h.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve KYC tier"})
+		return
+	}
+
+	rows, err := h.DB.Query("SELECT id, ticker, price FROM coins WHERE status = 1 ORDER BY ticker ASC")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch coins"})
+		return
+	}
+	defer rows.Close()
+
+	type limitCoin struct {
+		ID     int
+		Ticker string
+		Price  string
+	}
+	coins := []limitCoin{}
+	for rows.Next() {
+		var coin limitCoin
+		if err := rows.Scan(&coin.ID, &coin.Ticker, &coin.Price); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch coins"})
+			return
+		}
+		coins = append(coins, coin)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch coins"})
+		return
+	}
+
+	response := models.LimitsResponse{
+		KYCStatus: kycStatus,
+		Features:  *features,
+		Coins:     []models.CoinLimits{},
+	}
+
+	for _, coin := range coins {
+		price, ok := new(big.Rat).SetString(coin.Price)
+		if !ok {
+			price = new(big.Rat)
+		}
+
+		caps, err := getCoinCaps(h.DB, kycStatus, coin.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve limits"})
+			return
+		}
+
+		limits := models.CoinLimits{Coin: coin.Ticker, Price: coin.Price}
+		for _, kind := range []string{"withdraw", "deposit"} {
+			usedDaily, usedMonthly, err := getLimitUsage(h.DB, userID, coin.ID, kind, time.Now())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve limit usage"})
+				return
+			}
+
+			capDaily, capMonthly := caps.periods(kind)
+			periods := models.LimitPeriods{
+				Daily:   limitWindow(capDaily, usedDaily, price),
+				Monthly: limitWindow(capMonthly, usedMonthly, price),
+			}
+			if kind == "deposit" {
+				limits.Deposit = periods
+			} else {
+				limits.Withdraw = periods
+			}
+		}
+
+		response.Coins = append(response.Coins, limits)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// checkFeature returns an error unless the user's KYC tier unlocks the feature
+// (deposit, withdraw, transfer or trade). It returns the user's KYC status.
+func checkFeature(q limitQueryer, userID uuid.UUID, feature string) (string, error) {
+	kycStatus, features, err := getKYCTier(q, userID)
+	if err != nil {
+		return "", err
+	}
+
+	var enabled bool
+	switch feature {
+	case "deposit":
+		enabled = features.Deposit
+	case "withdraw":
+		enabled = features.Withdraw
+	case "transfer":
+		enabled = features.Transfer
+	case "trade":
+		enabled = features.Trade
+	}
+	if !enabled {
+		return kycStatus, &featureDisabledError{Feature: feature, KYCStatus: kycStatus}
+	}
+
+	return kycStatus, nil
+}
+
+// checkLimit verifies that a deposit, withdrawal or transfer of amount (in coin units) is unlocked
+// by the user's KYC tier and stays within the tier's daily and monthly caps for the coin
+func checkLimit(q limitQueryer, userID uuid.UUID, coinID int, kind string, amount *big.Rat) error {
+	kycStatus, err := checkFeature(q, userID, kind)
+	if err != nil {
+		return err
+	}
+
+	caps, err := getCoinCaps(q, kycStatus, coinID)
+	if err != nil {
+		return err
+	}
+	capDaily, capMonthly := caps.periods(kind)
+	if capDaily == nil && capMonthly == nil {
+		return nil
+	}
+
+	var rawPrice string
+	if err := q.QueryRow("SELECT price FROM coins WHERE id = $1", coinID).Scan(&rawPrice); err != nil {
+		return err
+	}
+	price, ok := new(big.Rat).SetString(rawPrice)
+	if !ok || price.Sign() <= 0 {
+		// Without a price the caps can not be valued; refuse rather than allow unlimited amounts
+		return errLimitUnpriced
+	}
+
+	usedDaily, usedMonthly, err := getLimitUsage(q, userID, coinID, kind, time.Now())
+	if err != nil {
+		return err
+	}
+
+	requested := new(big.Rat).Mul(amount, price)
+	windows := []struct {
+		period string
+		cap    *big.Rat
+		used   *big.Rat
+	}{
+		{"daily", capDaily, usedDaily},
+		{"monthly", capMonthly, usedMonthly},
+	}
+	for _, w := range windows {
+		if w.cap == nil {
+			continue
+		}
+		used := new(big.Rat).Mul(w.used, price)
+		if new(big.Rat).Add(used, requested).Cmp(w.cap) > 0 {
+			return &limitExceededError{Kind: kind, Period: w.period, Limit: w.cap, Used: used}
+		}
+	}
+
+	return nil
+}
+
+// respondLimitError writes the response for an error returned by checkFeature or checkLimit
+func respondLimitError(c *gin.Context, err error) {
+	var featureErr *featureDisabledError
+	var limitErr *limitExceededError
+
+	switch {
+	case errors.As(err, &featureErr):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      "feature_disabled",
+			"message":    fmt.Sprintf("Your verification level does not allow %s. Complete identity verification to unlock it.", featureLabel(featureErr.Feature)),
+			"feature":    featureErr.Feature,
+			"kyc_status": featureErr.KYCStatus,
+		})
+	case errors.As(err, &limitErr):
+		remaining := new(big.Rat).Sub(limitErr.Limit, limitErr.Used)
+		if remaining.Sign() < 0 {
+			remaining.SetInt64(0)
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":     "limit_exceeded",
+			"message":   fmt.Sprintf("This exceeds your %s %s limit", limitErr.Period, featureLabel(limitErr.Kind)),
+			"period":    limitErr.Period,
+			"limit":     limitErr.Limit.FloatString(usdDecimals),
+			"used":      limitErr.Used.FloatString(usdDecimals),
+			"remaining": truncateRat(remaining, usdDecimals),
+		})
+	case err == errLimitUnpriced:
+		c.JSON(http.StatusForbidden, gin.H{"error": "limit_unavailable", "message": "Limits can not be evaluated for this coin right now"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to check limits"})
+	}
+}
+
+// featureLabel returns the plural noun used in messages for a feature
+func featureLabel(feature string) string {
+	switch feature {
+	case "deposit":
+		return "deposits"
+	case "withdraw":
+		return "withdrawals"
+	case "transfer":
+		return "transfers"
+	default:
+		return "trading"
+	}
+}
+
+// getKYCTier returns the user's KYC status and the features it unlocks.
+// A status without a kyc_tiers row unlocks nothing.
+func getKYCTier(q limitQueryer, userID uuid.UUID) (string, *models.KYCTierFeatures, error) {
+	var kycStatus string
+	var f models.KYCTierFeatures
+	err := q.QueryRow(`
+		SELECT u.kyc_status,
+			   COALESCE(t.can_deposit, FALSE), COALESCE(t.can_withdraw, FALSE),
+			   COALESCE(t.can_transfer, FALSE), COALESCE(t.can_trade, FALSE)
+		FROM users u
+		LEFT JOIN kyc_tiers t ON t.kyc_status = u.kyc_status
+		WHERE u.id = $1
+	`, userID).Scan(&kycStatus, &f.Deposit, &f.Withdraw, &f.Transfer, &f.Trade)
+	if err != nil {
+		return "", nil, err
+	}
+	return kycStatus, &f, nil
+}
+
+// getCoinCaps returns the caps of a KYC tier for a coin. A coin specific row overrides the tier default.
+func getCoinCaps(q limitQueryer, kycStatus string, coinID int) (*coinCaps, error) {
+	var withdrawDaily, withdrawMonthly, depositDaily, depositMonthly sql.NullString
+	err := q.QueryRow(`
+		SELECT withdraw_daily, withdraw_monthly, deposit_daily, deposit_monthly
+		FROM kyc_limits
+		WHERE kyc_status = $1 AND (coin_id = $2 OR coin_id IS NULL)
+		ORDER BY coin_id NULLS LAST
+		LIMIT 1
+	`, kycStatus, coinID).Scan(&withdrawDaily, &withdrawMonthly, &depositDaily, &depositMonthly)
+	if err == sql.ErrNoRows {
+		return &coinCaps{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &coinCaps{
+		WithdrawDaily:   nullRat(withdrawDaily),
+		WithdrawMonthly: nullRat(withdrawMonthly),
+		DepositDaily:    nullRat(depositDaily),
+		DepositMonthly:  nullRat(depositMonthly),
+	}, nil
+}
+
+// getLimitUsage returns the amounts (coin units) counted against the caps of a transaction type in
+// the current UTC day and month. Withdrawals and outgoing transfers count together; received
+// transfers are recorded as deposits. Failed and cancelled transactions do not count.
+func getLimitUsage(q limitQueryer, userID uuid.UUID, coinID int, kind string, now time.Time) (*big.Rat, *big.Rat, error) {
+	types := []string{kind}
+	if kind == "withdraw" || kind == "transfer" {
+		types = []string{"withdraw", "transfer"}
+	}
+
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var daily, monthly string
+	err := q.QueryRow(`
+		SELECT COALESCE(SUM(t.amount) FILTER (WHERE t.created_at >= $4), 0), COALESCE(SUM(t.amount), 0)
+		FROM transactions t
+		JOIN wallets w ON w.id = t.wallet_id
+		WHERE t.user_id = $1 AND w.coin_id = $2 AND t.type = ANY($3)
+		  AND t.status NOT IN ('failed', 'cancelled')
+		  AND t.created_at >= $5
+	`, userID, coinID, pq.Array(types), dayStart, monthStart).Scan(&daily, &monthly)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	usedDaily, ok := new(big.Rat).SetString(daily)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid usage amount %q", daily)
+	}
+	usedMonthly, ok := new(big.Rat).SetString(monthly)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid usage amount %q", monthly)
+	}
+	return usedDaily, usedMonthly, nil
+}
+
+// limitWindow builds the response for one cap. used is in coin units, cap is USD.
+func limitWindow(cap, usedAmount, price *big.Rat) models.LimitWindow {
+	used := new(big.Rat).Mul(usedAmount, price)
+	window := models.LimitWindow{Used: used.FloatString(usdDecimals)}
+	if cap == nil {
+		return window
+	}
+
+	remaining := new(big.Rat).Sub(cap, used)
+	if remaining.Sign() < 0 {
+		remaining.SetInt64(0)
+	}
+
+	limit := cap.FloatString(usdDecimals)
+	remainingUSD := truncateRat(remaining, usdDecimals)
+	window.Limit = &limit
+	window.Remaining = &remainingUSD
+
+	if price.Sign() > 0 {
+		remainingAmount := truncateRat(new(big.Rat).Quo(remaining, price), withdrawalDecimals)
+		window.RemainingAmount = &remainingAmount
+	}
+
+	return window
+}
+
+// truncateRat formats a value truncated toward zero to decimals, so remaining amounts never overstate the cap
+// and maker rebates are never overpaid
+func truncateRat(x *big.Rat, decimals int) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	scaled := new(big.Int).Mul(x.Num(), scale)
+	scaled.Quo(scaled, x.Denom())
+	return new(big.Rat).SetFrac(scaled, scale).FloatString(decimals)
+}
+
+// nullRat parses a nullable NUMERIC column; NULL becomes nil
+func nullRat(value sql.NullString) *big.Rat {
+	if !value.Valid {
+		return nil
+	}
+	r, ok := new(big.Rat).SetString(value.String)
+	if !ok {
+		return nil
+	}
+	return r
+}
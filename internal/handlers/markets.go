@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultCandleLimit = 500
+	maxCandleLimit     = 1000
+	defaultDepthLimit  = 100
+	maxDepthLimit      = 5000
+)
+
+type MarketHandler struct {
+	DB      *sql.DB
+	Trading *TradingEngine
+}
+
+func NewMarketHandler(db *sql.DB, trading *TradingEngine) *MarketHandler {
+	return &MarketHandler{DB: db, Trading: trading}
+}
+
+// GetMarkets godoc
+// @Summary List markets
+// @Description List the active markets with their trading rules, latest prices and 24h statistics
+// @Tags Markets
+// @Produce json
+// @Success 200 {object} map[string]interface{} "List of markets"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/markets [get]
+func (h *MarketHandler) GetMarkets(c *gin.Context) {
+	rows, err := h.DB.Query("SELECT " + marketColumns + " " + marketJoins + " WHERE m.is_active = true ORDER BY m.symbol")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch markets"})
+		return
+	}
+	defer rows.Close()
+
+	markets := []*models.Market{}
+	for rows.Next() {
+		market, err := scanMarket(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to read markets"})
+			return
+		}
+		markets = append(markets, market)
+	}
+	rows.Close()
+
+	// Tickers come from the in-memory statistics, not from the trades table
+	summaries := make([]models.MarketSummary, 0, len(markets))
+	for _, market := range markets {
+		ticker, err := h.Trading.Ticker(market.Symbol)
+		if err != nil {
+			fmt.Printf("Failed to get ticker %s: %v\n", market.Symbol, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to load market data"})
+			return
+		}
+		summaries = append(summaries, models.MarketSummary{Market: *market, Ticker: *ticker})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summaries})
+}
+
+// GetTicker godoc
+// @Summary Get a market ticker
+// @Description Get the last price, best bid and ask and the 24h open, high, low, volume, quote volume and change of a market. The 24h window moves by the minute.
+// @Tags Markets
+// @Produce json
+// @Param symbol path string true "Market symbol, e.g. BTC-USDT"
+// @Success 200 {object} models.MarketTicker "Ticker"
+// @Failure 404 {object} map[string]interface{} "Market not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/markets/{symbol}/ticker [get]
+func (h *MarketHandler) GetTicker(c *gin.Context) {
+	ticker, err := h.Trading.Ticker(c.Param("symbol"))
+	if err == errMarketNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "market_not_found", "message": "Market not found"})
+		return
+	}
+	if err != nil {
+		fmt.Printf("Failed to get ticker: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to load market data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticker)
+}
+
+// GetDepth godoc
+// @Summary Get order book depth
+// @Description Get the bids and asks of a market as [price, quantity] pairs, best first. tick groups levels into wider price steps (bids rounded down, asks up) and must be a multiple of the market's price step. update_id is the ID of the last diff on the "depth:SYMBOL" WebSocket channel included in the snapshot: to keep a local book, subscribe first, fetch the snapshot without a tick, drop diffs with an update_id up to the snapshot's and apply the rest in order. Every diff's update_id is one higher than the previous one.
+// @Tags Markets
+// @Produce json
+// @Param symbol path string true "Market symbol, e.g. BTC-USDT"
+// @Param limit query int false "Levels per side (default 100, max 5000)"
+// @Param tick query string false "Price step to group levels by, e.g. 10"
+// @Success 200 {object} models.OrderBookDepth "Order book snapshot"
+// @Failure 400 {object} map[string]interface{} "Invalid tick"
+// @Failure 404 {object} map[string]interface{} "Market not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/markets/{symbol}/depth [get]
+func (h *MarketHandler) GetDepth(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultDepthLimit)))
+	if err != nil || limit < 1 {
+		limit = defaultDepthLimit
+	}
+	if limit > maxDepthLimit {
+		limit = maxDepthLimit
+	}
+
+	depth, err := h.Trading.Depth(c.Param("symbol"), limit, strings.TrimSpace(c.Query("tick")))
+	switch {
+	case err == errMarketNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "market_not_found", "message": "Market not found"})
+		return
+	case err == errInvalidTick:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_tick", "message": "Tick must be a positive multiple of the market's price step"})
+		return
+	case err != nil:
+		fmt.Printf("Failed to get order book depth: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to load market data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, depth)
+}
+
+// GetCandles godoc
+// @Summary Get candles
+// @Description Get OHLCV candles of a market. With from, candles are returned from that time onwards; without it, the latest candles up to to (or now). Candles are sorted oldest first and timeframes without trades are left out. Timeframes above 1m are rebuilt from the next lower one every few seconds, so their last candle can briefly lag.
+// @Tags Markets
+// @Produce json
+// @Param symbol path string true "Market symbol, e.g. BTC-USDT"
+// @Param interval query string false "1m, 5m, 15m, 1h, 4h, 1d or 1w (default 1h)"
+// @Param from query string false "Earliest candle open time (RFC 3339)"
+// @Param to query string false "Candles opening before this time (RFC 3339)"
+// @Param limit query int false "Number of candles (default 500, max 1000)"
+// @Success 200 {object} map[string]interface{} "Market, interval and candles"
+// @Failure 400 {object} map[string]interface{} "Invalid interval or time range"
+// @Failure 404 {object} map[string]interface{} "Market not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/markets/{symbol}/candles [get]
+func (h *MarketHandler) GetCandles(c *gin.Context) {
+	tf, ok := getCandleTimeframe(c.DefaultQuery("interval", "1h"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_interval", "message": "Interval must be one of 1m, 5m, 15m, 1h, 4h, 1d, 1w"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultCandleLimit)))
+	if err != nil || limit < 1 {
+		limit = defaultCandleLimit
+	}
+	if limit > maxCandleLimit {
+		limit = maxCandleLimit
+	}
+
+	var bounds [2]*time.Time
+	for i, param := range []string{"from", "to"} {
+		if raw := c.Query(param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_time_range", "message": "Invalid " + param + ", expected RFC 3339"})
+				return
+			}
+			bounds[i] = &t
+		}
+	}
+	from, to := bounds[0], bounds[1]
+	if from != nil && to != nil && !from.Before(*to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_time_range", "message": "from must be before to"})
+		return
+	}
+
+	market, err := getMarket(h.DB, "UPPER(m.symbol) = $1", strings.ToUpper(c.Param("symbol")))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "market_not_found", "message": "Market not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve market"})
+		return
+	}
+
+	conditions := []string{"market_id = $1", "timeframe = $2"}
+	args := []interface{}{market.ID, tf.Name}
+	if from != nil {
+		args = append(args, *from)
+		conditions = append(conditions, "open_time >= $"+strconv.Itoa(len(args)))
+	}
+	if to != nil {
+		args = append(args, *to)
+		conditions = append(conditions, "open_time < $"+strconv.Itoa(len(args)))
+	}
+	// Without a start, the newest candles are wanted; they are put back in order below
+	order := "ASC"
+	if from == nil {
+		order = "DESC"
+	}
+	args = append(args, limit)
+
+	rows, err := h.DB.Query(`
+		SELECT open_time, open, high, low, close, volume, quote_volume, trade_count
+		FROM candles
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY open_time `+order+`
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch candles"})
+		return
+	}
+	defer rows.Close()
+
+	candles := []models.Candle{}
+	for rows.Next() {
+		var candle models.Candle
+		err := rows.Scan(&candle.OpenTime, &candle.Open, &candle.High, &candle.Low, &candle.Close,
+			&candle.Volume, &candle.QuoteVolume, &candle.TradeCount)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to read candles"})
+			return
+		}
+		candle.OpenTime = candle.OpenTime.UTC()
+		candle.CloseTime = candle.OpenTime.Add(tf.Step)
+		candles = append(candles, candle)
+	}
+	if from == nil {
+		for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+			candles[i], candles[j] = candles[j], candles[i]
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"market":   market.Symbol,
+		"interval": tf.Name,
+		"data":     candles,
+	})
+}
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+const monitoringRuleColumns = `
+	id, code, name, description, rule_type, event_type, threshold_usd, window_minutes, min_count, ratio,
+	severity, action, enabled, created_at, updated_at
+`
+
+const monitoringAlertColumns = `
+	a.id, a.rule_id, r.code, a.user_id, a.transaction_id, a.login_event_id, a.severity, a.details,
+	a.account_frozen, a.previous_status, a.status, a.reviewed_by, a.reviewed_at, a.review_note,
+	a.created_at, a.updated_at
+`
+
+// monitoringEvent is a transaction or sign-in evaluated against the monitoring rules
+type monitoringEvent struct {
+	Type          string // deposit, withdraw, login
+	UserID        uuid.UUID
+	TransactionID *uuid.UUID
+	LoginEventID  *uuid.UUID
+	AmountUSD     *big.Rat // Transactions only, valued at the coin's current price
+	NewDevice     bool     // Sign-ins only
+	At            time.Time
+}
+
+// recordLoginEvent stores a successful sign-in. The device is identified by the User-Agent and the
+// optional X-Device-ID header. The first device of an account is not counted as a new device.
+func recordLoginEvent(db *sql.DB, userID uuid.UUID, ip, userAgent, deviceID string) (*monitoringEvent, error) {
+	sum := sha256.Sum256([]byte(userAgent + "\n" + deviceID))
+	deviceHash := hex.EncodeToString(sum[:])
+
+	event := &monitoringEvent{Type: "login", UserID: userID}
+	var eventID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO login_events (user_id, ip_address, user_agent, device_hash, new_device)
+		VALUES ($1, $2, $3, $4,
+			EXISTS (SELECT 1 FROM login_events WHERE user_id = $1)
+			AND NOT EXISTS (SELECT 1 FROM login_events WHERE user_id = $1 AND device_hash = $4))
+		RETURNING id, new_device, created_at
+	`, userID, ip, userAgent, deviceHash).Scan(&eventID, &event.NewDevice, &event.At)
+	if err != nil {
+		return nil, err
+	}
+	event.LoginEventID = &eventID
+
+	return event, nil
+}
+
+// evaluateTransaction runs the enabled rules for the transaction's type and raises alerts.
+// It returns true when a rule froze the account.
+func evaluateTransaction(db *sql.DB, transactionID uuid.UUID) (bool, error) {
+	event := &monitoringEvent{TransactionID: &transactionID}
+	var amountUSD string
+	err := db.QueryRow(`
+		SELECT t.user_id, t.type, t.created_at, t.amount * c.price
+		FROM transactions t
+		JOIN wallets w ON w.id = t.wallet_id
+		JOIN coins c ON c.id = w.coin_id
+		WHERE t.id = $1
+	`, transactionID).Scan(&event.UserID, &event.Type, &event.At, &amountUSD)
+	if err != nil {
+		return false, err
+	}
+
+	usd, ok := new(big.Rat).SetString(amountUSD)
+	if !ok {
+		return false, fmt.Errorf("invalid transaction value %q", amountUSD)
+	}
+	event.AmountUSD = usd
+
+	return evaluateMonitoringEvent(db, event)
+}
+
+// evaluateMonitoringEvent runs the enabled rules for the event type. A failing rule does not stop
+// the others; the first error is returned after all rules ran.
+func evaluateMonitoringEvent(db *sql.DB, event *monitoringEvent) (bool, error) {
+	rows, err := db.Query("SELECT "+monitoringRuleColumns+" FROM monitoring_rules WHERE enabled AND event_type = $1 ORDER BY id", event.Type)
+	if err != nil {
+		return false, err
+	}
+	rules := []models.MonitoringRule{}
+	for rows.Next() {
+		rule, err := scanMonitoringRule(rows)
+		if err != nil {
+			rows.Close()
+			return false, err
+		}
+		rules = append(rules, *rule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	frozen := false
+	var firstErr error
+	for i := range rules {
+		rule := &rules[i]
+		details, err := matchMonitoringRule(db, rule, event)
+		if err == nil && details != nil {
+			var froze bool
+			froze, err = raiseMonitoringAlert(db, rule, event, details)
+			frozen = frozen || froze
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("rule %s: %w", rule.Code, err)
+		}
+	}
+
+	return frozen, firstErr
+}
+
+// matchMonitoringRule returns the observed values when the event triggers the rule, or nil
+func matchMonitoringRule(db *sql.DB, rule *models.MonitoringRule, event *monitoringEvent) (map[string]interface{}, error) {
+	threshold := ruleRat(rule.ThresholdUSD)
+	ratio := ruleRat(rule.Ratio)
+	var windowStart time.Time
+	if rule.WindowMinutes != nil {
+		windowStart = event.At.Add(-time.Duration(*rule.WindowMinutes) * time.Minute)
+
+		// A windowed rule raises one open alert per window, not one per event
+		var recent bool
+		err := db.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM monitoring_alerts
+				WHERE rule_id = $1 AND user_id = $2 AND status = 'open' AND created_at > $3
+			)
+		`, rule.ID, event.UserID, time.Now().Add(-time.Duration(*rule.WindowMinutes)*time.Minute)).Scan(&recent)
+		if err != nil || recent {
+			return nil, err
+		}
+	}
+
+	switch rule.RuleType {
+	case "threshold":
+		if threshold == nil || event.AmountUSD == nil || event.AmountUSD.Cmp(threshold) < 0 {
+			return nil, nil
+		}
+		return map[string]interface{}{
+			"amount_usd":    event.AmountUSD.FloatString(usdDecimals),
+			"threshold_usd": threshold.FloatString(usdDecimals),
+		}, nil
+
+	case "structuring":
+		// Several amounts each within ratio below the threshold, e.g. 9,000-9,999.99 for 10,000 and 0.1
+		if threshold == nil || ratio == nil || rule.MinCount == nil || rule.WindowMinutes == nil || event.AmountUSD == nil {
+			return nil, nil
+		}
+		lower := new(big.Rat).Mul(threshold, new(big.Rat).Sub(big.NewRat(1, 1), ratio))
+		if event.AmountUSD.Cmp(lower) < 0 || event.AmountUSD.Cmp(threshold) >= 0 {
+			return nil, nil
+		}
+		count, total, err := monitoringTransactionStats(db, event, windowStart, lower, threshold)
+		if err != nil || count < *rule.MinCount {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"count":          count,
+			"total_usd":      total.FloatString(usdDecimals),
+			"band_low_usd":   lower.FloatString(usdDecimals),
+			"threshold_usd":  threshold.FloatString(usdDecimals),
+			"window_minutes": *rule.WindowMinutes,
+		}, nil
+
+	case "velocity":
+		if rule.WindowMinutes == nil {
+			return nil, nil
+		}
+		if event.Type == "login" {
+			if !event.NewDevice || rule.MinCount == nil {
+				return nil, nil
+			}
+			var count int
+			err := db.QueryRow(`
+				SELECT COUNT(*) FROM login_events
+				WHERE user_id = $1 AND new_device AND created_at > $2 AND created_at <= $3
+			`, event.UserID, windowStart, event.At).Scan(&count)
+			if err != nil || count < *rule.MinCount {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"new_devices":    count,
+				"window_minutes": *rule.WindowMinutes,
+			}, nil
+		}
+
+		count, total, err := monitoringTransactionStats(db, event, windowStart, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		countHit := rule.MinCount != nil && count >= *rule.MinCount
+		totalHit := threshold != nil && total.Cmp(threshold) >= 0
+		if !countHit && !totalHit {
+			return nil, nil
+		}
+		details := map[string]interface{}{
+			"count":          count,
+			"total_usd":      total.FloatString(usdDecimals),
+			"window_minutes": *rule.WindowMinutes,
+		}
+		if rule.MinCount != nil {
+			details["min_count"] = *rule.MinCount
+		}
+		if threshold != nil {
+			details["threshold_usd"] = threshold.FloatString(usdDecimals)
+		}
+		return details, nil
+
+	case "deposit_withdraw":
+		// Most of what was deposited in the window is withdrawn again
+		if threshold == nil || ratio == nil || rule.WindowMinutes == nil || event.Type != "withdraw" {
+			return nil, nil
+		}
+		deposits, err := monitoringTypeTotal(db, event.UserID, "deposit", windowStart, event.At)
+		if err != nil || deposits.Cmp(threshold) < 0 {
+			return nil, err
+		}
+		withdrawals, err := monitoringTypeTotal(db, event.UserID, "withdraw", windowStart, event.At)
+		if err != nil || withdrawals.Cmp(new(big.Rat).Mul(deposits, ratio)) < 0 {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"deposits_usd":    deposits.FloatString(usdDecimals),
+			"withdrawals_usd": withdrawals.FloatString(usdDecimals),
+			"ratio":           ratio.FloatString(4),
+			"window_minutes":  *rule.WindowMinutes,
+		}, nil
+
+	case "new_device_withdrawal":
+		if rule.WindowMinutes == nil || event.Type != "withdraw" {
+			return nil, nil
+		}
+		var loginID uuid.UUID
+		var ip sql.NullString
+		var loginAt time.Time
+		err := db.QueryRow(`
+			SELECT id, ip_address, created_at FROM login_events
+			WHERE user_id = $1 AND new_device AND created_at > $2 AND created_at <= $3
+			ORDER BY created_at DESC
+			LIMIT 1
+		`, event.UserID, windowStart, event.At).Scan(&loginID, &ip, &loginAt)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"login_event_id": loginID,
+			"login_ip":       ip.String,
+			"login_at":       loginAt,
+			"window_minutes": *rule.WindowMinutes,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// monitoringTransactionStats counts and sums (USD) the user's transactions of the event type in the
+// window, optionally only those valued within [low, high). Failed and cancelled transactions do not count.
+func monitoringTransactionStats(db *sql.DB, event *monitoringEvent, from time.Time, low, high *big.Rat) (int, *big.Rat, error) {
+	var lowArg, highArg interface{}
+	if low != nil {
+		lowArg = low.FloatString(8)
+	}
+	if high != nil {
+		highArg = high.FloatString(8)
+	}
+
+	var count int
+	var total string
+	err := db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(t.amount * c.price), 0)
+		FROM transactions t
+		JOIN wallets w ON w.id = t.wallet_id
+		JOIN coins c ON c.id = w.coin_id
+		WHERE t.user_id = $1 AND t.type = $2 AND t.status NOT IN ('failed', 'cancelled')
+		  AND t.created_at > $3 AND t.created_at <= $4
+		  AND ($5::numeric IS NULL OR t.amount * c.price >= $5::numeric)
+		  AND ($6::numeric IS NULL OR t.amount * c.price < $6::numeric)
+	`, event.UserID, event.Type, from, event.At, lowArg, highArg).Scan(&count, &total)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	sum, ok := new(big.Rat).SetString(total)
+	if !ok {
+		return 0, nil, fmt.Errorf("invalid transaction total %q", total)
+	}
+	return count, sum, nil
+}
+
+// monitoringTypeTotal sums (USD) the user's transactions of a type in the window
+func monitoringTypeTotal(db *sql.DB, userID uuid.UUID, txType string, from, to time.Time) (*big.Rat, error) {
+	_, total, err := monitoringTransactionStats(db, &monitoringEvent{UserID: userID, Type: txType, At: to}, from, nil, nil)
+	return total, err
+}
+
+// raiseMonitoringAlert opens an alert for the event and, for freeze rules, sets the account status
+// to frozen. It returns true when the account was frozen by this alert.
+func raiseMonitoringAlert(db *sql.DB, rule *models.MonitoringRule, event *monitoringEvent, details map[string]interface{}) (bool, error) {
+	rawDetails, err := json.Marshal(details)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Only active and pending accounts are frozen; other statuses already block the account
+	var previousStatus sql.NullString
+	if rule.Action == "freeze" {
+		err := tx.QueryRow(`
+			UPDATE users u SET status = 'frozen', updated_at = NOW()
+			FROM (SELECT id, status FROM users WHERE id = $1 FOR UPDATE) previous
+			WHERE u.id = previous.id AND previous.status IN ('active', 'pending')
+			RETURNING previous.status
+		`, event.UserID).Scan(&previousStatus)
+		if err != nil && err != sql.ErrNoRows {
+			return false, err
+		}
+	}
+
+	var alertID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO monitoring_alerts (rule_id, user_id, transaction_id, login_event_id, severity, details, account_frozen, previous_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	`, rule.ID, event.UserID, event.TransactionID, event.LoginEventID, rule.Severity, rawDetails,
+		previousStatus.Valid, previousStatus).Scan(&alertID)
+	if err == sql.ErrNoRows {
+		// The event already raised this rule
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	fmt.Printf("Monitoring rule %s raised alert %s for user %s\n", rule.Code, alertID, event.UserID)
+	return previousStatus.Valid, nil
+}
+
+// validateMonitoringRule checks that the rule has the parameters its type needs
+func validateMonitoringRule(rule *models.MonitoringRule) error {
+	threshold := ruleRat(rule.ThresholdUSD)
+	ratio := ruleRat(rule.Ratio)
+	if rule.ThresholdUSD != nil && (threshold == nil || threshold.Sign() <= 0) {
+		return fmt.Errorf("threshold_usd must be greater than 0")
+	}
+	if rule.Ratio != nil && (ratio == nil || ratio.Sign() <= 0 || ratio.Cmp(big.NewRat(1, 1)) > 0) {
+		return fmt.Errorf("ratio must be greater than 0 and at most 1")
+	}
+
+	var missing []string
+	require := func(ok bool, name string) {
+		if !ok {
+			missing = append(missing, name)
+		}
+	}
+	switch rule.RuleType {
+	case "threshold":
+		require(threshold != nil, "threshold_usd")
+	case "structuring":
+		require(threshold != nil, "threshold_usd")
+		require(ratio != nil, "ratio")
+		require(rule.WindowMinutes != nil, "window_minutes")
+		require(rule.MinCount != nil, "min_count")
+	case "velocity":
+		require(rule.WindowMinutes != nil, "window_minutes")
+		if rule.EventType == "login" {
+			require(rule.MinCount != nil, "min_count")
+		} else {
+			require(rule.MinCount != nil || threshold != nil, "min_count or threshold_usd")
+		}
+	case "deposit_withdraw":
+		require(threshold != nil, "threshold_usd")
+		require(ratio != nil, "ratio")
+		require(rule.WindowMinutes != nil, "window_minutes")
+	case "new_device_withdrawal":
+		require(rule.WindowMinutes != nil, "window_minutes")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s rules require %s", rule.RuleType, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// ruleRat parses an optional NUMERIC rule parameter; nil or invalid becomes nil
+func ruleRat(value *string) *big.Rat {
+	if value == nil {
+		return nil
+	}
+	return nullRat(sql.NullString{String: *value, Valid: true})
+}
+
+func scanMonitoringRule(row rowScanner) (*models.MonitoringRule, error) {
+	var rule models.MonitoringRule
+	err := row.Scan(&rule.ID, &rule.Code, &rule.Name, &rule.Description, &rule.RuleType, &rule.EventType,
+		&rule.ThresholdUSD, &rule.WindowMinutes, &rule.MinCount, &rule.Ratio, &rule.Severity, &rule.Action,
+		&rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func scanMonitoringAlert(row rowScanner) (*models.MonitoringAlert, error) {
+	var alert models.MonitoringAlert
+	var details []byte
+	err := row.Scan(&alert.ID, &alert.RuleID, &alert.RuleCode, &alert.UserID, &alert.TransactionID, &alert.LoginEventID,
+		&alert.Severity, &details, &alert.AccountFrozen, &alert.PreviousStatus, &alert.Status, &alert.ReviewedBy,
+		&alert.ReviewedAt, &alert.ReviewNote, &alert.CreatedAt, &alert.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	alert.Details = details
+	return &alert, nil
+}
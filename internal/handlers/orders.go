@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OrderHandler struct {
+	DB      *sql.DB
+	Trading *TradingEngine
+}
+
+func NewOrderHandler(db *sql.DB, trading *TradingEngine) *OrderHandler {
+	return &OrderHandler{DB: db, Trading: trading}
+}
+
+// PlaceOrder godoc
+// @Summary Place an order
+// @Description Place a limit or market order. Limit orders need a price and a quantity; market sells need a quantity and market buys a quantity or a quote_quantity to spend. Prices and quantities must respect the market's precision, minimum/maximum quantity and minimum order value. Stop (stop_market, stop_limit) and take-profit (take_profit, take_profit_limit) orders need a stop_price and wait with status untriggered until the trigger_by price (last_price or mark_price) reaches it, then run as a market or limit order; a stop_price the price has already reached is rejected. An oco order places a limit order at price and a stop order at stop_price (stop_limit_price for a stop_limit leg, required for buys) and returns the limit order; when one leg fills or triggers, the other is cancelled. time_in_force is GTC (default for limit orders), IOC (default for market orders), FOK or GTD (with expire_at; the order expires and its funds are released after it). Post-only limit orders that would take liquidity are rejected, or repriced one step inside the spread with post_only_mode reprice. self_trade_prevention (default: the account's trading setting) decides what happens when the order would match another order of the user; every prevented match is reported on the "orders" channel. The funds the order can spend are moved to the frozen balance until it fills or is cancelled. Updates are pushed on the private "orders" and "balances" WebSocket channels.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.PlaceOrderRequest true "Order"
+// @Success 201 {object} models.Order "Order placed"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors, or rejected (error is the reason code, e.g. insufficient_balance, fok_not_filled, post_only_would_take, invalid_expire_at; also sent on the orders channel)"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Account not active or trading not allowed for the KYC tier"
+// @Failure 404 {object} map[string]interface{} "Market not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/orders [post]
+func (h *OrderHandler) PlaceOrder(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.PlaceOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var status string
+	if err := h.DB.QueryRow("SELECT status FROM users WHERE id = $1", userID).Scan(&status); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+	if status != "active" && status != "pending" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "account_inactive", "message": "Account is not active. Please contact support."})
+		return
+	}
+
+	if _, err := checkFeature(h.DB, userID, "trade"); err != nil {
+		respondLimitError(c, err)
+		return
+	}
+
+	order, err := h.Trading.PlaceOrder(userID, &req)
+	if err != nil {
+		respondOrderError(c, err, "Failed to place order")
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// CancelOrder godoc
+// @Summary Cancel an order
+// @Description Cancel an open or untriggered order and release its frozen funds. Cancelling either leg of an OCO pair cancels both.
+// @Tags Orders
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Success 200 {object} models.Order "Order cancelled"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order is already filled or cancelled"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/orders/{id} [delete]
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order_not_found", "message": "Order not found"})
+		return
+	}
+
+	order, err := h.Trading.CancelOrder(userID, orderID)
+	if err != nil {
+		respondOrderError(c, err, "Failed to cancel order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// GetOrders godoc
+// @Summary List orders
+// @Description List the authenticated user's orders, newest first
+// @Tags Orders
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param market query string false "Market symbol, e.g. BTC-USDT"
+// @Param status query string false "open, closed or all (default all)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page (max 100)"
+// @Success 200 {object} map[string]interface{} "List of orders with pagination"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/orders [get]
+func (h *OrderHandler) GetOrders(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	conditions := []string{"o.user_id = $1"}
+	args := []interface{}{userID}
+	if market := strings.TrimSpace(c.Query("market")); market != "" {
+		args = append(args, strings.ToUpper(market))
+		conditions = append(conditions, fmt.Sprintf("UPPER(m.symbol) = $%d", len(args)))
+	}
+	switch c.Query("status") {
+	case "open":
+		conditions = append(conditions, "o.status IN ('untriggered', 'new', 'partially_filled')")
+	case "closed":
+		conditions = append(conditions, "o.status NOT IN ('untriggered', 'new', 'partially_filled')")
+	}
+
+	where := strings.Join(conditions, " AND ")
+	var total int
+	err := h.DB.QueryRow("SELECT COUNT(*) FROM orders o JOIN markets m ON m.id = o.market_id WHERE "+where, args...).Scan(&total)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch orders"})
+		return
+	}
+
+	args = append(args, limit, (page-1)*limit)
+	rows, err := h.DB.Query(fmt.Sprintf(`
+		SELECT %s
+		FROM orders o JOIN markets m ON m.id = o.market_id
+		WHERE %s
+		ORDER BY o.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, orderColumns, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch orders"})
+		return
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to read orders"})
+			return
+		}
+		orders = append(orders, *order)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  orders,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetOrder godoc
+// @Summary Get an order
+// @Description Get one of the authenticated user's orders
+// @Tags Orders
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Success 200 {object} models.Order "Order"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/orders/{id} [get]
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order_not_found", "message": "Order not found"})
+		return
+	}
+
+	order, err := getOrder(h.DB, orderID)
+	if err == sql.ErrNoRows || (err == nil && order.UserID != userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order_not_found", "message": "Order not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// respondOrderError writes the response for an error returned by the trading engine
+func respondOrderError(c *gin.Context, err error, message string) {
+	var rejected *orderRejectedError
+	switch {
+	case errors.As(err, &rejected):
+		c.JSON(http.StatusBadRequest, gin.H{"error": rejected.Code, "message": rejected.Message})
+	case err == errInsufficientBalance:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient_balance", "message": "Insufficient balance to place this order"})
+	case err == errMarketNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "market_not_found", "message": "Market not found"})
+	case err == errOrderNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "order_not_found", "message": "Order not found"})
+	case err == errOrderNotOpen:
+		c.JSON(http.StatusConflict, gin.H{"error": "order_not_open", "message": "Order is already filled or cancelled"})
+	default:
+		fmt.Printf("%s: %v\n", message, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": message})
+	}
+}
+
+// GetTradingSettings godoc
+// @Summary Get trading settings
+// @Description Get the account defaults for new orders. self_trade_prevention applies to orders that do not set their own: none (allow), cancel_newest, cancel_oldest, cancel_both or decrement_and_cancel.
+// @Tags Orders
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} models.TradingSettings "Trading settings"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/trading/settings [get]
+func (h *OrderHandler) GetTradingSettings(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	settings := models.TradingSettings{SelfTrade: "none"}
+	err := h.DB.QueryRow(`
+		SELECT self_trade_prevention, updated_at FROM user_trading_settings WHERE user_id = $1
+	`, userID).Scan(&settings.SelfTrade, &settings.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve trading settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateTradingSettings godoc
+// @Summary Update trading settings
+// @Description Change the account defaults for new orders. Orders already placed keep their settings.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.UpdateTradingSettingsRequest true "Trading settings"
+// @Success 200 {object} models.TradingSettings "Trading settings updated"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/trading/settings [put]
+func (h *OrderHandler) UpdateTradingSettings(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateTradingSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback()
+
+	before, err := accountSelfTrade(tx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve trading settings"})
+		return
+	}
+
+	var settings models.TradingSettings
+	err = tx.QueryRow(`
+		INSERT INTO user_trading_settings (user_id, self_trade_prevention)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET self_trade_prevention = EXCLUDED.self_trade_prevention
+		RETURNING self_trade_prevention, updated_at
+	`, userID, req.SelfTrade).Scan(&settings.SelfTrade, &settings.UpdatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update trading settings"})
+		return
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &userID,
+		Action:     "user.trading_settings_update",
+		TargetType: "user",
+		TargetID:   userID.String(),
+		Before:     map[string]interface{}{"self_trade_prevention": before},
+		After:      map[string]interface{}{"self_trade_prevention": settings.SelfTrade},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update trading settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// otpTTL is how long a generated OTP code stays valid
+const otpTTL = 10 * time.Minute
+
+var (
+	errOTPNotFound = errors.New("no valid otp found")
+	errOTPExpired  = errors.New("otp has expired")
+	errOTPInvalid  = errors.New("invalid otp code")
+)
+
+// issueOTP invalidates previous unused codes of the same type and stores a new one
+func issueOTP(db *sql.DB, userID uuid.UUID, otpType string) (string, error) {
+	code, err := newOTPCode()
+	if err != nil {
+		return "", err
+	}
+
+	// Mark all previous unused OTPs of the same type as used (only accept latest)
+	_, err = db.Exec(`
+		UPDATE otps
+		SET used = TRUE, updated_at = NOW()
+		WHERE user_id = $1 AND type = $2 AND used = FALSE
+	`, userID, otpType)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO otps (id, user_id, type, code, used, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, FALSE, $5, NOW(), NOW())
+	`, uuid.New(), userID, otpType, code, time.Now().Add(otpTTL))
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// consumeOTP checks the latest unused code of the given type and marks it as used
+func consumeOTP(db *sql.DB, userID uuid.UUID, otpType, code string) error {
+	var otpID uuid.UUID
+	var storedCode string
+	var expiresAt time.Time
+	err := db.QueryRow(`
+		SELECT id, code, expires_at
+		FROM otps
+		WHERE user_id = $1 AND type = $2 AND used = FALSE
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID, otpType).Scan(&otpID, &storedCode, &expiresAt)
+	if err == sql.ErrNoRows {
+		return errOTPNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if time.Now().After(expiresAt) {
+		return errOTPExpired
+	}
+
+	if storedCode != code {
+		return errOTPInvalid
+	}
+
+	_, err = db.Exec("UPDATE otps SET used = TRUE, updated_at = NOW() WHERE id = $1", otpID)
+	return err
+}
+
+// respondOTPError writes the API error matching a consumeOTP failure
+func respondOTPError(c *gin.Context, err error) {
+	switch err {
+	case errOTPNotFound:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "otp_required",
+			"message": "No valid OTP found. Please request a new code.",
+		})
+	case errOTPExpired:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "otp_expired",
+			"message": "OTP code has expired. Please request a new code.",
+		})
+	case errOTPInvalid:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_code",
+			"message": "Invalid OTP code",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "database_error",
+			"message": "Failed to verify OTP",
+		})
+	}
+}
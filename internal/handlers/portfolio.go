@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// portfolioRefreshOverlap is how far each refresh looks back before the previous one, so
+// balance changes of transactions that started before it are not missed
+const portfolioRefreshOverlap = time.Minute
+
+// getPortfolioQuoteCurrency returns the currency portfolios and users.global_balance are valued
+// in: USD (coin prices as they are) or the ticker of a coin
+func getPortfolioQuoteCurrency() string {
+	currency := strings.ToUpper(strings.TrimSpace(os.Getenv("PORTFOLIO_QUOTE_CURRENCY")))
+	if currency == "" {
+		return "USD"
+	}
+	return currency
+}
+
+// portfolioQuotePrice returns the USD price of the quote currency, which coin prices are divided by
+func portfolioQuotePrice(q limitQueryer, currency string) (*big.Rat, error) {
+	if currency == "USD" {
+		return big.NewRat(1, 1), nil
+	}
+	var raw string
+	err := q.QueryRow("SELECT price FROM coins WHERE UPPER(ticker) = $1", currency).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("quote currency %s is not a coin", currency)
+	}
+	if err != nil {
+		return nil, err
+	}
+	price := ratOrZero(raw)
+	if price.Sign() <= 0 {
+		return nil, fmt.Errorf("quote currency %s has no price", currency)
+	}
+	return price, nil
+}
+
+type PortfolioHandler struct {
+	DB *sql.DB
+}
+
+func NewPortfolioHandler(db *sql.DB) *PortfolioHandler {
+	return &PortfolioHandler{DB: db}
+}
+
+// GetPortfolio godoc
+// @Summary Get portfolio
+// @Description Get the value of all wallets (balance plus frozen balance) in the portfolio quote currency with a breakdown per coin, the allocation of each coin and the value at the start of each day for performance charts
+// @Tags Wallets
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param days query int false "Days of daily snapshots (default 30, max 365)"
+// @Success 200 {object} models.Portfolio "Portfolio"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/portfolio [get]
+func (h *PortfolioHandler) GetPortfolio(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 {
+		days = 30
+	}
+	if days > 365 {
+		days = 365
+	}
+
+	currency := getPortfolioQuoteCurrency()
+	quotePrice, err := portfolioQuotePrice(h.DB, currency)
+	if err != nil {
+		fmt.Printf("Failed to value portfolio: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to value portfolio"})
+		return
+	}
+
+	rows, err := h.DB.Query(`
+		SELECT c.id, c.ticker, c.name, c.price_decimal, w.balance, w.frozen_balance, c.price
+		FROM wallets w
+		JOIN coins c ON c.id = w.coin_id
+		WHERE w.user_id = $1 AND w.balance + w.frozen_balance > 0
+	`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch wallets"})
+		return
+	}
+	defer rows.Close()
+
+	type holding struct {
+		asset models.PortfolioAsset
+		value *big.Rat
+	}
+	holdings := []holding{}
+	total := new(big.Rat)
+	for rows.Next() {
+		var asset models.PortfolioAsset
+		var priceDecimal int
+		var rawPrice string
+		err := rows.Scan(&asset.CoinID, &asset.Ticker, &asset.Name, &priceDecimal, &asset.Balance, &asset.FrozenBalance, &rawPrice)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to read wallets"})
+			return
+		}
+
+		quantity := new(big.Rat).Add(ratOrZero(asset.Balance), ratOrZero(asset.FrozenBalance))
+		price := new(big.Rat).Quo(ratOrZero(rawPrice), quotePrice)
+		value := new(big.Rat).Mul(quantity, price)
+		asset.Quantity = quantity.FloatString(ledgerDecimals)
+		asset.Price = price.FloatString(ledgerDecimals)
+		asset.Value = value.FloatString(usdDecimals)
+		total.Add(total, value)
+		holdings = append(holdings, holding{asset: asset, value: value})
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to read wallets"})
+		return
+	}
+
+	sort.Slice(holdings, func(i, j int) bool {
+		return holdings[i].value.Cmp(holdings[j].value) > 0
+	})
+	assets := make([]models.PortfolioAsset, 0, len(holdings))
+	for _, h := range holdings {
+		allocation := new(big.Rat)
+		if total.Sign() > 0 {
+			allocation.Quo(new(big.Rat).Mul(h.value, big.NewRat(100, 1)), total)
+		}
+		h.asset.AllocationPercent = allocation.FloatString(2)
+		assets = append(assets, h.asset)
+	}
+
+	snapshotRows, err := h.DB.Query(`
+		SELECT to_char(snapshot_date, 'YYYY-MM-DD'), total_value
+		FROM portfolio_snapshots
+		WHERE user_id = $1 AND quote_currency = $2 AND snapshot_date > (NOW() AT TIME ZONE 'UTC')::date - $3::int
+		ORDER BY snapshot_date
+	`, userID, currency, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch portfolio history"})
+		return
+	}
+	defer snapshotRows.Close()
+
+	snapshots := []models.PortfolioSnapshot{}
+	for snapshotRows.Next() {
+		var snapshot models.PortfolioSnapshot
+		if err := snapshotRows.Scan(&snapshot.Date, &snapshot.TotalValue); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to read portfolio history"})
+			return
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	c.JSON(http.StatusOK, models.Portfolio{
+		QuoteCurrency: currency,
+		TotalValue:    total.FloatString(usdDecimals),
+		Assets:        assets,
+		Snapshots:     snapshots,
+		Time:          time.Now().UTC(),
+	})
+}
+
+// PortfolioJobs keeps users.global_balance equal to the value of the user's wallets and takes
+// the daily portfolio snapshots. Each run revalues the users whose wallets changed since the
+// previous run, or everyone when a coin price changed. Both jobs are idempotent, so any
+// number of API instances can run them.
+type PortfolioJobs struct {
+	DB           *sql.DB
+	since        time.Time
+	snapshotDate string
+}
+
+func NewPortfolioJobs(db *sql.DB) *PortfolioJobs {
+	return &PortfolioJobs{DB: db}
+}
+
+// getPortfolioRefreshInterval returns the pause between two runs, which is how long
+// users.global_balance can lag behind balance and price changes
+func getPortfolioRefreshInterval() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("PORTFOLIO_REFRESH_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return 10 * time.Second // Default 10 seconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Start runs the jobs in the background until the process exits. Set
+// PORTFOLIO_JOBS_ENABLED=false to leave them to other instances.
+func (j *PortfolioJobs) Start() {
+	if os.Getenv("PORTFOLIO_JOBS_ENABLED") == "false" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(getPortfolioRefreshInterval())
+		defer ticker.Stop()
+		for {
+			j.RunOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+// RunOnce refreshes global balances and takes the day's snapshots if they are missing. The
+// first run revalues every user.
+func (j *PortfolioJobs) RunOnce() {
+	currency := getPortfolioQuoteCurrency()
+	quotePrice, err := portfolioQuotePrice(j.DB, currency)
+	if err != nil {
+		fmt.Printf("Failed to value portfolios: %v\n", err)
+		return
+	}
+
+	started := time.Now()
+	all := j.since.IsZero()
+	if !all {
+		if err := j.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM coins WHERE updated_at >= $1)", j.since).Scan(&all); err != nil {
+			fmt.Printf("Failed to check coin prices: %v\n", err)
+			return
+		}
+	}
+	if err := j.refreshGlobalBalances(quotePrice, all); err != nil {
+		fmt.Printf("Failed to refresh global balances: %v\n", err)
+		return
+	}
+	j.since = started.Add(-portfolioRefreshOverlap)
+
+	today := time.Now().UTC().Format("2006-01-02")
+	if j.snapshotDate != today {
+		if err := j.takeSnapshots(today, currency, quotePrice); err != nil {
+			fmt.Printf("Failed to take portfolio snapshots: %v\n", err)
+			return
+		}
+		j.snapshotDate = today
+	}
+}
+
+// refreshGlobalBalances revalues all users, or those whose wallets changed since the last run
+func (j *PortfolioJobs) refreshGlobalBalances(quotePrice *big.Rat, all bool) error {
+	filter := "TRUE"
+	args := []interface{}{quotePrice.FloatString(ledgerDecimals)}
+	if !all {
+		filter = "u.id IN (SELECT user_id FROM wallets WHERE updated_at >= $2)"
+		args = append(args, j.since)
+	}
+
+	_, err := j.DB.Exec(`
+		UPDATE users SET global_balance = v.total
+		FROM (
+			SELECT u.id, ROUND(COALESCE(SUM((w.balance + w.frozen_balance) * c.price), 0) / $1::numeric, 2) AS total
+			FROM users u
+			LEFT JOIN wallets w ON w.user_id = u.id
+			LEFT JOIN coins c ON c.id = w.coin_id
+			WHERE `+filter+`
+			GROUP BY u.id
+		) v
+		WHERE users.id = v.id AND users.global_balance IS DISTINCT FROM v.total
+	`, args...)
+	return err
+}
+
+// takeSnapshots records the portfolio value of every user holding anything for the given day
+func (j *PortfolioJobs) takeSnapshots(date, currency string, quotePrice *big.Rat) error {
+	_, err := j.DB.Exec(`
+		INSERT INTO portfolio_snapshots (user_id, snapshot_date, quote_currency, total_value, assets)
+		SELECT w.user_id, $1::date, $2,
+			ROUND(SUM((w.balance + w.frozen_balance) * c.price) / $3::numeric, 2),
+			jsonb_agg(jsonb_build_object(
+				'ticker', c.ticker,
+				'quantity', w.balance + w.frozen_balance,
+				'value', ROUND((w.balance + w.frozen_balance) * c.price / $3::numeric, 2)
+			) ORDER BY c.ticker)
+		FROM wallets w
+		JOIN coins c ON c.id = w.coin_id
+		JOIN users u ON u.id = w.user_id
+		WHERE w.balance + w.frozen_balance > 0 AND u.deleted_at IS NULL
+		GROUP BY w.user_id
+		ON CONFLICT (user_id, snapshot_date) DO NOTHING
+	`, date, currency, quotePrice.FloatString(ledgerDecimals))
+	return err
+}
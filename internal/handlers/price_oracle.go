@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/services"
+)
+
+// priceOracleLockKey is the transaction-level advisory lock that keeps two instances from
+// writing prices at the same time
+const priceOracleLockKey = 4_217_306_156
+
+// tradePriceSource prices coins by the last trade of their markets, converted to USD with the
+// quote coin's price
+type tradePriceSource struct {
+	db     *sql.DB
+	maxAge time.Duration
+}
+
+func (s *tradePriceSource) Name() string {
+	return "trades"
+}
+
+func (s *tradePriceSource) Prices(ctx context.Context, tickers []string) (map[string]*big.Rat, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (m.base_coin_id) b.ticker, t.price * q.price
+		FROM trades t
+		JOIN markets m ON m.id = t.market_id
+		JOIN coins b ON b.id = m.base_coin_id
+		JOIN coins q ON q.id = m.quote_coin_id
+		WHERE t.created_at > NOW() - $1::interval AND q.price > 0
+		ORDER BY m.base_coin_id, t.created_at DESC
+	`, fmt.Sprintf("%d seconds", int64(s.maxAge/time.Second)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := make(map[string]*big.Rat)
+	for rows.Next() {
+		var ticker, price string
+		if err := rows.Scan(&ticker, &price); err != nil {
+			return nil, err
+		}
+		if value := ratOrZero(price); value.Sign() > 0 {
+			prices[strings.ToUpper(ticker)] = value
+		}
+	}
+	return prices, rows.Err()
+}
+
+// PriceOracle keeps coins.price current. Every run it asks all configured sources for the
+// prices of the active coins, combines them per coin (median with outlier rejection), writes
+// them to coins.price and records them in price_history. Coins without enough quotes keep
+// their price.
+type PriceOracle struct {
+	DB      *sql.DB
+	Coins   *CoinCache
+	config  *services.PriceOracleConfig
+	sources []services.PriceSource
+}
+
+func NewPriceOracle(db *sql.DB, coins *CoinCache) *PriceOracle {
+	config := services.GetPriceOracleConfig()
+	oracle := &PriceOracle{DB: db, Coins: coins, config: config}
+
+	for _, name := range config.Sources {
+		switch name {
+		case "trades":
+			oracle.sources = append(oracle.sources, &tradePriceSource{db: db, maxAge: config.TradeMaxAge})
+		case "http":
+			if len(config.HTTPURLs) == 0 {
+				fmt.Printf("Warning: price source http is enabled but PRICE_HTTP_URLS is empty\n")
+			}
+			for _, url := range config.HTTPURLs {
+				oracle.sources = append(oracle.sources, services.NewHTTPPriceSource(url, config.HTTPAPIKey))
+			}
+		case "file":
+			oracle.sources = append(oracle.sources, services.NewFilePriceSource(config.File))
+		default:
+			fmt.Printf("Warning: unknown price source %q\n", name)
+		}
+	}
+	return oracle
+}
+
+// Start runs the oracle in the background until the process exits. Set
+// PRICE_ORACLE_ENABLED=false to leave it to other instances.
+func (o *PriceOracle) Start() {
+	if os.Getenv("PRICE_ORACLE_ENABLED") == "false" || len(o.sources) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(o.config.Interval)
+		defer ticker.Stop()
+		for {
+			o.RunOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+// RunOnce updates the prices of all active coins. A run is skipped when another instance
+// updated prices less than half an interval ago.
+func (o *PriceOracle) RunOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	coins := map[string]int{}
+	rows, err := o.DB.QueryContext(ctx, "SELECT id, ticker FROM coins WHERE status = 1")
+	if err != nil {
+		fmt.Printf("Failed to load coins for price update: %v\n", err)
+		return
+	}
+	tickers := []string{}
+	for rows.Next() {
+		var id int
+		var ticker string
+		if err := rows.Scan(&id, &ticker); err != nil {
+			rows.Close()
+			fmt.Printf("Failed to load coins for price update: %v\n", err)
+			return
+		}
+		coins[strings.ToUpper(ticker)] = id
+		tickers = append(tickers, strings.ToUpper(ticker))
+	}
+	rows.Close()
+
+	// A failing source is left out of this run
+	quotes := map[string][]services.PriceQuote{}
+	for _, source := range o.sources {
+		prices, err := source.Prices(ctx, tickers)
+		if err != nil {
+			fmt.Printf("Failed to get prices from %s: %v\n", source.Name(), err)
+			continue
+		}
+		for ticker, price := range prices {
+			if _, ok := coins[ticker]; ok {
+				quotes[ticker] = append(quotes[ticker], services.NewPriceQuote(source.Name(), price))
+			}
+		}
+	}
+
+	updated, err := o.writePrices(ctx, coins, quotes)
+	if err != nil {
+		fmt.Printf("Failed to update coin prices: %v\n", err)
+		return
+	}
+	if updated > 0 {
+		o.Coins.Invalidate()
+	}
+}
+
+// writePrices aggregates the quotes and stores the prices; it returns the number of coins updated
+func (o *PriceOracle) writePrices(ctx context.Context, coins map[string]int, quotes map[string][]services.PriceQuote) (int, error) {
+	tx, err := o.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var locked, recent bool
+	if err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", priceOracleLockKey).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(created_at) > NOW() - $1::interval, false) FROM price_history
+	`, fmt.Sprintf("%d seconds", int64(o.config.Interval/2/time.Second))).Scan(&recent)
+	if err != nil {
+		return 0, err
+	}
+	if recent {
+		return 0, nil
+	}
+
+	updated := 0
+	for ticker, coinQuotes := range quotes {
+		price, coinQuotes, ok := services.AggregatePrice(coinQuotes, o.config.MaxDeviation, o.config.MinSources)
+		if !ok {
+			fmt.Printf("Price of %s not updated: not enough agreeing sources\n", ticker)
+			continue
+		}
+
+		encoded, err := json.Marshal(coinQuotes)
+		if err != nil {
+			return 0, err
+		}
+		value := price.FloatString(8)
+		if ratOrZero(value).Sign() == 0 {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE coins SET price = $1, updated_at = NOW() WHERE id = $2 AND price <> $1::numeric", value, coins[ticker]); err != nil {
+			return 0, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO price_history (coin_id, price, quotes) VALUES ($1, $2, $3)
+		`, coins[ticker], value, string(encoded))
+		if err != nil {
+			return 0, err
+		}
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
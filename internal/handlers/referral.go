@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ledgerDecimals is the scale of wallet balances and transaction amounts
+const ledgerDecimals = 8
+
+var errInvalidReferral = errors.New("invalid referral")
+
+type ReferralHandler struct {
+	DB *sql.DB
+}
+
+func NewReferralHandler(db *sql.DB) *ReferralHandler {
+	return &ReferralHandler{DB: db}
+}
+
+// GetReferrals godoc
+// @Summary Get referral program overview
+// @Description Get the user's referral code and link, the number of referred users, their traded volume and the commissions earned per coin. Commissions are a share of the referees' trading fees credited to the user's wallets.
+// @Tags Referrals
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Success 200 {object} models.ReferralSummaryResponse "Referral overview"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/referrals [get]
+func (h *ReferralHandler) GetReferrals(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	response := models.ReferralSummaryResponse{
+		CommissionRate: getReferralCommissionRate().FloatString(4),
+		Earnings:       []models.ReferralEarning{},
+	}
+
+	err := h.DB.QueryRow(`
+		SELECT u.referral_code,
+		       (SELECT COUNT(*) FROM users r WHERE r.referred_by = u.id AND r.deleted_at IS NULL)
+		FROM users u
+		WHERE u.id = $1
+	`, userID).Scan(&response.ReferralCode, &response.ReferralCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve referral code"})
+		return
+	}
+	response.ReferralLink = getFrontendURL() + "/register?ref=" + url.QueryEscape(response.ReferralCode)
+
+	var volume string
+	err = h.DB.QueryRow(`
+		SELECT COALESCE(SUM(volume_usd), 0) FROM referral_commissions WHERE referrer_id = $1
+	`, userID).Scan(&volume)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve referral volume"})
+		return
+	}
+	volumeUSD, ok := new(big.Rat).SetString(volume)
+	if !ok {
+		volumeUSD = new(big.Rat)
+	}
+	response.RefereesVolumeUSD = volumeUSD.FloatString(usdDecimals)
+
+	rows, err := h.DB.Query(`
+		SELECT c.id, c.ticker, c.price, SUM(rc.commission_amount)
+		FROM referral_commissions rc
+		JOIN coins c ON c.id = rc.coin_id
+		WHERE rc.referrer_id = $1
+		GROUP BY c.id, c.ticker, c.price
+		ORDER BY c.ticker ASC
+	`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve commissions"})
+		return
+	}
+	defer rows.Close()
+
+	earnedUSD := new(big.Rat)
+	for rows.Next() {
+		var earning models.ReferralEarning
+		var rawPrice, rawAmount string
+		if err := rows.Scan(&earning.CoinID, &earning.Ticker, &rawPrice, &rawAmount); err != nil {
+			continue
+		}
+		amount, ok := new(big.Rat).SetString(rawAmount)
+		if !ok {
+			continue
+		}
+		price, ok := new(big.Rat).SetString(rawPrice)
+		if !ok {
+			price = new(big.Rat)
+		}
+		value := new(big.Rat).Mul(amount, price)
+		earnedUSD.Add(earnedUSD, value)
+
+		earning.Amount = amount.FloatString(ledgerDecimals)
+		earning.AmountUSD = value.FloatString(usdDecimals)
+		response.Earnings = append(response.Earnings, earning)
+	}
+	response.EarnedUSD = earnedUSD.FloatString(usdDecimals)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// resolveReferrer returns the user a registration was referred by. The referral code is matched
+// case-insensitively; the UUID form is still accepted for older clients. Unknown, deleted or blocked
+// referrers return errInvalidReferral.
+func resolveReferrer(db *sql.DB, code, legacyID *string) (*uuid.UUID, error) {
+	var query string
+	var arg interface{}
+	switch {
+	case code != nil && strings.TrimSpace(*code) != "":
+		query = "SELECT id FROM users WHERE referral_code = $1 AND deleted_at IS NULL AND status IN ('active', 'pending')"
+		arg = strings.ToUpper(strings.TrimSpace(*code))
+	case legacyID != nil && *legacyID != "":
+		parsed, err := uuid.Parse(*legacyID)
+		if err != nil {
+			return nil, errInvalidReferral
+		}
+		query = "SELECT id FROM users WHERE id = $1 AND deleted_at IS NULL AND status IN ('active', 'pending')"
+		arg = parsed
+	default:
+		return nil, nil
+	}
+
+	var referrerID uuid.UUID
+	err := db.QueryRow(query, arg).Scan(&referrerID)
+	if err == sql.ErrNoRows {
+		return nil, errInvalidReferral
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &referrerID, nil
+}
+
+// accrueReferralCommission credits the referrer of a user with a share of a fee the user paid on a
+// trade. It runs in the caller's transaction and does nothing for users without a referrer. The fee is
+// in units of coinID; volumeUSD is the traded value counted towards the referrer's statistics. A source
+// is paid out at most once, and the commission is debited from the fee revenue account.
+func accrueReferralCommission(tx *sql.Tx, refereeID uuid.UUID, coinID int, sourceType string, sourceID uuid.UUID, fee, volumeUSD *big.Rat) error {
+	var referrerID uuid.UUID
+	err := tx.QueryRow(`
+		SELECT r.id FROM users u
+		JOIN users r ON r.id = u.referred_by AND r.deleted_at IS NULL
+		WHERE u.id = $1
+	`, refereeID).Scan(&referrerID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rate := getReferralCommissionRate()
+	commission := truncateRat(new(big.Rat).Mul(fee, rate), ledgerDecimals)
+
+	var commissionID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO referral_commissions (referrer_id, referee_id, coin_id, source_type, source_id, volume_usd, fee_amount, rate, commission_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (source_type, source_id, referee_id) DO NOTHING
+		RETURNING id
+	`, referrerID, refereeID, coinID, sourceType, sourceID, volumeUSD.FloatString(usdDecimals),
+		fee.FloatString(ledgerDecimals), rate.FloatString(4), commission).Scan(&commissionID)
+	if err == sql.ErrNoRows {
+		// Already paid out
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	amount, _ := new(big.Rat).SetString(commission)
+	if amount == nil || amount.Sign() == 0 {
+		return nil
+	}
+
+	// Credit the referrer's wallet and record the ledger entry
+	var walletID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO wallets (user_id, coin_id, balance)
+		VALUES ($1, $2, $3::numeric)
+		ON CONFLICT (user_id, coin_id) DO UPDATE SET balance = wallets.balance + EXCLUDED.balance, updated_at = NOW()
+		RETURNING id
+	`, referrerID, coinID, commission).Scan(&walletID)
+	if err != nil {
+		return err
+	}
+
+	var transactionID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO transactions (user_id, wallet_id, type, amount, fee, description, reference_id, status)
+		VALUES ($1, $2, 'referral', $3, 0, 'Referral commission', $4, 'completed')
+		RETURNING id
+	`, referrerID, walletID, commission, sourceID.String()).Scan(&transactionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE referral_commissions SET transaction_id = $1 WHERE id = $2", transactionID, commissionID)
+	if err != nil {
+		return err
+	}
+
+	// The commission is paid out of the fee it is a share of
+	return postFeeRevenueEntry(tx, coinID, sourceID, referrerID, "referral_commission", new(big.Rat).Neg(amount))
+}
+
+// getReferralCommissionRate returns the share (0-1) of a referee's trading fee paid to the referrer
+func getReferralCommissionRate() *big.Rat {
+	rate, ok := new(big.Rat).SetString(os.Getenv("REFERRAL_COMMISSION_RATE"))
+	if !ok || rate.Sign() < 0 || rate.Cmp(big.NewRat(1, 1)) > 0 {
+		return big.NewRat(1, 5) // Default 20%
+	}
+	return rate
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const screeningCaseColumns = `
+	id, user_id, subject_type, subject_id, screened_value, matches, top_score, status,
+	reviewed_by, reviewed_at, review_note, created_at, updated_at
+`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// screenSubject screens a name (registration, kyc_submission) or crypto address (withdrawal_address)
+// for a user. It returns the open or confirmed case that blocks the action, or nil when the action
+// may proceed. List entries compliance already cleared for the same user and value are not raised again.
+func screenSubject(db *sql.DB, screening *services.ScreeningService, userID uuid.UUID, subjectType string, subjectID *uuid.UUID, value string) (*models.ScreeningCase, error) {
+	value = strings.TrimSpace(value)
+
+	// An unresolved case keeps blocking until it is reviewed
+	existing, err := getBlockingScreeningCase(db, userID, subjectType, value)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	var matches []services.ScreeningMatch
+	if subjectType == "withdrawal_address" {
+		matches = screening.ScreenAddress(value)
+	} else {
+		matches = screening.ScreenName(value)
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	cleared, err := clearedScreeningEntries(db, userID, subjectType, value)
+	if err != nil {
+		return nil, err
+	}
+	open := []services.ScreeningMatch{}
+	for _, match := range matches {
+		if !cleared[match.List+"/"+match.EntryUID] {
+			open = append(open, match)
+		}
+	}
+	if len(open) == 0 {
+		return nil, nil
+	}
+
+	rawMatches, err := json.Marshal(open)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &models.ScreeningCase{
+		ID:            uuid.New(),
+		UserID:        userID,
+		SubjectType:   subjectType,
+		SubjectID:     subjectID,
+		ScreenedValue: value,
+		Matches:       rawMatches,
+		TopScore:      open[0].Score,
+		Status:        "open",
+	}
+	err = db.QueryRow(`
+		INSERT INTO screening_cases (id, user_id, subject_type, subject_id, screened_value, matches, top_score, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'open')
+		ON CONFLICT (user_id, subject_type, screened_value) WHERE status = 'open' DO NOTHING
+		RETURNING created_at, updated_at
+	`, sc.ID, userID, subjectType, subjectID, value, rawMatches, sc.TopScore).Scan(&sc.CreatedAt, &sc.UpdatedAt)
+	if err == sql.ErrNoRows {
+		// A concurrent request opened the case first
+		return getBlockingScreeningCase(db, userID, subjectType, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sc, nil
+}
+
+// screenUserName screens the account holder's name as a registration subject and clears the
+// user's name_screening_pending flag once screening completed. While the flag is set login screens
+// the name again, so a failed screening blocks the account instead of letting it through.
+func screenUserName(db *sql.DB, screening *services.ScreeningService, userID uuid.UUID, firstName, lastName string) (*models.ScreeningCase, error) {
+	screeningCase, err := screenSubject(db, screening, userID, "registration", nil, firstName+" "+lastName)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec("UPDATE users SET name_screening_pending = FALSE WHERE id = $1", userID); err != nil {
+		return nil, err
+	}
+	return screeningCase, nil
+}
+
+// getBlockingScreeningCase returns the open or confirmed case of a user for a subject type and
+// screened value (any value when empty), or nil when there is none
+func getBlockingScreeningCase(db *sql.DB, userID uuid.UUID, subjectType, value string) (*models.ScreeningCase, error) {
+	sc, err := scanScreeningCase(db.QueryRow(`
+		SELECT `+screeningCaseColumns+`
+		FROM screening_cases
+		WHERE user_id = $1 AND subject_type = $2 AND ($3 = '' OR screened_value = $3)
+		  AND status IN ('open', 'confirmed')
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID, subjectType, value))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sc, err
+}
+
+// clearedScreeningEntries returns the list entries ("list/uid") compliance cleared for a user and value
+func clearedScreeningEntries(db *sql.DB, userID uuid.UUID, subjectType, value string) (map[string]bool, error) {
+	rows, err := db.Query(`
+		SELECT matches FROM screening_cases
+		WHERE user_id = $1 AND subject_type = $2 AND screened_value = $3 AND status = 'cleared'
+	`, userID, subjectType, value)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cleared := map[string]bool{}
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		var matches []services.ScreeningMatch
+		if err := json.Unmarshal(raw, &matches); err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			cleared[match.List+"/"+match.EntryUID] = true
+		}
+	}
+
+	return cleared, rows.Err()
+}
+
+func scanScreeningCase(row rowScanner) (*models.ScreeningCase, error) {
+	var sc models.ScreeningCase
+	var matches []byte
+	err := row.Scan(&sc.ID, &sc.UserID, &sc.SubjectType, &sc.SubjectID, &sc.ScreenedValue, &matches, &sc.TopScore,
+		&sc.Status, &sc.ReviewedBy, &sc.ReviewedAt, &sc.ReviewNote, &sc.CreatedAt, &sc.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	sc.Matches = matches
+	return &sc, nil
+}
+
+// respondScreeningHold rejects an action blocked by a screening case. The response does not
+// reveal the case or the list entry, so the user is not tipped off.
+func respondScreeningHold(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":   "compliance_review",
+		"message": "This request is on hold pending a compliance review. Please contact support.",
+	})
+}
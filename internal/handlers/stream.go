@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	streamWriteWait      = 10 * time.Second
+	streamMaxMessageSize = 4096
+
+	// Close codes in the private range for the client to act on
+	streamCloseTokenExpired = 4001
+	streamCloseSlowConsumer = 4008
+)
+
+// privateStreamChannels need an authenticated connection
+var privateStreamChannels = map[string]bool{"orders": true, "balances": true, "transactions": true}
+
+// publicStreamChannels are per market, written as <channel>:<symbol>
+var publicStreamChannels = map[string]bool{"ticker": true, "trades": true, "depth": true}
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// Connections authenticate with a token message, not cookies, so any origin may connect
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// streamRequest is a message from the client
+type streamRequest struct {
+	ID       interface{} `json:"id,omitempty"` // Echoed in the response
+	Op       string      `json:"op"`           // subscribe, unsubscribe, auth, ping
+	Channels []string    `json:"channels,omitempty"`
+	Token    string      `json:"token,omitempty"`
+}
+
+// streamResponse answers a client request
+type streamResponse struct {
+	ID      interface{}       `json:"id,omitempty"`
+	Op      string            `json:"op"`
+	Channel string            `json:"channel,omitempty"`
+	Seq     *uint64           `json:"seq,omitempty"` // Seq of the channel at subscription; updates continue from it
+	Error   string            `json:"error,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Time    *time.Time        `json:"time,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+type StreamHandler struct {
+	DB      *sql.DB
+	Hub     *services.StreamHub
+	Trading *TradingEngine
+}
+
+func NewStreamHandler(db *sql.DB, hub *services.StreamHub, trading *TradingEngine) *StreamHandler {
+	return &StreamHandler{DB: db, Hub: hub, Trading: trading}
+}
+
+// streamSession is the state of one WebSocket connection
+type streamSession struct {
+	handler   *StreamHandler
+	conn      *websocket.Conn
+	client    *services.StreamClient
+	userID    uuid.UUID // Nil until authenticated
+	expiresAt time.Time
+	expiry    chan time.Time // New token expiry for the writer
+}
+
+// Stream godoc
+// @Summary WebSocket market data and account streams
+// @Description Upgrades to a WebSocket. Send {"op":"subscribe","channels":["trades:BTC-USDT"]} to subscribe and "unsubscribe" to stop. Public channels: ticker:<market>, trades:<market>, depth:<market> (depth starts with a full snapshot, then diffs with update_id). Private channels orders, balances and transactions need {"op":"auth","token":"<access token>"} first. Every message carries a per-channel seq that increases by one; a gap means updates were dropped because the connection fell behind, and the client should resubscribe or resync over REST. The server sends a heartbeat message and a ping frame every STREAM_HEARTBEAT_SECONDS and closes connections that stop answering or keep falling behind.
+// @Tags Streams
+// @Success 101 "Switching protocols"
+// @Router /ws [get]
+func (h *StreamHandler) Stream(c *gin.Context) {
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader already wrote the error response
+		return
+	}
+
+	session := &streamSession{
+		handler: h,
+		conn:    conn,
+		client:  h.Hub.NewClient(),
+		expiry:  make(chan time.Time, 1),
+	}
+	defer h.Hub.Remove(session.client)
+
+	go session.writeLoop()
+	session.readLoop()
+}
+
+// readLoop handles client requests until the connection fails or is closed
+func (s *streamSession) readLoop() {
+	heartbeat := s.handler.Hub.Config().Heartbeat
+	s.conn.SetReadLimit(streamMaxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+	})
+
+	for {
+		_, raw, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+
+		var req streamRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			s.reply(streamResponse{Op: "error", Error: "invalid_message", Message: "Messages must be JSON objects"})
+			continue
+		}
+
+		switch req.Op {
+		case "subscribe":
+			for _, channel := range req.Channels {
+				s.subscribe(req.ID, strings.TrimSpace(channel))
+			}
+		case "unsubscribe":
+			for _, channel := range req.Channels {
+				s.unsubscribe(req.ID, strings.TrimSpace(channel))
+			}
+		case "auth":
+			s.authenticate(req.ID, req.Token)
+		case "ping":
+			s.reply(streamResponse{ID: req.ID, Op: "pong", Time: timePtr(time.Now().UTC())})
+		default:
+			s.reply(streamResponse{ID: req.ID, Op: "error", Error: "unknown_op", Message: "op must be subscribe, unsubscribe, auth or ping"})
+		}
+	}
+}
+
+// writeLoop writes queued messages, heartbeats and pings, and closes the connection when the hub
+// gives up on it or the token expires
+func (s *streamSession) writeLoop() {
+	heartbeat := time.NewTicker(s.handler.Hub.Config().Heartbeat)
+	defer heartbeat.Stop()
+	defer s.conn.Close()
+
+	var expired <-chan time.Time
+	var expiryTimer *time.Timer
+	defer func() {
+		if expiryTimer != nil {
+			expiryTimer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case message := <-s.client.Send():
+			if err := s.write(websocket.TextMessage, message); err != nil {
+				s.client.Close("write failed")
+				return
+			}
+		case <-heartbeat.C:
+			beat, _ := json.Marshal(streamResponse{Op: "heartbeat", Time: timePtr(time.Now().UTC())})
+			if err := s.write(websocket.TextMessage, beat); err != nil {
+				s.client.Close("write failed")
+				return
+			}
+			if err := s.write(websocket.PingMessage, nil); err != nil {
+				s.client.Close("write failed")
+				return
+			}
+		case expiresAt := <-s.expiry:
+			if expiryTimer != nil {
+				expiryTimer.Stop()
+			}
+			expiryTimer = time.NewTimer(time.Until(expiresAt))
+			expired = expiryTimer.C
+		case <-expired:
+			s.closeWith(streamCloseTokenExpired, "token expired")
+			return
+		case <-s.client.Done():
+			code := websocket.CloseNormalClosure
+			reason := s.client.CloseReason()
+			if reason == services.CloseReasonSlowConsumer {
+				code = streamCloseSlowConsumer
+			}
+			s.closeWith(code, reason)
+			return
+		}
+	}
+}
+
+func (s *streamSession) write(messageType int, data []byte) error {
+	s.conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	return s.conn.WriteMessage(messageType, data)
+}
+
+func (s *streamSession) closeWith(code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+	s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(streamWriteWait))
+}
+
+// reply queues a response behind the updates already queued for the client
+func (s *streamSession) reply(response streamResponse) {
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	s.client.Enqueue(encoded)
+}
+
+func (s *streamSession) replyError(id interface{}, channel, code, message string) {
+	s.reply(streamResponse{ID: id, Op: "error", Channel: channel, Error: code, Message: message})
+}
+
+// subscribe adds the connection to a public market channel or one of the user's private channels
+func (s *streamSession) subscribe(id interface{}, channel string) {
+	var seq uint64
+	var err error
+
+	if privateStreamChannels[channel] {
+		if s.userID == uuid.Nil {
+			s.replyError(id, channel, "auth_required", "Authenticate before subscribing to private channels")
+			return
+		}
+		seq, err = s.handler.Hub.Subscribe(s.client, services.PrivateTopic(channel, s.userID), channel, nil)
+	} else {
+		kind, symbol, ok := strings.Cut(channel, ":")
+		if !ok || !publicStreamChannels[kind] || symbol == "" {
+			s.replyError(id, channel, "unknown_channel", "Channels are ticker:<market>, trades:<market>, depth:<market>, orders, balances and transactions")
+			return
+		}
+		channel, seq, err = s.handler.Trading.SubscribeMarket(s.client, kind, symbol)
+		if err == errMarketNotFound {
+			s.replyError(id, kind+":"+symbol, "market_not_found", "Market not found")
+			return
+		}
+	}
+
+	if err == services.ErrTooManySubscriptions {
+		s.replyError(id, channel, "too_many_subscriptions", fmt.Sprintf("At most %d channels per connection", s.handler.Hub.Config().MaxSubscriptions))
+		return
+	}
+	if err != nil {
+		fmt.Printf("Failed to subscribe to %s: %v\n", channel, err)
+		s.replyError(id, channel, "subscribe_failed", "Failed to subscribe")
+		return
+	}
+
+	s.reply(streamResponse{ID: id, Op: "subscribed", Channel: channel, Seq: &seq})
+}
+
+// unsubscribe removes the connection from a channel
+func (s *streamSession) unsubscribe(id interface{}, channel string) {
+	topic := channel
+	if privateStreamChannels[channel] {
+		topic = services.PrivateTopic(channel, s.userID)
+	} else if kind, symbol, ok := strings.Cut(channel, ":"); ok {
+		topic = kind + ":" + strings.ToUpper(symbol)
+	}
+
+	if !s.handler.Hub.Subscribed(s.client, topic) {
+		s.replyError(id, channel, "not_subscribed", "Not subscribed to this channel")
+		return
+	}
+	s.handler.Hub.Unsubscribe(s.client, topic)
+	s.reply(streamResponse{ID: id, Op: "unsubscribed", Channel: topicChannel(topic)})
+}
+
+// authenticate binds the connection to a user. The connection is closed when the token expires;
+// send a fresh token of the same user before that to keep it open.
+func (s *streamSession) authenticate(id interface{}, token string) {
+	claims, err := models.ValidateAccessToken(token)
+	if err != nil {
+		s.replyError(id, "", "invalid_token", "Invalid or expired token")
+		return
+	}
+	if s.userID != uuid.Nil && claims.UserID != s.userID {
+		s.replyError(id, "", "already_authenticated", "The connection is authenticated as another user")
+		return
+	}
+
+	// Tokens stay valid after an account is closed or locked; check the account itself
+	var status string
+	err = s.handler.DB.QueryRow(`
+		SELECT status FROM users WHERE id = $1 AND deleted_at IS NULL
+	`, claims.UserID).Scan(&status)
+	if err == sql.ErrNoRows || (err == nil && (status == "banned" || status == "inactive")) {
+		s.replyError(id, "", "account_inactive", "Account is not active")
+		return
+	}
+	if err != nil {
+		s.replyError(id, "", "database_error", "Failed to retrieve user")
+		return
+	}
+
+	s.userID = claims.UserID
+	if claims.ExpiresAt != nil {
+		s.expiresAt = claims.ExpiresAt.Time
+		// Replace a pending expiry the writer has not picked up yet
+		select {
+		case <-s.expiry:
+		default:
+		}
+		s.expiry <- s.expiresAt
+	}
+
+	response := streamResponse{ID: id, Op: "authenticated", Data: map[string]string{"user_id": s.userID.String()}}
+	if !s.expiresAt.IsZero() {
+		expiresAt := s.expiresAt.UTC()
+		response.Time = &expiresAt
+	}
+	s.reply(response)
+}
+
+// topicChannel returns the channel name of a hub topic
+func topicChannel(topic string) string {
+	channel, _, _ := strings.Cut(topic, "@")
+	return channel
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// publishOrder sends the current state of an order to its owner's orders channel. Failures are only logged.
+func publishOrder(db *sql.DB, stream *services.StreamHub, orderID uuid.UUID) {
+	order, err := getOrder(db, orderID)
+	if err != nil {
+		fmt.Printf("Failed to load order %s for stream: %v\n", orderID, err)
+		return
+	}
+	stream.PublishPrivate(order.UserID, "orders", order)
+}
+
+// publishBalance sends the current balance of a wallet to its owner's balances channel. Failures are only logged.
+func publishBalance(db *sql.DB, stream *services.StreamHub, userID uuid.UUID, coinID int) {
+	if !stream.HasSubscribers(services.PrivateTopic("balances", userID)) {
+		return
+	}
+
+	update := models.BalanceUpdate{CoinID: coinID}
+	err := db.QueryRow(`
+		SELECT c.ticker, COALESCE(w.balance, 0), COALESCE(w.frozen_balance, 0)
+		FROM coins c
+		LEFT JOIN wallets w ON w.coin_id = c.id AND w.user_id = $1
+		WHERE c.id = $2
+	`, userID, coinID).Scan(&update.Ticker, &update.Balance, &update.FrozenBalance)
+	if err != nil {
+		fmt.Printf("Failed to load balance for stream: %v\n", err)
+		return
+	}
+	stream.PublishPrivate(userID, "balances", update)
+}
@@ -0,0 +1,1801 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/engine"
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const marketColumns = `m.id, m.symbol, m.base_coin_id, m.quote_coin_id, b.ticker, q.ticker, m.is_active,
+	m.min_quantity, m.max_quantity, m.min_notional, m.price_precision, m.quantity_precision,
+	m.maker_fee_rate, m.taker_fee_rate, m.created_at, m.updated_at`
+
+const marketJoins = `FROM markets m
+	JOIN coins b ON b.id = m.base_coin_id
+	JOIN coins q ON q.id = m.quote_coin_id`
+
+const orderColumns = `o.id, o.user_id, o.market_id, m.symbol, o.side, o.type, o.price, o.quantity, o.quote_quantity,
+	o.filled_quantity, o.filled_quote, o.fee, o.reserved, o.status, o.reject_reason, o.time_in_force, o.expire_at, o.post_only, o.post_only_mode,
+	o.self_trade_prevention, o.client_order_id, o.stop_price, o.trigger_by, o.triggered_at, o.linked_order_id,
+	o.created_at, o.updated_at`
+
+var (
+	errInvalidTick    = errors.New("invalid tick")
+	errMarketNotFound = errors.New("market not found")
+	errOrderNotFound  = errors.New("order not found")
+	errOrderNotOpen   = errors.New("order is not open")
+)
+
+// orderRejectedError is returned when an order breaks a market rule. Code is the machine
+// readable reason sent to the client.
+type orderRejectedError struct {
+	Code    string
+	Message string
+}
+
+func (e *orderRejectedError) Error() string {
+	return e.Message
+}
+
+func rejectOrder(code, format string, args ...interface{}) error {
+	return &orderRejectedError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// marketBook is the in-memory order book of one market. mu serializes everything that changes
+// the market's orders, so the book, the database and the published updates stay in step.
+type marketBook struct {
+	mu        sync.Mutex
+	market    *models.Market
+	book      *engine.Book
+	lastPrice *big.Rat
+	stats     *engine.RollingStats           // Trades of the last 24 hours
+	triggers  map[string]*engine.TriggerBook // Untriggered orders by trigger_by (last_price, mark_price)
+	linked    map[uuid.UUID]uuid.UUID        // Open OCO legs to the other leg, both ways
+}
+
+// TradingEngine matches orders in memory and settles trades in PostgreSQL. Books are loaded from
+// the open orders on first use. Matching state lives in this process, so only one API instance
+// may accept orders.
+type TradingEngine struct {
+	DB      *sql.DB
+	Stream  *services.StreamHub
+	mu      sync.Mutex
+	markets map[string]*marketBook
+}
+
+func NewTradingEngine(db *sql.DB, stream *services.StreamHub) *TradingEngine {
+	return &TradingEngine{DB: db, Stream: stream, markets: make(map[string]*marketBook)}
+}
+
+// getTriggerCheckInterval returns how often mark price triggers are checked
+func getTriggerCheckInterval() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("TRADING_TRIGGER_CHECK_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return 2 * time.Second // Default 2 seconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Start loads the markets with untriggered orders and then, in the background until the
+// process exits, checks their mark price triggers and expires GTD orders. Last price triggers
+// are checked after every trade. Set TRADING_JOBS_ENABLED=false to turn the jobs off.
+func (e *TradingEngine) Start() {
+	if os.Getenv("TRADING_JOBS_ENABLED") == "false" {
+		return
+	}
+
+	go func() {
+		rows, err := e.DB.Query(`
+			SELECT DISTINCT m.symbol FROM orders o JOIN markets m ON m.id = o.market_id WHERE o.status = 'untriggered'
+		`)
+		if err != nil {
+			fmt.Printf("Failed to load markets with untriggered orders: %v\n", err)
+		} else {
+			var symbols []string
+			for rows.Next() {
+				var symbol string
+				if err := rows.Scan(&symbol); err == nil {
+					symbols = append(symbols, symbol)
+				}
+			}
+			rows.Close()
+			for _, symbol := range symbols {
+				if mb, err := e.lockMarket(symbol); err == nil {
+					mb.mu.Unlock()
+				} else {
+					fmt.Printf("Failed to load order book %s: %v\n", symbol, err)
+				}
+			}
+		}
+
+		triggers := time.NewTicker(getTriggerCheckInterval())
+		defer triggers.Stop()
+		expiry := time.NewTicker(getOrderExpiryInterval())
+		defer expiry.Stop()
+		for {
+			select {
+			case <-triggers.C:
+				e.checkMarkTriggers()
+			case <-expiry.C:
+				e.expireOrders()
+			}
+		}
+	}()
+}
+
+// getOrderExpiryInterval returns how often GTD orders past their expire_at are swept
+func getOrderExpiryInterval() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("TRADING_EXPIRY_CHECK_SECONDS"))
+	if err != nil || seconds <= 0 {
+		return time.Second // Default 1 second
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// expireOrders closes the GTD orders whose expire_at has passed with status expired and
+// releases their frozen funds. Expiring either leg of an OCO pair cancels the other.
+func (e *TradingEngine) expireOrders() {
+	rows, err := e.DB.Query(`
+		SELECT o.id, m.symbol
+		FROM orders o JOIN markets m ON m.id = o.market_id
+		WHERE o.expire_at <= NOW() AND o.status IN ('untriggered', 'new', 'partially_filled')
+		ORDER BY o.expire_at
+		LIMIT 1000
+	`)
+	if err != nil {
+		fmt.Printf("Failed to load expired orders: %v\n", err)
+		return
+	}
+	type expired struct {
+		orderID uuid.UUID
+		symbol  string
+	}
+	var orders []expired
+	for rows.Next() {
+		var order expired
+		if err := rows.Scan(&order.orderID, &order.symbol); err != nil {
+			rows.Close()
+			fmt.Printf("Failed to load expired orders: %v\n", err)
+			return
+		}
+		orders = append(orders, order)
+	}
+	rows.Close()
+
+	for _, order := range orders {
+		if err := e.expireOrder(order.symbol, order.orderID); err != nil {
+			fmt.Printf("Failed to expire order %s: %v\n", order.orderID, err)
+		}
+	}
+}
+
+func (e *TradingEngine) expireOrder(symbol string, orderID uuid.UUID) error {
+	mb, err := e.lockMarket(symbol)
+	if err != nil {
+		return err
+	}
+	defer mb.mu.Unlock()
+
+	tx, err := e.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ex := newExecution()
+	err = e.cancelInTx(tx, mb, ex, orderID, "expired")
+	if err == errOrderNotOpen {
+		// Filled or cancelled since it was loaded
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			e.reloadBook(mb)
+		}
+	}()
+	if err := e.cancelLinked(tx, mb, ex, orderID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+	e.finish(mb, ex)
+	return nil
+}
+
+// checkMarkTriggers runs the mark price triggers of every loaded market
+func (e *TradingEngine) checkMarkTriggers() {
+	e.mu.Lock()
+	books := make([]*marketBook, 0, len(e.markets))
+	for _, mb := range e.markets {
+		books = append(books, mb)
+	}
+	e.mu.Unlock()
+
+	for _, mb := range books {
+		mb.mu.Lock()
+		if mb.triggers["mark_price"].Len() > 0 {
+			mark, err := markPrice(e.DB, mb.market)
+			if err != nil {
+				fmt.Printf("Failed to get mark price of %s: %v\n", mb.market.Symbol, err)
+			} else if mark != nil {
+				e.runTriggers(mb, mark)
+			}
+		}
+		mb.mu.Unlock()
+	}
+}
+
+// parsedOrder is a validated order request
+type parsedOrder struct {
+	Side        engine.Side
+	Type        string
+	ExecType    string   // limit or market: how the order runs once active
+	Price       *big.Rat // Limit orders
+	Quantity    *big.Rat // Nil for market buys sized by Funds
+	Funds       *big.Rat
+	ReserveCoin int
+	Reserve     *big.Rat // Moved to the frozen balance when the order is accepted
+	StopPrice   *big.Rat // Stop and take-profit orders
+	Direction   engine.Direction
+	TriggerBy   string
+	TimeInForce string     // GTC, IOC, FOK, GTD
+	ExpireAt    *time.Time // GTD orders
+	PostOnly    string     // reject or reprice; empty if the order may take liquidity
+	SelfTrade   engine.SelfTradeMode
+}
+
+// takerState accumulates the executions of an incoming order
+type takerState struct {
+	FilledQuantity *big.Rat
+	FilledQuote    *big.Rat
+	Fee            *big.Rat
+	Unfrozen       *big.Rat // Part of the reserve released by fills
+}
+
+// walletKey identifies a wallet whose balance changed
+type walletKey struct {
+	UserID uuid.UUID
+	CoinID int
+}
+
+// lockMarket returns the book of a market with its lock held, loading it on first use
+func (e *TradingEngine) lockMarket(symbol string) (*marketBook, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	e.mu.Lock()
+	mb, ok := e.markets[symbol]
+	if !ok {
+		market, err := getMarket(e.DB, "UPPER(m.symbol) = $1", symbol)
+		if err == sql.ErrNoRows {
+			e.mu.Unlock()
+			return nil, errMarketNotFound
+		}
+		if err != nil {
+			e.mu.Unlock()
+			return nil, err
+		}
+
+		mb = &marketBook{market: market}
+		if err := e.loadBook(mb); err != nil {
+			e.mu.Unlock()
+			return nil, err
+		}
+		e.markets[symbol] = mb
+	}
+	e.mu.Unlock()
+
+	mb.mu.Lock()
+	return mb, nil
+}
+
+// loadBook rebuilds a book from the open orders in the database. Update IDs continue from the
+// previous book.
+func (e *TradingEngine) loadBook(mb *marketBook) error {
+	rows, err := e.DB.Query(`
+		SELECT id, user_id, side, price, quantity - filled_quantity
+		FROM orders
+		WHERE market_id = $1 AND status IN ('new', 'partially_filled') AND type = 'limit'
+		ORDER BY created_at, id
+	`, mb.market.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	book := engine.NewBook(mb.market.QuantityPrecision)
+	for rows.Next() {
+		var order engine.Order
+		var side, price, remaining string
+		if err := rows.Scan(&order.ID, &order.UserID, &side, &price, &remaining); err != nil {
+			return err
+		}
+		order.Side = engine.Side(side)
+		order.Price, _ = new(big.Rat).SetString(price)
+		order.Remaining, _ = new(big.Rat).SetString(remaining)
+		book.Add(&order)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Loading is not a change clients need to see
+	book.TakeChanges()
+	if mb.book != nil {
+		book.SetUpdateID(mb.book.UpdateID() + 1)
+	}
+	mb.book = book
+
+	var lastPrice sql.NullString
+	err = e.DB.QueryRow(`
+		SELECT price FROM trades WHERE market_id = $1 ORDER BY created_at DESC LIMIT 1
+	`, mb.market.ID).Scan(&lastPrice)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	mb.lastPrice = nullRat(lastPrice)
+
+	// The 24h statistics start from the 1m candles and then follow the trades
+	rows, err = e.DB.Query(`
+		SELECT open_time, open, high, low, volume, quote_volume, trade_count
+		FROM candles
+		WHERE market_id = $1 AND timeframe = '1m' AND open_time > NOW() - INTERVAL '24 hours'
+		ORDER BY open_time
+	`, mb.market.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	stats := engine.NewRollingStats()
+	for rows.Next() {
+		var openTime time.Time
+		var open, high, low, volume, quoteVolume string
+		var count int
+		if err := rows.Scan(&openTime, &open, &high, &low, &volume, &quoteVolume, &count); err != nil {
+			return err
+		}
+		stats.AddBucket(openTime, ratOrZero(open), ratOrZero(high), ratOrZero(low), ratOrZero(volume), ratOrZero(quoteVolume), count)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	mb.stats = stats
+
+	// Conditional orders waiting for their trigger, oldest first within a price
+	rows, err = e.DB.Query(`
+		SELECT id, type, side, stop_price, COALESCE(trigger_by, 'last_price'), linked_order_id
+		FROM orders
+		WHERE market_id = $1 AND status = 'untriggered'
+		ORDER BY created_at, id
+	`, mb.market.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	triggers := map[string]*engine.TriggerBook{"last_price": engine.NewTriggerBook(), "mark_price": engine.NewTriggerBook()}
+	linked := make(map[uuid.UUID]uuid.UUID)
+	for rows.Next() {
+		var orderID uuid.UUID
+		var orderType, side, stopPrice, triggerBy string
+		var linkedID *uuid.UUID
+		if err := rows.Scan(&orderID, &orderType, &side, &stopPrice, &triggerBy, &linkedID); err != nil {
+			return err
+		}
+		book, ok := triggers[triggerBy]
+		if !ok {
+			book = triggers["last_price"]
+		}
+		book.Add(&engine.Trigger{OrderID: orderID, Price: ratOrZero(stopPrice), Direction: triggerDirection(orderType, engine.Side(side))})
+		if linkedID != nil {
+			linked[orderID], linked[*linkedID] = *linkedID, orderID
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	mb.triggers = triggers
+	mb.linked = linked
+	return nil
+}
+
+// reloadBook replaces a book that no longer matches the database (a settlement failed after the
+// in-memory match) and sends depth subscribers a fresh snapshot
+func (e *TradingEngine) reloadBook(mb *marketBook) {
+	if err := e.loadBook(mb); err != nil {
+		// Drop the market so the next request loads it from scratch
+		fmt.Printf("Failed to reload order book %s: %v\n", mb.market.Symbol, err)
+		e.mu.Lock()
+		delete(e.markets, strings.ToUpper(mb.market.Symbol))
+		e.mu.Unlock()
+		return
+	}
+	e.Stream.PublishSnapshot("depth:"+mb.market.Symbol, mb.depth(0))
+}
+
+// PlaceOrder validates an order, freezes the funds it needs, matches it against the book and
+// settles the resulting trades in one database transaction. A limit order's remainder rests in
+// the book; a market order's remainder is cancelled and its funds released. Stop and
+// take-profit orders freeze their funds and wait in a trigger book until their stop price is
+// reached. Trades of the order can reach the stop prices of others, which then run in turn.
+// Rejections are also sent to the user's orders channel with their reason code.
+func (e *TradingEngine) PlaceOrder(userID uuid.UUID, req *models.PlaceOrderRequest) (*models.Order, error) {
+	order, err := e.placeOrder(userID, req)
+
+	reason, message := "", ""
+	var rejected *orderRejectedError
+	switch {
+	case errors.As(err, &rejected):
+		reason, message = rejected.Code, rejected.Message
+	case err == errInsufficientBalance:
+		reason, message = "insufficient_balance", "Insufficient balance to place this order"
+	}
+	if reason != "" {
+		rejection := models.OrderRejection{
+			Status:       "rejected",
+			Market:       strings.ToUpper(strings.TrimSpace(req.Market)),
+			Side:         req.Side,
+			Type:         req.Type,
+			RejectReason: reason,
+			Message:      message,
+			CreatedAt:    time.Now().UTC(),
+		}
+		if id := strings.TrimSpace(req.ClientOrderID); id != "" {
+			rejection.ClientOrderID = &id
+		}
+		e.Stream.PublishPrivate(userID, "orders", rejection)
+	}
+	return order, err
+}
+
+func (e *TradingEngine) placeOrder(userID uuid.UUID, req *models.PlaceOrderRequest) (*models.Order, error) {
+	mb, err := e.lockMarket(req.Market)
+	if err != nil {
+		return nil, err
+	}
+	defer mb.mu.Unlock()
+
+	// Market settings may have changed since the book was loaded
+	market, err := getMarket(e.DB, "m.id = $1", mb.market.ID)
+	if err != nil {
+		return nil, err
+	}
+	mb.market = market
+	if !market.IsActive {
+		return nil, rejectOrder("market_inactive", "Trading is suspended for %s", market.Symbol)
+	}
+
+	// Orders without their own self-trade prevention take the account's
+	if req.SelfTrade == "" {
+		resolved := *req
+		if resolved.SelfTrade, err = accountSelfTrade(e.DB, userID); err != nil {
+			return nil, err
+		}
+		req = &resolved
+	}
+
+	if req.Type == "oco" {
+		return e.placeOCO(mb, userID, req)
+	}
+
+	parsed, err := parseOrder(market, mb.book, req)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.StopPrice != nil {
+		err = e.checkTrigger(mb, parsed)
+	} else {
+		err = checkExecution(market, mb.book, parsed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := e.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1. Freeze the funds the order can spend
+	if err := freezeFunds(tx, userID, parsed.ReserveCoin, parsed.Reserve); err != nil {
+		return nil, err
+	}
+
+	// 2. Record the order
+	orderID := uuid.New()
+	status := "new"
+	if parsed.StopPrice != nil {
+		status = "untriggered"
+	}
+	if err := insertOrder(tx, orderID, userID, market, parsed, status, req.ClientOrderID, nil); err != nil {
+		return nil, err
+	}
+
+	ex := newExecution()
+	ex.touch(orderID)
+	ex.changed[walletKey{userID, parsed.ReserveCoin}] = true
+
+	// A conditional order only waits for its trigger
+	if parsed.StopPrice != nil {
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		mb.triggers[parsed.TriggerBy].Add(&engine.Trigger{OrderID: orderID, Price: parsed.StopPrice, Direction: parsed.Direction})
+		e.finish(mb, ex)
+		return getOrder(e.DB, orderID)
+	}
+
+	// 3. Match and settle. From here on the book is ahead of the database until the commit.
+	committed := false
+	defer func() {
+		if !committed {
+			e.reloadBook(mb)
+		}
+	}()
+	if err := e.execute(tx, mb, ex, orderID, userID, parsed); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	// 4. Publish while the market is still locked, so updates go out in order
+	e.finish(mb, ex)
+	e.runTriggers(mb, nil)
+
+	return getOrder(e.DB, orderID)
+}
+
+// placeOCO places a one-cancels-other pair: a limit order at price and a stop order at
+// stop_price (stop_limit at stop_limit_price, or stop_market). Both are for the same quantity
+// and only the limit order holds funds; the stop order takes them over when it triggers, after
+// the limit order is cancelled. The limit order is returned.
+func (e *TradingEngine) placeOCO(mb *marketBook, userID uuid.UUID, req *models.PlaceOrderRequest) (*models.Order, error) {
+	if req.Price == "" || req.StopPrice == "" || req.Quantity == "" || req.QuoteQuantity != "" {
+		return nil, rejectOrder("invalid_order", "OCO orders need a price, a stop_price and a quantity")
+	}
+	if req.Side == "buy" && req.StopLimitPrice == "" {
+		return nil, rejectOrder("invalid_order", "OCO buy orders need a stop_limit_price")
+	}
+	if req.TimeInForce == "IOC" || req.TimeInForce == "FOK" {
+		return nil, rejectOrder("invalid_time_in_force", "OCO orders can only be GTC or GTD")
+	}
+
+	// A GTD stop_market leg goes when its limit leg expires
+	limitReq := *req
+	limitReq.Type, limitReq.StopPrice, limitReq.StopLimitPrice, limitReq.TriggerBy = "limit", "", "", ""
+	stopReq := *req
+	stopReq.Type, stopReq.Price, stopReq.StopLimitPrice, stopReq.ClientOrderID = "stop_market", "", "", ""
+	stopReq.PostOnly, stopReq.PostOnlyMode = false, ""
+	if req.StopLimitPrice != "" {
+		stopReq.Type, stopReq.Price = "stop_limit", req.StopLimitPrice
+	} else {
+		stopReq.TimeInForce, stopReq.ExpireAt = "", nil
+	}
+
+	limit, err := parseOrder(mb.market, mb.book, &limitReq)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := parseOrder(mb.market, mb.book, &stopReq)
+	if err != nil {
+		return nil, err
+	}
+	// The limit order takes profit, the stop order limits the loss
+	if (limit.Side == engine.Sell) != (stop.StopPrice.Cmp(limit.Price) < 0) {
+		return nil, rejectOrder("invalid_oco_prices", "The stop_price must be below the price for sells and above it for buys")
+	}
+	if err := e.checkTrigger(mb, stop); err != nil {
+		return nil, err
+	}
+	if err := checkExecution(mb.market, mb.book, limit); err != nil {
+		return nil, err
+	}
+
+	tx, err := e.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := freezeFunds(tx, userID, limit.ReserveCoin, limit.Reserve); err != nil {
+		return nil, err
+	}
+
+	limitID, stopID := uuid.New(), uuid.New()
+	stop.Reserve = new(big.Rat)
+	if err := insertOrder(tx, limitID, userID, mb.market, limit, "new", req.ClientOrderID, nil); err != nil {
+		return nil, err
+	}
+	if err := insertOrder(tx, stopID, userID, mb.market, stop, "untriggered", "", &limitID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE orders SET linked_order_id = $2 WHERE id = $1", limitID, stopID); err != nil {
+		return nil, err
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			e.reloadBook(mb)
+		}
+	}()
+	mb.triggers[stop.TriggerBy].Add(&engine.Trigger{OrderID: stopID, Price: stop.StopPrice, Direction: stop.Direction})
+	mb.linked[limitID], mb.linked[stopID] = stopID, limitID
+
+	ex := newExecution()
+	ex.touch(limitID)
+	ex.touch(stopID)
+	ex.changed[walletKey{userID, limit.ReserveCoin}] = true
+	if err := e.execute(tx, mb, ex, limitID, userID, limit); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	e.finish(mb, ex)
+	e.runTriggers(mb, nil)
+
+	return getOrder(e.DB, limitID)
+}
+
+// execution collects what a change to a market touched, for publishing after the commit
+type execution struct {
+	trades      []models.PublicTrade
+	preventions []preventedMatch
+	orders      []uuid.UUID // Orders whose state changed, in the order they changed
+	seen        map[uuid.UUID]bool
+	changed     map[walletKey]bool
+	rates       map[uuid.UUID]*feeRate // Fee rates of the users on the market
+}
+
+func newExecution() *execution {
+	return &execution{seen: make(map[uuid.UUID]bool), changed: make(map[walletKey]bool), rates: make(map[uuid.UUID]*feeRate)}
+}
+
+// preventedMatch is a self-trade prevention to report to the user
+type preventedMatch struct {
+	userID uuid.UUID
+	models.SelfTradePrevention
+}
+
+// touch records a changed order
+func (ex *execution) touch(orderID uuid.UUID) {
+	if !ex.seen[orderID] {
+		ex.seen[orderID] = true
+		ex.orders = append(ex.orders, orderID)
+	}
+}
+
+// execute matches an accepted order against the book, settles every fill and rests the
+// remainder of a limit order or releases what a market order did not use. Filling either
+// leg of an OCO pair cancels the other.
+func (e *TradingEngine) execute(tx *sql.Tx, mb *marketBook, ex *execution, orderID, userID uuid.UUID, parsed *parsedOrder) error {
+	market := mb.market
+	taker := newTaker(orderID, userID, parsed)
+	fills, preventions := mb.book.Match(taker)
+
+	// Settle every fill
+	state := &takerState{FilledQuantity: new(big.Rat), FilledQuote: new(big.Rat), Fee: new(big.Rat), Unfrozen: new(big.Rat)}
+	quotePrice, err := coinPrice(tx, market.QuoteCoinID)
+	if err != nil {
+		return err
+	}
+	for _, fill := range fills {
+		trade, err := e.settleFill(tx, ex, market, parsed, taker, state, fill, quotePrice)
+		if err != nil {
+			return err
+		}
+		ex.trades = append(ex.trades, *trade)
+		ex.touch(fill.MakerID)
+		if err := e.cancelLinked(tx, mb, ex, fill.MakerID); err != nil {
+			return err
+		}
+	}
+
+	// Matches against the user's own orders that were prevented
+	takerCancelled := false
+	decremented := new(big.Rat)
+	for _, prevention := range preventions {
+		if err := e.settlePrevention(tx, mb, ex, orderID, userID, parsed.Side, prevention); err != nil {
+			return err
+		}
+		takerCancelled = takerCancelled || prevention.TakerCancelled
+		if prevention.Mode == engine.DecrementAndCancel && taker.Remaining != nil {
+			decremented.Add(decremented, prevention.Quantity)
+		}
+	}
+
+	// Rest the remainder of a GTC or GTD limit order, release what other orders did not use
+	status := "filled"
+	leftover := new(big.Rat).Sub(parsed.Reserve, state.Unfrozen)
+	rests := parsed.ExecType == "limit" && (parsed.TimeInForce == "GTC" || parsed.TimeInForce == "GTD") && !takerCancelled
+	switch {
+	case rests && taker.Remaining.Sign() > 0:
+		// Quantity taken off by decrement_and_cancel no longer needs funds
+		if decremented.Sign() > 0 {
+			release := decremented
+			if parsed.Side == engine.Buy {
+				release = new(big.Rat).Mul(decremented, parsed.Price)
+			}
+			if err := adjustWallet(tx, userID, parsed.ReserveCoin, release, new(big.Rat).Neg(release)); err != nil {
+				return err
+			}
+			leftover.Sub(leftover, release)
+		}
+		mb.book.Add(&engine.Order{ID: orderID, UserID: userID, Side: parsed.Side, Price: parsed.Price, Remaining: new(big.Rat).Set(taker.Remaining)})
+		status = "new"
+		if state.FilledQuantity.Sign() > 0 {
+			status = "partially_filled"
+		}
+	default:
+		if takerCancelled || (parsed.ExecType == "market" && !marketOrderComplete(mb.book, taker)) ||
+			(parsed.ExecType == "limit" && taker.Remaining.Sign() > 0) {
+			status = "cancelled"
+		}
+		if leftover.Sign() > 0 {
+			if err := adjustWallet(tx, userID, parsed.ReserveCoin, leftover, new(big.Rat).Neg(leftover)); err != nil {
+				return err
+			}
+		}
+		leftover = new(big.Rat)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE orders SET filled_quantity = $2, filled_quote = $3, fee = $4, reserved = $5, status = $6, price = $7,
+			quantity = quantity - $8::numeric, updated_at = NOW()
+		WHERE id = $1
+	`, orderID, state.FilledQuantity.FloatString(ledgerDecimals), state.FilledQuote.FloatString(ledgerDecimals),
+		state.Fee.FloatString(ledgerDecimals), leftover.FloatString(ledgerDecimals), status, ratArg(parsed.Price, market.PricePrecision),
+		decremented.FloatString(ledgerDecimals))
+	if err != nil {
+		return err
+	}
+
+	if state.FilledQuantity.Sign() > 0 {
+		return e.cancelLinked(tx, mb, ex, orderID)
+	}
+	return nil
+}
+
+// settlePrevention applies a prevented self-trade to the resting order: a decremented one loses
+// the quantity and its funds, a cancelled one is closed and its funds released
+func (e *TradingEngine) settlePrevention(tx *sql.Tx, mb *marketBook, ex *execution, takerID, userID uuid.UUID, taker engine.Side, prevention engine.Prevention) error {
+	market := mb.market
+	ex.preventions = append(ex.preventions, preventedMatch{userID, models.SelfTradePrevention{
+		Event:          "self_trade_prevented",
+		Market:         market.Symbol,
+		Mode:           string(prevention.Mode),
+		TakerOrderID:   takerID,
+		MakerOrderID:   prevention.MakerID,
+		Price:          prevention.Price.FloatString(market.PricePrecision),
+		Quantity:       prevention.Quantity.FloatString(market.QuantityPrecision),
+		TakerCancelled: prevention.TakerCancelled,
+		MakerCancelled: prevention.MakerCancelled,
+		CreatedAt:      time.Now().UTC(),
+	}})
+
+	// Decremented quantity leaves the order; the funds it held go back unless the whole
+	// order is cancelled below, which releases them with the rest
+	if prevention.Mode == engine.DecrementAndCancel && prevention.Quantity.Sign() > 0 {
+		// The maker is on the other side of the taker
+		coinID, release := market.BaseCoinID, prevention.Quantity
+		if taker == engine.Sell {
+			coinID, release = market.QuoteCoinID, new(big.Rat).Mul(prevention.Quantity, prevention.Price)
+		}
+		if prevention.MakerCancelled {
+			release = new(big.Rat)
+		}
+		_, err := tx.Exec(`
+			UPDATE orders SET quantity = quantity - $2::numeric, reserved = reserved - $3::numeric, updated_at = NOW()
+			WHERE id = $1
+		`, prevention.MakerID, prevention.Quantity.FloatString(ledgerDecimals), release.FloatString(ledgerDecimals))
+		if err != nil {
+			return err
+		}
+		if err := adjustWallet(tx, userID, coinID, release, new(big.Rat).Neg(release)); err != nil {
+			return err
+		}
+		ex.touch(prevention.MakerID)
+		ex.changed[walletKey{userID, coinID}] = true
+	}
+
+	if prevention.MakerCancelled {
+		if err := e.cancelInTx(tx, mb, ex, prevention.MakerID, "cancelled"); err != nil {
+			return err
+		}
+		return e.cancelLinked(tx, mb, ex, prevention.MakerID)
+	}
+	return nil
+}
+
+// accountSelfTrade returns the self-trade prevention mode of the user's account
+func accountSelfTrade(q limitQueryer, userID uuid.UUID) (string, error) {
+	var mode string
+	err := q.QueryRow("SELECT self_trade_prevention FROM user_trading_settings WHERE user_id = $1", userID).Scan(&mode)
+	if err == sql.ErrNoRows {
+		return string(engine.AllowSelfTrade), nil
+	}
+	return mode, err
+}
+
+// finish applies the committed trades to the ticker state and publishes the market, order and
+// balance updates. It must run while the market is still locked, so updates go out in order.
+func (e *TradingEngine) finish(mb *marketBook, ex *execution) {
+	for _, trade := range ex.trades {
+		mb.lastPrice = ratOrZero(trade.Price)
+		mb.stats.Add(trade.CreatedAt, mb.lastPrice, ratOrZero(trade.Quantity), ratOrZero(trade.QuoteQuantity))
+	}
+
+	e.publishMarket(mb, ex.trades)
+	for _, prevention := range ex.preventions {
+		e.Stream.PublishPrivate(prevention.userID, "orders", prevention.SelfTradePrevention)
+	}
+	for _, orderID := range ex.orders {
+		publishOrder(e.DB, e.Stream, orderID)
+	}
+	for key := range ex.changed {
+		publishBalance(e.DB, e.Stream, key.UserID, key.CoinID)
+	}
+}
+
+// checkTrigger rejects a conditional order whose stop price the reference price has already
+// reached, as it would run at once
+func (e *TradingEngine) checkTrigger(mb *marketBook, parsed *parsedOrder) error {
+	reference := mb.lastPrice
+	if parsed.TriggerBy == "mark_price" {
+		mark, err := markPrice(e.DB, mb.market)
+		if err != nil {
+			return err
+		}
+		reference = mark
+	}
+	if reference != nil && engine.Reached(parsed.Direction, parsed.StopPrice, reference) {
+		return rejectOrder("would_trigger_immediately", "The %s has already reached the stop price", strings.ReplaceAll(parsed.TriggerBy, "_", " "))
+	}
+	return nil
+}
+
+// runTriggers activates the conditional orders reached by the last trade price or by mark (nil
+// to leave mark price triggers alone). Trades of an activated order can reach more triggers,
+// so it repeats until nothing fires. Triggers that fail to activate go back into their book.
+func (e *TradingEngine) runTriggers(mb *marketBook, mark *big.Rat) {
+	type firedTrigger struct {
+		triggerBy string
+		trigger   *engine.Trigger
+	}
+	var failed []firedTrigger
+	seen := map[uuid.UUID]bool{}
+
+	for {
+		var fired []firedTrigger
+		if mb.lastPrice != nil {
+			for _, trigger := range mb.triggers["last_price"].Fire(mb.lastPrice) {
+				fired = append(fired, firedTrigger{"last_price", trigger})
+			}
+		}
+		if mark != nil {
+			for _, trigger := range mb.triggers["mark_price"].Fire(mark) {
+				fired = append(fired, firedTrigger{"mark_price", trigger})
+			}
+		}
+
+		activated := false
+		for _, f := range fired {
+			// A failed activation reloads the book, which puts the trigger back
+			if seen[f.trigger.OrderID] {
+				continue
+			}
+			seen[f.trigger.OrderID] = true
+			activated = true
+			if err := e.activate(mb, f.trigger.OrderID); err != nil {
+				fmt.Printf("Failed to trigger order %s: %v\n", f.trigger.OrderID, err)
+				failed = append(failed, f)
+			}
+		}
+		if !activated {
+			break
+		}
+	}
+
+	for _, f := range failed {
+		if book := mb.triggers[f.triggerBy]; !book.Has(f.trigger.OrderID) {
+			book.Add(f.trigger)
+		}
+	}
+}
+
+// activate runs a triggered conditional order as a limit or market order. The other leg of an
+// OCO pair is cancelled first and its funds go to the stop order. An order that can no longer
+// run (its funds are gone, a fill-or-kill order cannot fill, a post-only order would take) is
+// rejected with the reason code in reject_reason.
+func (e *TradingEngine) activate(mb *marketBook, orderID uuid.UUID) error {
+	tx, err := e.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	var side, orderType, status, reserved, timeInForce, selfTrade string
+	var price, quantity, funds, postOnlyMode sql.NullString
+	err = tx.QueryRow(`
+		SELECT user_id, side, type, status, price, quantity, quote_quantity, reserved, time_in_force, post_only_mode,
+			self_trade_prevention
+		FROM orders WHERE id = $1 FOR UPDATE
+	`, orderID).Scan(&userID, &side, &orderType, &status, &price, &quantity, &funds, &reserved, &timeInForce, &postOnlyMode,
+		&selfTrade)
+	if err != nil {
+		return err
+	}
+	if status != "untriggered" {
+		return nil
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			e.reloadBook(mb)
+		}
+	}()
+
+	ex := newExecution()
+	ex.touch(orderID)
+	if err := e.cancelLinked(tx, mb, ex, orderID); err != nil {
+		return err
+	}
+
+	parsed := &parsedOrder{
+		Side:        engine.Side(side),
+		Type:        orderType,
+		ExecType:    executionType(orderType),
+		Price:       nullRat(price),
+		Quantity:    nullRat(quantity),
+		Funds:       nullRat(funds),
+		TimeInForce: timeInForce,
+		PostOnly:    postOnlyMode.String,
+		SelfTrade:   engine.SelfTradeMode(selfTrade),
+	}
+	orderReserve(mb.market, mb.book, parsed)
+	ex.changed[walletKey{userID, parsed.ReserveCoin}] = true
+
+	// Freeze what the order still needs (only the stop leg of an OCO pair has nothing yet) or
+	// release what a repriced post-only order no longer needs
+	held := ratOrZero(reserved)
+	reason := ""
+	err = checkExecution(mb.market, mb.book, parsed)
+	var rejected *orderRejectedError
+	if errors.As(err, &rejected) {
+		reason = rejected.Code
+	} else if err != nil {
+		return err
+	} else if shortfall := new(big.Rat).Sub(parsed.Reserve, held); shortfall.Sign() > 0 {
+		err := freezeFunds(tx, userID, parsed.ReserveCoin, shortfall)
+		if err == errInsufficientBalance {
+			reason = "insufficient_balance"
+		} else if err != nil {
+			return err
+		}
+	} else if shortfall.Sign() < 0 {
+		if err := adjustWallet(tx, userID, parsed.ReserveCoin, new(big.Rat).Neg(shortfall), shortfall); err != nil {
+			return err
+		}
+	}
+
+	if reason != "" {
+		if err := adjustWallet(tx, userID, parsed.ReserveCoin, held, new(big.Rat).Neg(held)); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			UPDATE orders SET status = 'rejected', reject_reason = $2, reserved = 0, triggered_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+		`, orderID, reason)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE orders SET status = 'new', triggered_at = NOW(), updated_at = NOW() WHERE id = $1
+		`, orderID)
+		if err == nil {
+			err = e.execute(tx, mb, ex, orderID, userID, parsed)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+	e.finish(mb, ex)
+	return nil
+}
+
+// freezeFunds moves amount from a wallet's balance to its frozen balance
+func freezeFunds(tx *sql.Tx, userID uuid.UUID, coinID int, amount *big.Rat) error {
+	result, err := tx.Exec(`
+		UPDATE wallets
+		SET balance = balance - $1::numeric, frozen_balance = frozen_balance + $1::numeric, updated_at = NOW()
+		WHERE user_id = $2 AND coin_id = $3 AND balance >= $1::numeric
+	`, amount.FloatString(ledgerDecimals), userID, coinID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return errInsufficientBalance
+	}
+	return nil
+}
+
+// insertOrder records an accepted order holding parsed.Reserve
+func insertOrder(tx *sql.Tx, orderID, userID uuid.UUID, market *models.Market, parsed *parsedOrder, status, clientOrderID string, linkedOrderID *uuid.UUID) error {
+	var clientID, triggerBy, postOnlyMode *string
+	if id := strings.TrimSpace(clientOrderID); id != "" {
+		clientID = &id
+	}
+	if parsed.StopPrice != nil {
+		triggerBy = &parsed.TriggerBy
+	}
+	if parsed.PostOnly != "" {
+		postOnlyMode = &parsed.PostOnly
+	}
+	_, err := tx.Exec(`
+		INSERT INTO orders (id, user_id, market_id, side, type, price, quantity, quote_quantity, reserved, client_order_id,
+			status, stop_price, trigger_by, linked_order_id, time_in_force, expire_at, post_only, post_only_mode,
+			self_trade_prevention)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	`, orderID, userID, market.ID, string(parsed.Side), parsed.Type, ratArg(parsed.Price, market.PricePrecision),
+		ratArg(parsed.Quantity, market.QuantityPrecision), ratArg(parsed.Funds, ledgerDecimals),
+		parsed.Reserve.FloatString(ledgerDecimals), clientID, status, ratArg(parsed.StopPrice, market.PricePrecision),
+		triggerBy, linkedOrderID, parsed.TimeInForce, parsed.ExpireAt, parsed.PostOnly != "", postOnlyMode,
+		string(parsed.SelfTrade))
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return rejectOrder("duplicate_client_order_id", "An order with this client_order_id already exists")
+	}
+	return err
+}
+
+// settleFill moves the funds of one execution between the taker and the maker, charges both
+// fees at each user's rates, books them as fee revenue, updates the maker order and records the trade
+func (e *TradingEngine) settleFill(tx *sql.Tx, ex *execution, market *models.Market, parsed *parsedOrder, taker *engine.Order,
+	state *takerState, fill engine.Fill, quotePrice *big.Rat) (*models.PublicTrade, error) {
+
+	quote := new(big.Rat).Mul(fill.Price, fill.Quantity)
+	makerFeeRate, err := ex.feeRate(tx, market.ID, fill.MakerUserID)
+	if err != nil {
+		return nil, err
+	}
+	takerFeeRate, err := ex.feeRate(tx, market.ID, taker.UserID)
+	if err != nil {
+		return nil, err
+	}
+	makerRate, takerRate := makerFeeRate.maker, takerFeeRate.taker
+
+	buyer, seller := taker.UserID, fill.MakerUserID
+	buyOrder, sellOrder := taker.ID, fill.MakerID
+	buyerRate, sellerRate := takerRate, makerRate
+	if taker.Side == engine.Sell {
+		buyer, seller = seller, buyer
+		buyOrder, sellOrder = sellOrder, buyOrder
+		buyerRate, sellerRate = makerRate, takerRate
+	}
+
+	// Fees are taken from what each side receives; a maker rebate adds to it
+	buyerFee, _ := new(big.Rat).SetString(truncateRat(new(big.Rat).Mul(fill.Quantity, buyerRate), ledgerDecimals))
+	sellerFee, _ := new(big.Rat).SetString(truncateRat(new(big.Rat).Mul(quote, sellerRate), ledgerDecimals))
+
+	// The buyer's fee is in the base coin and the seller's in the quote coin
+	if taker.Side == engine.Buy {
+		sellerFee = clampMakerRebate(sellerFee, buyerFee, fill.Price)
+	} else {
+		buyerFee = clampMakerRebate(buyerFee, sellerFee, new(big.Rat).Inv(fill.Price))
+	}
+
+	// The buyer's reserve was frozen at its limit price; the price improvement goes back to the balance
+	buyerUnfreeze := quote
+	if taker.Side == engine.Buy && parsed.Price != nil {
+		buyerUnfreeze = new(big.Rat).Mul(parsed.Price, fill.Quantity)
+	}
+	refund := new(big.Rat).Sub(buyerUnfreeze, quote)
+
+	moves := []struct {
+		userID          uuid.UUID
+		coinID          int
+		balance, frozen *big.Rat
+	}{
+		{buyer, market.QuoteCoinID, refund, new(big.Rat).Neg(buyerUnfreeze)},
+		{buyer, market.BaseCoinID, new(big.Rat).Sub(fill.Quantity, buyerFee), new(big.Rat)},
+		{seller, market.BaseCoinID, new(big.Rat), new(big.Rat).Neg(fill.Quantity)},
+		{seller, market.QuoteCoinID, new(big.Rat).Sub(quote, sellerFee), new(big.Rat)},
+	}
+	for _, move := range moves {
+		if err := adjustWallet(tx, move.userID, move.coinID, move.balance, move.frozen); err != nil {
+			return nil, err
+		}
+		ex.changed[walletKey{move.userID, move.coinID}] = true
+	}
+
+	// Maker order: its reserve shrinks by what the fill released
+	makerFee, makerUnfreeze := sellerFee, fill.Quantity
+	if taker.Side == engine.Sell {
+		makerFee, makerUnfreeze = buyerFee, quote
+	}
+	makerStatus := "partially_filled"
+	if fill.MakerRemaining.Sign() == 0 {
+		makerStatus = "filled"
+	}
+	_, err = tx.Exec(`
+		UPDATE orders SET filled_quantity = filled_quantity + $2::numeric, filled_quote = filled_quote + $3::numeric,
+			fee = fee + $4::numeric, reserved = reserved - $5::numeric, status = $6, updated_at = NOW()
+		WHERE id = $1
+	`, fill.MakerID, fill.Quantity.FloatString(ledgerDecimals), quote.FloatString(ledgerDecimals),
+		makerFee.FloatString(ledgerDecimals), makerUnfreeze.FloatString(ledgerDecimals), makerStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	takerFee, takerUnfreeze := buyerFee, buyerUnfreeze
+	if taker.Side == engine.Sell {
+		takerFee, takerUnfreeze = sellerFee, fill.Quantity
+	}
+	state.FilledQuantity.Add(state.FilledQuantity, fill.Quantity)
+	state.FilledQuote.Add(state.FilledQuote, quote)
+	state.Fee.Add(state.Fee, takerFee)
+	state.Unfrozen.Add(state.Unfrozen, takerUnfreeze)
+
+	trade := models.PublicTrade{
+		Market:        market.Symbol,
+		Price:         fill.Price.FloatString(market.PricePrecision),
+		Quantity:      fill.Quantity.FloatString(market.QuantityPrecision),
+		QuoteQuantity: quote.FloatString(ledgerDecimals),
+		TakerSide:     string(taker.Side),
+	}
+	volumeUSD := new(big.Rat).Mul(quote, quotePrice)
+	err = tx.QueryRow(`
+		INSERT INTO trades (market_id, buy_order_id, sell_order_id, buyer_id, seller_id, price, quantity, quote_quantity,
+			taker_side, buyer_fee, seller_fee, buyer_fee_rate, seller_fee_rate, volume_usd)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at
+	`, market.ID, buyOrder, sellOrder, buyer, seller, trade.Price, trade.Quantity, trade.QuoteQuantity,
+		trade.TakerSide, buyerFee.FloatString(ledgerDecimals), sellerFee.FloatString(ledgerDecimals),
+		buyerRate.FloatString(6), sellerRate.FloatString(6), volumeUSD.FloatString(usdDecimals)).Scan(&trade.ID, &trade.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordTradeCandle(tx, market.ID, &trade); err != nil {
+		return nil, err
+	}
+
+	if err := postFeeRevenue(tx, market.BaseCoinID, trade.ID, buyer, buyerFee); err != nil {
+		return nil, err
+	}
+	if err := postFeeRevenue(tx, market.QuoteCoinID, trade.ID, seller, sellerFee); err != nil {
+		return nil, err
+	}
+
+	// Both sides' referrers earn a share of the fee; a rebate counts as no fee
+	zero := new(big.Rat)
+	if buyerFee.Sign() < 0 {
+		buyerFee = zero
+	}
+	if sellerFee.Sign() < 0 {
+		sellerFee = zero
+	}
+	if err := accrueReferralCommission(tx, buyer, market.BaseCoinID, "trade", trade.ID, buyerFee, volumeUSD); err != nil {
+		return nil, err
+	}
+	if err := accrueReferralCommission(tx, seller, market.QuoteCoinID, "trade", trade.ID, sellerFee, volumeUSD); err != nil {
+		return nil, err
+	}
+
+	return &trade, nil
+}
+
+// CancelOrder cancels an open or untriggered order of the user and releases its reserved
+// funds. Cancelling either leg of an OCO pair cancels both.
+func (e *TradingEngine) CancelOrder(userID, orderID uuid.UUID) (*models.Order, error) {
+	order, err := getOrder(e.DB, orderID)
+	if err == sql.ErrNoRows || (err == nil && order.UserID != userID) {
+		return nil, errOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	mb, err := e.lockMarket(order.Market)
+	if err != nil {
+		return nil, err
+	}
+	defer mb.mu.Unlock()
+
+	tx, err := e.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ex := newExecution()
+	if err := e.cancelInTx(tx, mb, ex, orderID, "cancelled"); err != nil {
+		return nil, err
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			e.reloadBook(mb)
+		}
+	}()
+	if err := e.cancelLinked(tx, mb, ex, orderID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	committed = true
+
+	e.finish(mb, ex)
+	return getOrder(e.DB, orderID)
+}
+
+// cancelInTx closes an order with status (cancelled or expired), releases its reserve and
+// takes it out of the books. The in-memory books change last, so they are untouched when it fails.
+func (e *TradingEngine) cancelInTx(tx *sql.Tx, mb *marketBook, ex *execution, orderID uuid.UUID, status string) error {
+	var userID uuid.UUID
+	var current, side, reserved string
+	err := tx.QueryRow(`
+		SELECT user_id, status, side, reserved FROM orders WHERE id = $1 FOR UPDATE
+	`, orderID).Scan(&userID, &current, &side, &reserved)
+	if err != nil {
+		return err
+	}
+	if current != "untriggered" && current != "new" && current != "partially_filled" {
+		return errOrderNotOpen
+	}
+
+	_, err = tx.Exec(`
+		UPDATE orders SET status = $2, reserved = 0, updated_at = NOW() WHERE id = $1
+	`, orderID, status)
+	if err != nil {
+		return err
+	}
+
+	coinID := mb.market.BaseCoinID
+	if side == "buy" {
+		coinID = mb.market.QuoteCoinID
+	}
+	if release := ratOrZero(reserved); release.Sign() > 0 {
+		if err := adjustWallet(tx, userID, coinID, release, new(big.Rat).Neg(release)); err != nil {
+			return err
+		}
+	}
+
+	mb.book.Cancel(orderID)
+	for _, triggers := range mb.triggers {
+		triggers.Remove(orderID)
+	}
+	if linkedID, ok := mb.linked[orderID]; ok {
+		delete(mb.linked, orderID)
+		delete(mb.linked, linkedID)
+	}
+	ex.touch(orderID)
+	ex.changed[walletKey{userID, coinID}] = true
+	return nil
+}
+
+// cancelLinked cancels the other leg of an OCO pair, if the order has one that is still open
+func (e *TradingEngine) cancelLinked(tx *sql.Tx, mb *marketBook, ex *execution, orderID uuid.UUID) error {
+	linkedID, ok := mb.linked[orderID]
+	if !ok {
+		return nil
+	}
+	delete(mb.linked, orderID)
+	delete(mb.linked, linkedID)
+
+	if err := e.cancelInTx(tx, mb, ex, linkedID, "cancelled"); err != nil && err != errOrderNotOpen {
+		return err
+	}
+	return nil
+}
+
+// SubscribeMarket subscribes a stream client to a public market channel (ticker, trades or depth).
+// The snapshot is taken under the market lock, so it matches the returned seq exactly.
+func (e *TradingEngine) SubscribeMarket(client *services.StreamClient, kind, symbol string) (string, uint64, error) {
+	mb, err := e.lockMarket(symbol)
+	if err != nil {
+		return "", 0, err
+	}
+	defer mb.mu.Unlock()
+
+	channel := kind + ":" + mb.market.Symbol
+	var snapshot interface{}
+	switch kind {
+	case "ticker":
+		snapshot = mb.ticker()
+	case "depth":
+		snapshot = mb.depth(0)
+	}
+
+	seq, err := e.Stream.Subscribe(client, channel, channel, snapshot)
+	return channel, seq, err
+}
+
+// publishMarket sends the trades, the order book diff and the ticker after a change to the book
+func (e *TradingEngine) publishMarket(mb *marketBook, trades []models.PublicTrade) {
+	symbol := mb.market.Symbol
+	for _, trade := range trades {
+		e.Stream.Publish("trades:"+symbol, trade)
+	}
+
+	// Always taken, so the next diff only has the next change
+	bids, asks, updateID, changed := mb.book.TakeChanges()
+	if changed {
+		e.Stream.Publish("depth:"+symbol, models.OrderBookDepth{
+			Market:   symbol,
+			UpdateID: updateID,
+			Bids:     formatLevels(bids, mb.market),
+			Asks:     formatLevels(asks, mb.market),
+		})
+	}
+
+	if changed || len(trades) > 0 {
+		e.Stream.Publish("ticker:"+symbol, mb.ticker())
+	}
+}
+
+// ticker returns the latest prices of the market and its 24h statistics
+func (mb *marketBook) ticker() models.MarketTicker {
+	now := time.Now().UTC()
+	bid, ask := mb.book.Best()
+	stats := mb.stats.Snapshot(now)
+	pricePrecision := mb.market.PricePrecision
+
+	ticker := models.MarketTicker{
+		Market:         mb.market.Symbol,
+		LastPrice:      ratArg(mb.lastPrice, pricePrecision),
+		BestBid:        ratArg(bid, pricePrecision),
+		BestAsk:        ratArg(ask, pricePrecision),
+		Open24h:        ratArg(stats.Open, pricePrecision),
+		High24h:        ratArg(stats.High, pricePrecision),
+		Low24h:         ratArg(stats.Low, pricePrecision),
+		Volume24h:      stats.Volume.FloatString(mb.market.QuantityPrecision),
+		QuoteVolume24h: stats.QuoteVolume.FloatString(pricePrecision + mb.market.QuantityPrecision),
+		TradeCount24h:  stats.Count,
+		Time:           now,
+	}
+	if stats.Open != nil && mb.lastPrice != nil && stats.Open.Sign() > 0 {
+		change := new(big.Rat).Sub(mb.lastPrice, stats.Open)
+		percent := new(big.Rat).Quo(new(big.Rat).Mul(change, big.NewRat(100, 1)), stats.Open)
+		ticker.PriceChange24h = ratArg(change, pricePrecision)
+		ticker.PriceChangePercent = ratArg(percent, 2)
+	}
+	return ticker
+}
+
+// Depth returns an order book snapshot of a market with up to limit levels per side. A tick
+// groups the levels into wider price steps; it must be a multiple of the market's price step.
+// The update ID is that of the last depth diff published, taken under the market lock.
+func (e *TradingEngine) Depth(symbol string, limit int, tick string) (*models.OrderBookDepth, error) {
+	mb, err := e.lockMarket(symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer mb.mu.Unlock()
+
+	if tick == "" {
+		depth := mb.depth(limit)
+		return &depth, nil
+	}
+
+	step, err := parseAmount(tick, mb.market.PricePrecision)
+	if err != nil {
+		return nil, errInvalidTick
+	}
+	bids, asks := mb.book.Depth(0)
+	return &models.OrderBookDepth{
+		Market:   mb.market.Symbol,
+		UpdateID: mb.book.UpdateID(),
+		Tick:     step.FloatString(mb.market.PricePrecision),
+		Bids:     formatLevels(engine.GroupLevels(engine.Buy, bids, step, limit), mb.market),
+		Asks:     formatLevels(engine.GroupLevels(engine.Sell, asks, step, limit), mb.market),
+	}, nil
+}
+
+// Ticker returns the ticker of a market
+func (e *TradingEngine) Ticker(symbol string) (*models.MarketTicker, error) {
+	mb, err := e.lockMarket(symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer mb.mu.Unlock()
+
+	ticker := mb.ticker()
+	return &ticker, nil
+}
+
+// depth returns up to limit levels per side; 0 returns the whole book
+func (mb *marketBook) depth(limit int) models.OrderBookDepth {
+	bids, asks := mb.book.Depth(limit)
+	return models.OrderBookDepth{
+		Market:   mb.market.Symbol,
+		UpdateID: mb.book.UpdateID(),
+		Bids:     formatLevels(bids, mb.market),
+		Asks:     formatLevels(asks, mb.market),
+	}
+}
+
+// parseOrder validates an order request against the market rules and works out the funds to freeze
+func parseOrder(market *models.Market, book *engine.Book, req *models.PlaceOrderRequest) (*parsedOrder, error) {
+	parsed := &parsedOrder{
+		Side:      engine.Side(req.Side),
+		Type:      req.Type,
+		ExecType:  executionType(req.Type),
+		SelfTrade: engine.SelfTradeMode(req.SelfTrade),
+	}
+	quoteDecimals := market.PricePrecision + market.QuantityPrecision
+	conditional := req.Type != "limit" && req.Type != "market"
+
+	if req.StopLimitPrice != "" {
+		return nil, rejectOrder("invalid_order", "Only OCO orders take a stop_limit_price")
+	}
+	if conditional {
+		if req.StopPrice == "" {
+			return nil, rejectOrder("invalid_stop_price", "Stop and take-profit orders need a stop_price")
+		}
+		stopPrice, err := parseAmount(req.StopPrice, market.PricePrecision)
+		if err != nil {
+			return nil, rejectOrder("invalid_stop_price", "Stop price: %s (max %d decimals)", err.Error(), market.PricePrecision)
+		}
+		parsed.StopPrice = stopPrice
+		parsed.Direction = triggerDirection(req.Type, parsed.Side)
+		parsed.TriggerBy = req.TriggerBy
+		if parsed.TriggerBy == "" {
+			parsed.TriggerBy = "last_price"
+		}
+	} else if req.StopPrice != "" || req.TriggerBy != "" {
+		return nil, rejectOrder("invalid_order", "Only stop and take-profit orders take a stop_price")
+	}
+
+	// Limit orders rest until cancelled by default, market orders never rest
+	parsed.TimeInForce = req.TimeInForce
+	if parsed.TimeInForce == "" {
+		parsed.TimeInForce = "GTC"
+		if parsed.ExecType == "market" {
+			parsed.TimeInForce = "IOC"
+		}
+	}
+	if parsed.ExecType == "market" && parsed.TimeInForce != "IOC" && parsed.TimeInForce != "FOK" {
+		return nil, rejectOrder("invalid_time_in_force", "Market orders can only be IOC or FOK")
+	}
+	if parsed.TimeInForce == "GTD" {
+		if req.ExpireAt == nil || !req.ExpireAt.After(time.Now()) {
+			return nil, rejectOrder("invalid_expire_at", "GTD orders need an expire_at in the future")
+		}
+		expireAt := req.ExpireAt.UTC()
+		parsed.ExpireAt = &expireAt
+	} else if req.ExpireAt != nil {
+		return nil, rejectOrder("invalid_expire_at", "Only GTD orders take an expire_at")
+	}
+	if req.PostOnly {
+		if parsed.ExecType != "limit" || parsed.TimeInForce == "IOC" || parsed.TimeInForce == "FOK" {
+			return nil, rejectOrder("invalid_post_only", "Only GTC and GTD limit orders can be post-only")
+		}
+		parsed.PostOnly = req.PostOnlyMode
+		if parsed.PostOnly == "" {
+			parsed.PostOnly = "reject"
+		}
+	} else if req.PostOnlyMode != "" {
+		return nil, rejectOrder("invalid_post_only", "post_only_mode needs post_only")
+	}
+
+	if parsed.ExecType == "limit" {
+		if req.Price == "" || req.Quantity == "" || req.QuoteQuantity != "" {
+			return nil, rejectOrder("invalid_order", "Limit orders need a price and a quantity")
+		}
+		price, err := parseAmount(req.Price, market.PricePrecision)
+		if err != nil {
+			return nil, rejectOrder("invalid_price", "Price: %s (max %d decimals)", err.Error(), market.PricePrecision)
+		}
+		parsed.Price = price
+	} else {
+		if req.Price != "" {
+			return nil, rejectOrder("invalid_order", "Market orders do not take a price")
+		}
+		if (req.Quantity == "") == (req.QuoteQuantity == "") || (req.Side == "sell" && req.Quantity == "") {
+			return nil, rejectOrder("invalid_order", "Market orders need a quantity, or a quote_quantity for buys")
+		}
+		// What a market buy by quantity costs is only known when it runs
+		if conditional && req.Side == "buy" && req.QuoteQuantity == "" {
+			return nil, rejectOrder("invalid_order", "Stop and take-profit market buys need a quote_quantity")
+		}
+		if !conditional && !book.HasLiquidity(parsed.Side) {
+			return nil, rejectOrder("no_liquidity", "There are no orders to match against")
+		}
+	}
+
+	if req.Quantity != "" {
+		quantity, err := parseAmount(req.Quantity, market.QuantityPrecision)
+		if err != nil {
+			return nil, rejectOrder("invalid_quantity", "Quantity: %s (max %d decimals)", err.Error(), market.QuantityPrecision)
+		}
+		if minQuantity, _ := new(big.Rat).SetString(market.MinQuantity); minQuantity != nil && quantity.Cmp(minQuantity) < 0 {
+			return nil, rejectOrder("quantity_too_small", "Quantity is below the minimum of %s", market.MinQuantity)
+		}
+		if market.MaxQuantity != nil {
+			if maxQuantity, _ := new(big.Rat).SetString(*market.MaxQuantity); maxQuantity != nil && quantity.Cmp(maxQuantity) > 0 {
+				return nil, rejectOrder("quantity_too_large", "Quantity is above the maximum of %s", *market.MaxQuantity)
+			}
+		}
+		parsed.Quantity = quantity
+	} else {
+		funds, err := parseAmount(req.QuoteQuantity, quoteDecimals)
+		if err != nil {
+			return nil, rejectOrder("invalid_quote_quantity", "Quote quantity: %s (max %d decimals)", err.Error(), quoteDecimals)
+		}
+		parsed.Funds = funds
+	}
+
+	if err := checkNotional(market, parsed); err != nil {
+		return nil, err
+	}
+
+	orderReserve(market, book, parsed)
+	return parsed, nil
+}
+
+// checkNotional enforces the minimum order value, where it is known up front
+func checkNotional(market *models.Market, parsed *parsedOrder) error {
+	var notional *big.Rat
+	if parsed.Price != nil {
+		notional = new(big.Rat).Mul(parsed.Price, parsed.Quantity)
+	} else if parsed.Funds != nil {
+		notional = parsed.Funds
+	}
+	if minNotional, _ := new(big.Rat).SetString(market.MinNotional); notional != nil && minNotional != nil && notional.Cmp(minNotional) < 0 {
+		return rejectOrder("notional_too_small", "Order value is below the minimum of %s %s", market.MinNotional, market.QuoteCurrency)
+	}
+	return nil
+}
+
+// checkExecution applies the rules that depend on the book at the moment an order becomes
+// active: a post-only order must not take liquidity (in reprice mode it is moved one price
+// step away from the best opposite price instead) and a fill-or-kill order must fill completely
+func checkExecution(market *models.Market, book *engine.Book, parsed *parsedOrder) error {
+	if parsed.PostOnly != "" {
+		bid, ask := book.Best()
+		step := new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(market.PricePrecision)), nil))
+		var price *big.Rat
+		switch {
+		case parsed.Side == engine.Buy && ask != nil && parsed.Price.Cmp(ask) >= 0:
+			price = new(big.Rat).Sub(ask, step)
+		case parsed.Side == engine.Sell && bid != nil && parsed.Price.Cmp(bid) <= 0:
+			price = new(big.Rat).Add(bid, step)
+		}
+		if price != nil {
+			if parsed.PostOnly != "reprice" || price.Sign() <= 0 {
+				return rejectOrder("post_only_would_take", "A post-only order at %s would take liquidity", parsed.Price.FloatString(market.PricePrecision))
+			}
+			parsed.Price = price
+			orderReserve(market, book, parsed)
+			if err := checkNotional(market, parsed); err != nil {
+				return err
+			}
+		}
+	}
+
+	if parsed.TimeInForce == "FOK" && !book.Fillable(newTaker(uuid.Nil, uuid.Nil, parsed)) {
+		return rejectOrder("fok_not_filled", "The order cannot be filled completely at once")
+	}
+	return nil
+}
+
+// newTaker returns the engine order that matches an accepted order against the book
+func newTaker(orderID, userID uuid.UUID, parsed *parsedOrder) *engine.Order {
+	taker := &engine.Order{ID: orderID, UserID: userID, Side: parsed.Side, Price: parsed.Price, SelfTrade: parsed.SelfTrade}
+	if parsed.Quantity != nil {
+		taker.Remaining = new(big.Rat).Set(parsed.Quantity)
+	} else {
+		taker.Funds = new(big.Rat).Set(parsed.Funds)
+	}
+	return taker
+}
+
+// orderReserve works out the coin and amount an order has to freeze
+func orderReserve(market *models.Market, book *engine.Book, parsed *parsedOrder) {
+	switch {
+	case parsed.Side == engine.Sell:
+		parsed.ReserveCoin, parsed.Reserve = market.BaseCoinID, parsed.Quantity
+	case parsed.Price != nil:
+		parsed.ReserveCoin, parsed.Reserve = market.QuoteCoinID, new(big.Rat).Mul(parsed.Price, parsed.Quantity)
+	case parsed.Funds != nil:
+		parsed.ReserveCoin, parsed.Reserve = market.QuoteCoinID, parsed.Funds
+	default:
+		// Market buy by quantity: the cost of sweeping the asks, exact because the book is locked
+		parsed.ReserveCoin, parsed.Reserve = market.QuoteCoinID, sweepCost(book, parsed.Quantity)
+	}
+}
+
+// executionType returns how an order type runs once it is active: limit or market
+func executionType(orderType string) string {
+	switch orderType {
+	case "limit", "stop_limit", "take_profit_limit":
+		return "limit"
+	}
+	return "market"
+}
+
+// triggerDirection returns the way the price has to move to trigger an order: stop orders
+// trigger when the price moves against the position (down for sells, up for buys),
+// take-profit orders when it moves in its favour
+func triggerDirection(orderType string, side engine.Side) engine.Direction {
+	stop := orderType == "stop_market" || orderType == "stop_limit"
+	if stop == (side == engine.Sell) {
+		return engine.Falling
+	}
+	return engine.Rising
+}
+
+// markPrice returns the price of the market's base coin in its quote coin from the coin
+// prices, nil if either has no price
+func markPrice(q limitQueryer, market *models.Market) (*big.Rat, error) {
+	base, err := coinPrice(q, market.BaseCoinID)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := coinPrice(q, market.QuoteCoinID)
+	if err != nil {
+		return nil, err
+	}
+	if base.Sign() <= 0 || quote.Sign() <= 0 {
+		return nil, nil
+	}
+	return new(big.Rat).Quo(base, quote), nil
+}
+
+// sweepCost returns what buying quantity from the asks costs; less if the book is too thin
+func sweepCost(book *engine.Book, quantity *big.Rat) *big.Rat {
+	_, asks := book.Depth(0)
+	cost := new(big.Rat)
+	left := new(big.Rat).Set(quantity)
+	for _, level := range asks {
+		if left.Sign() <= 0 {
+			break
+		}
+		take := level.Quantity
+		if take.Cmp(left) > 0 {
+			take = left
+		}
+		cost.Add(cost, new(big.Rat).Mul(take, level.Price))
+		left = new(big.Rat).Sub(left, take)
+	}
+	return cost
+}
+
+// marketOrderComplete reports whether a market order got everything it asked for. A buy sized
+// by funds is complete once the rest does not pay for the smallest quantity step.
+func marketOrderComplete(book *engine.Book, taker *engine.Order) bool {
+	if taker.Remaining != nil {
+		return taker.Remaining.Sign() == 0
+	}
+	return taker.Funds.Sign() == 0 || book.HasLiquidity(taker.Side)
+}
+
+// adjustWallet adds signed deltas to a wallet's balance and frozen balance, creating the wallet
+// for coins the user did not hold yet
+func adjustWallet(tx *sql.Tx, userID uuid.UUID, coinID int, balance, frozen *big.Rat) error {
+	if balance.Sign() == 0 && frozen.Sign() == 0 {
+		return nil
+	}
+	_, err := tx.Exec(`
+		INSERT INTO wallets (user_id, coin_id, balance, frozen_balance)
+		VALUES ($1, $2, $3::numeric, $4::numeric)
+		ON CONFLICT (user_id, coin_id) DO UPDATE SET
+			balance = wallets.balance + EXCLUDED.balance,
+			frozen_balance = wallets.frozen_balance + EXCLUDED.frozen_balance,
+			updated_at = NOW()
+	`, userID, coinID, balance.FloatString(ledgerDecimals), frozen.FloatString(ledgerDecimals))
+	return err
+}
+
+// coinPrice returns the USD price of a coin, zero if it has none
+func coinPrice(q limitQueryer, coinID int) (*big.Rat, error) {
+	var raw string
+	if err := q.QueryRow("SELECT price FROM coins WHERE id = $1", coinID).Scan(&raw); err != nil {
+		return nil, err
+	}
+	price, ok := new(big.Rat).SetString(raw)
+	if !ok {
+		return new(big.Rat), nil
+	}
+	return price, nil
+}
+
+// getMarket loads one market by a condition on the markets table (alias m)
+func getMarket(q limitQueryer, condition string, args ...interface{}) (*models.Market, error) {
+	return scanMarket(q.QueryRow("SELECT "+marketColumns+" "+marketJoins+" WHERE "+condition, args...))
+}
+
+func scanMarket(row rowScanner) (*models.Market, error) {
+	var m models.Market
+	err := row.Scan(&m.ID, &m.Symbol, &m.BaseCoinID, &m.QuoteCoinID, &m.BaseCurrency, &m.QuoteCurrency, &m.IsActive,
+		&m.MinQuantity, &m.MaxQuantity, &m.MinNotional, &m.PricePrecision, &m.QuantityPrecision,
+		&m.MakerFeeRate, &m.TakerFeeRate, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// getOrder loads one order with its market symbol
+func getOrder(q limitQueryer, orderID uuid.UUID) (*models.Order, error) {
+	return scanOrder(q.QueryRow(`
+		SELECT `+orderColumns+`
+		FROM orders o JOIN markets m ON m.id = o.market_id
+		WHERE o.id = $1
+	`, orderID))
+}
+
+func scanOrder(row rowScanner) (*models.Order, error) {
+	var o models.Order
+	err := row.Scan(&o.ID, &o.UserID, &o.MarketID, &o.Market, &o.Side, &o.Type, &o.Price, &o.Quantity, &o.QuoteQuantity,
+		&o.FilledQuantity, &o.FilledQuote, &o.Fee, &o.Reserved, &o.Status, &o.RejectReason, &o.TimeInForce, &o.ExpireAt, &o.PostOnly, &o.PostOnlyMode, &o.SelfTrade, &o.ClientOrderID, &o.StopPrice, &o.TriggerBy, &o.TriggeredAt, &o.LinkedOrderID, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// ratOrZero parses a NUMERIC value read from the database
+func ratOrZero(raw string) *big.Rat {
+	value, ok := new(big.Rat).SetString(raw)
+	if !ok {
+		return new(big.Rat)
+	}
+	return value
+}
+
+// ratArg formats an optional value for a NUMERIC column
+func ratArg(value *big.Rat, decimals int) *string {
+	if value == nil {
+		return nil
+	}
+	s := value.FloatString(decimals)
+	return &s
+}
+
+// formatLevels formats order book levels as [price, quantity] pairs
+func formatLevels(levels []engine.Level, market *models.Market) [][2]string {
+	formatted := make([][2]string, 0, len(levels))
+	for _, level := range levels {
+		formatted = append(formatted, [2]string{
+			level.Price.FloatString(market.PricePrecision),
+			level.Quantity.FloatString(market.QuantityPrecision),
+		})
+	}
+	return formatted
+}
@@ -49,7 +49,7 @@ func (h *TransactionHandler) GetTransactions(c *gin.Context) {
 	query := `
 		SELECT 
 			t.id, t.user_id, t.wallet_id, t.type, t.amount, t.fee, 
-			t.description, t.reference_id, t.payment_method, t.status, t.created_at, t.updated_at,
+			t.description, t.reference_id, t.payment_method, t.address, t.memo, t.status, t.created_at, t.updated_at,
 			c.ticker, c.name
 		FROM transactions t
 		JOIN wallets w ON t.wallet_id = w.id
@@ -77,7 +77,7 @@ func (h *TransactionHandler) GetTransactions(c *gin.Context) {
 		var t TransactionWithMeta
 		if err := rows.Scan(
 			&t.ID, &t.UserID, &t.WalletID, &t.Type, &t.Amount, &t.Fee,
-			&t.Description, &t.ReferenceID, &t.PaymentMethod, &t.Status, &t.CreatedAt, &t.UpdatedAt,
+			&t.Description, &t.ReferenceID, &t.PaymentMethod, &t.Address, &t.Memo, &t.Status, &t.CreatedAt, &t.UpdatedAt,
 			&t.CoinTicker, &t.CoinName,
 		); err != nil {
 			continue
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TransferHandler struct {
+	DB     *sql.DB
+	Stream *services.StreamHub
+}
+
+func NewTransferHandler(db *sql.DB, stream *services.StreamHub) *TransferHandler {
+	return &TransferHandler{DB: db, Stream: stream}
+}
+
+// CreateTransfer godoc
+// @Summary Send funds to another user
+// @Description Move funds to another user's wallet, found by email or username. Requires a 2fa OTP code (request one with type "2fa"). The sender's KYC tier must allow transfers and the amount counts toward the sender's withdrawal caps; the recipient's tier must allow deposits and the amount counts toward the recipient's deposit caps (see GET /api/v1/limits). The sender gets a completed transfer transaction, the recipient a completed deposit.
+// @Tags Wallets
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.TransferRequest true "Transfer data"
+// @Success 201 {object} models.Transaction "Transfer completed"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors, invalid OTP, own account or insufficient balance"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Withdrawals locked, not allowed for either KYC tier or over a limit"
+// @Failure 404 {object} map[string]interface{} "Coin or recipient not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/transfers [post]
+func (h *TransferHandler) CreateTransfer(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// 1. Account level checks; a transfer moves funds out like a withdrawal
+	var status, username string
+	var lockedUntil sql.NullTime
+	err := h.DB.QueryRow(`
+		SELECT status, username, withdrawals_locked_until FROM users WHERE id = $1
+	`, userID).Scan(&status, &username, &lockedUntil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	if status != "active" && status != "pending" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "account_inactive", "message": "Account is not active. Please contact support."})
+		return
+	}
+
+	if lockedUntil.Valid && time.Now().Before(lockedUntil.Time) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":        "withdrawals_locked",
+			"message":      "Withdrawals are temporarily locked after a recent security change",
+			"locked_until": lockedUntil.Time,
+		})
+		return
+	}
+
+	// 2. Recipient checks
+	var recipientID uuid.UUID
+	var recipientStatus string
+	err = h.DB.QueryRow(`
+		SELECT id, status FROM users
+		WHERE (LOWER(username) = LOWER($1) OR LOWER(email) = LOWER($1)) AND deleted_at IS NULL
+	`, strings.TrimSpace(req.Recipient)).Scan(&recipientID, &recipientStatus)
+	if err == sql.ErrNoRows || (err == nil && recipientStatus != "active" && recipientStatus != "pending") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recipient_not_found", "message": "Recipient not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve recipient"})
+		return
+	}
+	if recipientID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_recipient", "message": "You can not transfer to your own account"})
+		return
+	}
+
+	// 3. Coin checks
+	var coinID, decimals, coinStatus int
+	err = h.DB.QueryRow(`
+		SELECT id, decimal, status FROM coins WHERE UPPER(ticker) = UPPER($1)
+	`, strings.TrimSpace(req.Coin)).Scan(&coinID, &decimals, &coinStatus)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "coin_not_found", "message": "Coin not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve coin"})
+		return
+	}
+	if coinStatus != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "coin_disabled", "message": "This coin is disabled"})
+		return
+	}
+
+	amount, err := parseAmount(req.Amount, minInt(decimals, withdrawalDecimals))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_amount", "message": err.Error()})
+		return
+	}
+
+	// 4. KYC tier limits of both sides (checked again while the users are locked)
+	if err := checkLimit(h.DB, userID, coinID, "transfer", amount); err != nil {
+		respondLimitError(c, err)
+		return
+	}
+	if err := checkLimit(h.DB, recipientID, coinID, "deposit", amount); err != nil {
+		respondRecipientLimitError(c, err)
+		return
+	}
+
+	// 5. Second factor
+	if err := consumeOTP(h.DB, userID, "2fa", req.Code); err != nil {
+		respondOTPError(c, err)
+		return
+	}
+
+	// 6. Move the funds
+	transaction, deposit, err := h.createTransfer(userID, username, recipientID, coinID, amount)
+	if err == errInsufficientBalance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient_balance", "message": "Insufficient balance"})
+		return
+	}
+	if err != nil {
+		var recipientErr *recipientLimitError
+		if errors.As(err, &recipientErr) {
+			respondRecipientLimitError(c, recipientErr.err)
+			return
+		}
+		var featureErr *featureDisabledError
+		var limitErr *limitExceededError
+		if errors.As(err, &featureErr) || errors.As(err, &limitErr) || err == errLimitUnpriced {
+			respondLimitError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to create transfer"})
+		return
+	}
+
+	// 7. Transaction monitoring of both sides
+	for _, t := range []*models.Transaction{transaction, deposit} {
+		if _, err := evaluateTransaction(h.DB, t.ID); err != nil {
+			fmt.Printf("Failed to evaluate monitoring rules for transaction %s: %v\n", t.ID, err)
+		}
+	}
+
+	// 8. Push the transactions and balances to both users' streams
+	h.Stream.PublishPrivate(userID, "transactions", transaction)
+	h.Stream.PublishPrivate(recipientID, "transactions", deposit)
+	publishBalance(h.DB, h.Stream, userID, coinID)
+	publishBalance(h.DB, h.Stream, recipientID, coinID)
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+// recipientLimitError wraps an error of checkLimit for the recipient's KYC tier
+type recipientLimitError struct {
+	err error
+}
+
+func (e *recipientLimitError) Error() string {
+	return "recipient: " + e.err.Error()
+}
+
+// createTransfer debits the sender and credits the recipient as a deposit in one database
+// transaction. Both user rows are locked, in id order so two opposite transfers can not deadlock,
+// so concurrent transfers can not exceed either side's caps together.
+func (h *TransferHandler) createTransfer(userID uuid.UUID, username string, recipientID uuid.UUID, coinID int, amount *big.Rat) (*models.Transaction, *models.Transaction, error) {
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	first, second := userID, recipientID
+	if strings.Compare(second.String(), first.String()) < 0 {
+		first, second = second, first
+	}
+	for _, id := range []uuid.UUID{first, second} {
+		if _, err := tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", id); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := checkLimit(tx, userID, coinID, "transfer", amount); err != nil {
+		return nil, nil, err
+	}
+
+	amountStr := amount.FloatString(withdrawalDecimals)
+	var walletID uuid.UUID
+	err = tx.QueryRow(`
+		UPDATE wallets
+		SET balance = balance - $1::numeric, updated_at = NOW()
+		WHERE user_id = $2 AND coin_id = $3 AND balance >= $1::numeric
+		RETURNING id
+	`, amountStr, userID, coinID).Scan(&walletID)
+	if err == sql.ErrNoRows {
+		return nil, nil, errInsufficientBalance
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transferID := uuid.New()
+	deposit, err := creditDeposit(tx, recipientID, coinID, amount, "Transfer from "+username, transferID.String())
+	if err != nil {
+		var featureErr *featureDisabledError
+		var limitErr *limitExceededError
+		if errors.As(err, &featureErr) || errors.As(err, &limitErr) || err == errLimitUnpriced {
+			return nil, nil, &recipientLimitError{err: err}
+		}
+		return nil, nil, err
+	}
+
+	description := "Transfer to another user"
+	referenceID := deposit.ID.String()
+	t := models.Transaction{
+		ID:          transferID,
+		UserID:      userID,
+		WalletID:    walletID,
+		Type:        "transfer",
+		Amount:      amountStr,
+		Fee:         "0",
+		Description: &description,
+		ReferenceID: &referenceID,
+		Status:      "completed",
+	}
+	err = tx.QueryRow(`
+		INSERT INTO transactions (id, user_id, wallet_id, type, amount, fee, description, reference_id, status)
+		VALUES ($1, $2, $3, 'transfer', $4, 0, $5, $6, 'completed')
+		RETURNING created_at, updated_at
+	`, t.ID, userID, walletID, amountStr, description, referenceID).Scan(&t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return &t, deposit, nil
+}
+
+// respondRecipientLimitError writes the response when the recipient's KYC tier refuses the
+// deposit. The recipient's limits are not disclosed to the sender.
+func respondRecipientLimitError(c *gin.Context, err error) {
+	var featureErr *featureDisabledError
+	var limitErr *limitExceededError
+	if errors.As(err, &featureErr) || errors.As(err, &limitErr) || err == errLimitUnpriced {
+		c.JSON(http.StatusForbidden, gin.H{"error": "recipient_limit", "message": "The recipient can not receive this amount"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to check limits"})
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/Bixor-Engine/backend/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// withdrawalDecimals is the scale of wallet and transaction amounts (NUMERIC(20, 8))
+const withdrawalDecimals = 8
+
+var errInsufficientBalance = errors.New("insufficient balance")
+
+type WithdrawalHandler struct {
+	DB        *sql.DB
+	Screening *services.ScreeningService
+	Stream    *services.StreamHub
+}
+
+func NewWithdrawalHandler(db *sql.DB, screening *services.ScreeningService, stream *services.StreamHub) *WithdrawalHandler {
+	return &WithdrawalHandler{DB: db, Screening: screening, Stream: stream}
+}
+
+// withdrawalCoin holds the coin fields needed to process a withdrawal
+type withdrawalCoin struct {
+	ID              int
+	Ticker          string
+	Decimal         int
+	Status          int
+	WithdrawStatus  *int
+	WithdrawFee     *string
+	WithdrawFeeType *int
+}
+
+// CreateWithdrawal godoc
+// @Summary Request a withdrawal
+// @Description Withdraw funds to an external address. Requires a 2fa OTP code (request one with type "2fa"). The amount must be within the daily and monthly limits of the user's KYC tier (see GET /api/v1/limits). In whitelist-only mode the address must be a confirmed address book entry past its time lock. The amount plus fee is moved to the frozen balance until the withdrawal is processed.
+// @Tags Withdrawals
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.WithdrawRequest true "Withdrawal data"
+// @Success 201 {object} models.Transaction "Withdrawal created"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors, invalid OTP or insufficient balance"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Withdrawals locked, not allowed for the KYC tier, over the limit, under compliance review or address not whitelisted"
+// @Failure 404 {object} map[string]interface{} "Coin not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/withdrawals [post]
+func (h *WithdrawalHandler) CreateWithdrawal(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.WithdrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	address := strings.TrimSpace(req.Address)
+	memo := strings.TrimSpace(req.Memo)
+
+	// 1. Account level checks
+	var status string
+	var lockedUntil sql.NullTime
+	var whitelistEnabled bool
+	err := h.DB.QueryRow(`
+		SELECT status, withdrawals_locked_until, withdrawal_whitelist_enabled FROM users WHERE id = $1
+	`, userID).Scan(&status, &lockedUntil, &whitelistEnabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	if status != "active" && status != "pending" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "account_inactive", "message": "Account is not active. Please contact support."})
+		return
+	}
+
+	if lockedUntil.Valid && time.Now().Before(lockedUntil.Time) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":        "withdrawals_locked",
+			"message":      "Withdrawals are temporarily locked after a recent security change",
+			"locked_until": lockedUntil.Time,
+		})
+		return
+	}
+
+	// 2. Coin checks
+	coin, err := h.getCoinByTicker(req.Coin)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "coin_not_found", "message": "Coin not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve coin"})
+		return
+	}
+
+	if coin.Status != 1 || (coin.WithdrawStatus != nil && *coin.WithdrawStatus == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "withdrawals_disabled", "message": "Withdrawals are disabled for this coin"})
+		return
+	}
+
+	amount, err := parseAmount(req.Amount, minInt(coin.Decimal, withdrawalDecimals))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_amount", "message": err.Error()})
+		return
+	}
+
+	// 3. KYC tier limits (checked again while the funds are frozen)
+	if err := checkLimit(h.DB, userID, coin.ID, "withdraw", amount); err != nil {
+		respondLimitError(c, err)
+		return
+	}
+
+	// 4. Sanctions screening of the destination
+	screeningCase, err := screenSubject(h.DB, h.Screening, userID, "withdrawal_address", nil, address)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "screening_error", "message": "Failed to screen address"})
+		return
+	}
+	if screeningCase != nil {
+		respondScreeningHold(c)
+		return
+	}
+
+	// 5. Address book checks
+	if whitelistEnabled {
+		var availableAt time.Time
+		err := h.DB.QueryRow(`
+			SELECT available_at FROM withdrawal_addresses
+			WHERE user_id = $1 AND coin_id = $2 AND address = $3 AND COALESCE(memo, '') = $4 AND status = 'active'
+		`, userID, coin.ID, address, memo).Scan(&availableAt)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "address_not_whitelisted",
+				"message": "Whitelist-only mode is enabled. Add and confirm this address in your address book first.",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to check address book"})
+			return
+		}
+		if time.Now().Before(availableAt) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":        "address_locked",
+				"message":      "This address was added recently and can not be used yet",
+				"available_at": availableAt,
+			})
+			return
+		}
+	}
+
+	// 6. Second factor
+	if err := consumeOTP(h.DB, userID, "2fa", req.Code); err != nil {
+		respondOTPError(c, err)
+		return
+	}
+
+	// 7. Freeze funds and record the withdrawal
+	fee := withdrawalFee(amount, coin)
+	transaction, err := h.createWithdrawal(userID, coin, amount, fee, address, memo)
+	if err == errInsufficientBalance {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "insufficient_balance",
+			"message": "Insufficient balance to cover the amount and fee",
+			"fee":     fee.FloatString(withdrawalDecimals),
+		})
+		return
+	}
+	if err != nil {
+		var featureErr *featureDisabledError
+		var limitErr *limitExceededError
+		if errors.As(err, &featureErr) || errors.As(err, &limitErr) || err == errLimitUnpriced {
+			respondLimitError(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to create withdrawal"})
+		return
+	}
+
+	// 8. Transaction monitoring; a freeze holds the pending withdrawal for compliance review
+	if _, err := evaluateTransaction(h.DB, transaction.ID); err != nil {
+		fmt.Printf("Failed to evaluate monitoring rules for transaction %s: %v\n", transaction.ID, err)
+	}
+
+	// 9. Push the new transaction and frozen funds to the user's streams
+	h.Stream.PublishPrivate(userID, "transactions", transaction)
+	publishBalance(h.DB, h.Stream, userID, coin.ID)
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+// createWithdrawal moves amount + fee to the frozen balance and inserts a pending transaction.
+// The user row is locked so concurrent withdrawals can not exceed the KYC tier limits together.
+func (h *WithdrawalHandler) createWithdrawal(userID uuid.UUID, coin *withdrawalCoin, amount, fee *big.Rat, address, memo string) (*models.Transaction, error) {
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", userID); err != nil {
+		return nil, err
+	}
+	if err := checkLimit(tx, userID, coin.ID, "withdraw", amount); err != nil {
+		return nil, err
+	}
+
+	amountStr := amount.FloatString(withdrawalDecimals)
+	feeStr := fee.FloatString(withdrawalDecimals)
+	total := new(big.Rat).Add(amount, fee).FloatString(withdrawalDecimals)
+
+	var walletID uuid.UUID
+	err = tx.QueryRow(`
+		UPDATE wallets
+		SET balance = balance - $1::numeric, frozen_balance = frozen_balance + $1::numeric, updated_at = NOW()
+		WHERE user_id = $2 AND coin_id = $3 AND balance >= $1::numeric
+		RETURNING id
+	`, total, userID, coin.ID).Scan(&walletID)
+	if err == sql.ErrNoRows {
+		return nil, errInsufficientBalance
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var memoArg *string
+	if memo != "" {
+		memoArg = &memo
+	}
+	description := "Withdrawal to " + address
+
+	t := models.Transaction{
+		ID:          uuid.New(),
+		UserID:      userID,
+		WalletID:    walletID,
+		Type:        "withdraw",
+		Amount:      amountStr,
+		Fee:         feeStr,
+		Description: &description,
+		Address:     &address,
+		Memo:        memoArg,
+		Status:      "pending",
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO transactions (id, user_id, wallet_id, type, amount, fee, description, address, memo, status)
+		VALUES ($1, $2, $3, 'withdraw', $4, $5, $6, $7, $8, 'pending')
+		RETURNING created_at, updated_at
+	`, t.ID, userID, walletID, amountStr, feeStr, description, address, memoArg).Scan(&t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// getCoinByTicker loads the withdrawal settings of a coin
+func (h *WithdrawalHandler) getCoinByTicker(ticker string) (*withdrawalCoin, error) {
+	var coin withdrawalCoin
+	err := h.DB.QueryRow(`
+		SELECT id, ticker, decimal, status, withdraw_status, withdraw_fee, withdraw_fee_type
+		FROM coins
+		WHERE UPPER(ticker) = UPPER($1)
+	`, strings.TrimSpace(ticker)).Scan(
+		&coin.ID, &coin.Ticker, &coin.Decimal, &coin.Status, &coin.WithdrawStatus, &coin.WithdrawFee, &coin.WithdrawFeeType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &coin, nil
+}
+
+// withdrawalFee returns the coin's withdrawal fee for an amount (fee type 0 = fixed, 1 = percentage)
+func withdrawalFee(amount *big.Rat, coin *withdrawalCoin) *big.Rat {
+	fee := new(big.Rat)
+	if coin.WithdrawFee == nil {
+		return fee
+	}
+	if _, ok := fee.SetString(*coin.WithdrawFee); !ok {
+		return new(big.Rat)
+	}
+	if coin.WithdrawFeeType != nil && *coin.WithdrawFeeType == 1 {
+		fee.Mul(fee, amount)
+		fee.Quo(fee, big.NewRat(100, 1))
+	}
+
+	// Round to the stored scale
+	rounded, _ := new(big.Rat).SetString(fee.FloatString(withdrawalDecimals))
+	return rounded
+}
+
+// parseAmount parses a positive decimal string with at most maxDecimals fraction digits
+func parseAmount(raw string, maxDecimals int) (*big.Rat, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.ContainsAny(raw, "eE/+-") {
+		return nil, errors.New("amount must be a positive decimal number")
+	}
+
+	amount, ok := new(big.Rat).SetString(raw)
+	if !ok || amount.Sign() <= 0 {
+		return nil, errors.New("amount must be a positive decimal number")
+	}
+
+	if i := strings.IndexByte(raw, '.'); i != -1 && len(raw)-i-1 > maxDecimals {
+		return nil, errors.New("amount has too many decimal places")
+	}
+
+	return amount, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// contextUserID returns the authenticated user ID set by UserTokenMiddleware
+func contextUserID(c *gin.Context) (uuid.UUID, bool) {
+	value, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized", "message": "User ID not found in context"})
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(value.(string))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized", "message": "Invalid user ID in context"})
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
@@ -0,0 +1,592 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// getWithdrawalAddressDelay returns how long a new address book entry stays locked
+func getWithdrawalAddressDelay() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("WITHDRAWAL_ADDRESS_DELAY_HOURS"))
+	if err != nil || hours < 0 {
+		return 24 * time.Hour // Default 24 hours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// GetWithdrawalAddresses godoc
+// @Summary List withdrawal addresses
+// @Description List the user's withdrawal address book and whether whitelist-only mode is enabled
+// @Tags Withdrawals
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param coin query string false "Filter by coin ticker"
+// @Success 200 {object} map[string]interface{} "Address book and whitelist status"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/withdrawals/addresses [get]
+func (h *WithdrawalHandler) GetWithdrawalAddresses(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var whitelistEnabled bool
+	err := h.DB.QueryRow("SELECT withdrawal_whitelist_enabled FROM users WHERE id = $1", userID).Scan(&whitelistEnabled)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	query := `
+		SELECT a.id, a.user_id, a.coin_id, c.ticker, a.label, a.address, a.memo, a.status,
+			   a.confirm_expires_at, a.available_at, a.confirmed_at, a.created_at, a.updated_at
+		FROM withdrawal_addresses a
+		JOIN coins c ON a.coin_id = c.id
+		WHERE a.user_id = $1 AND a.status != 'removed'
+		  AND ($2 = '' OR UPPER(c.ticker) = UPPER($2))
+		ORDER BY c.ticker ASC, a.created_at DESC
+	`
+
+	rows, err := h.DB.Query(query, userID, c.Query("coin"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to fetch withdrawal addresses"})
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	addresses := []models.WithdrawalAddress{}
+	for rows.Next() {
+		var a models.WithdrawalAddress
+		if err := rows.Scan(
+			&a.ID, &a.UserID, &a.CoinID, &a.CoinTicker, &a.Label, &a.Address, &a.Memo, &a.Status,
+			&a.ConfirmExpiresAt, &a.AvailableAt, &a.ConfirmedAt, &a.CreatedAt, &a.UpdatedAt,
+		); err != nil {
+			continue
+		}
+		a.Usable = a.Status == "active" && !now.Before(a.AvailableAt)
+		addresses = append(addresses, a)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"whitelist_enabled": whitelistEnabled,
+		"addresses":         addresses,
+	})
+}
+
+// AddWithdrawalAddress godoc
+// @Summary Add a withdrawal address
+// @Description Add an address to the withdrawal address book. Requires a 2fa OTP code (request one with type "2fa"). A confirmation link is emailed and the entry is only usable after the time lock (24 hours by default).
+// @Tags Withdrawals
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.AddWithdrawalAddressRequest true "Address data"
+// @Success 201 {object} map[string]interface{} "Address added, email confirmation pending"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors or invalid OTP"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Address is under compliance review"
+// @Failure 404 {object} map[string]interface{} "Coin not found"
+// @Failure 409 {object} map[string]interface{} "Address already in the address book"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/withdrawals/addresses [post]
+func (h *WithdrawalHandler) AddWithdrawalAddress(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddWithdrawalAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	address := strings.TrimSpace(req.Address)
+	memo := strings.TrimSpace(req.Memo)
+	if strings.ContainsAny(address, " \t\r\n") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_address", "message": "Address must not contain whitespace"})
+		return
+	}
+
+	coin, err := h.getCoinByTicker(req.Coin)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "coin_not_found", "message": "Coin not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve coin"})
+		return
+	}
+
+	screeningCase, err := screenSubject(h.DB, h.Screening, userID, "withdrawal_address", nil, address)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "screening_error", "message": "Failed to screen address"})
+		return
+	}
+	if screeningCase != nil {
+		respondScreeningHold(c)
+		return
+	}
+
+	if err := consumeOTP(h.DB, userID, "2fa", req.Code); err != nil {
+		respondOTPError(c, err)
+		return
+	}
+
+	confirmToken, confirmTokenHash, err := generateCancelToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_generation_error", "message": "Failed to generate confirmation token"})
+		return
+	}
+
+	now := time.Now()
+	delay := getWithdrawalAddressDelay()
+	entry := models.WithdrawalAddress{
+		ID:               uuid.New(),
+		UserID:           userID,
+		CoinID:           coin.ID,
+		CoinTicker:       coin.Ticker,
+		Address:          address,
+		Status:           "pending",
+		ConfirmExpiresAt: now.Add(delay),
+		AvailableAt:      now.Add(delay),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if req.Label != "" {
+		entry.Label = &req.Label
+	}
+	if memo != "" {
+		entry.Memo = &memo
+	}
+	// Give the owner at least a day to find the confirmation email
+	if delay < 24*time.Hour {
+		entry.ConfirmExpiresAt = now.Add(24 * time.Hour)
+	}
+
+	_, err = h.DB.Exec(`
+		INSERT INTO withdrawal_addresses (
+			id, user_id, coin_id, label, address, memo, status, confirm_token_hash,
+			confirm_expires_at, available_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, 'pending', $7, $8, $9, $10, $10)
+	`, entry.ID, userID, coin.ID, entry.Label, entry.Address, entry.Memo, confirmTokenHash,
+		entry.ConfirmExpiresAt, entry.AvailableAt, now)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{"error": "address_exists", "message": "This address is already in your address book"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to save withdrawal address"})
+		return
+	}
+
+	var email, firstName, lastName, username string
+	err = h.DB.QueryRow("SELECT email, first_name, last_name, username FROM users WHERE id = $1", userID).
+		Scan(&email, &firstName, &lastName, &username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to retrieve user"})
+		return
+	}
+
+	emailService := userEmailService(h.DB, userID)
+	if !emailService.IsEnabled() {
+		// SMTP not enabled - return the confirmation token in response (development mode)
+		c.JSON(http.StatusCreated, gin.H{
+			"message":       "Withdrawal address added (SMTP not enabled)",
+			"address":       entry,
+			"confirm_token": confirmToken, // Only in development
+		})
+		return
+	}
+
+	userName := fmt.Sprintf("%s %s", firstName, lastName)
+	if userName == " " {
+		userName = username
+	}
+
+	confirmURL := fmt.Sprintf("%s/confirm-withdrawal-address?token=%s", getFrontendURL(), url.QueryEscape(confirmToken))
+	err = emailService.SendWithdrawalAddressConfirmation(email, userName, coin.Ticker, address, memo, confirmURL,
+		entry.AvailableAt.UTC().Format("2006-01-02 15:04 MST"))
+	if err != nil {
+		fmt.Printf("Failed to send email: %v\n", err)
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Withdrawal address added, but email sending failed. Please contact support.",
+			"error":   "email_send_failed",
+			"address": entry,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Withdrawal address added. Confirm it with the link sent to your email.",
+		"address": entry,
+	})
+}
+
+// ConfirmWithdrawalAddress godoc
+// @Summary Confirm a withdrawal address
+// @Description Confirm a new withdrawal address book entry with the token sent by email. The entry becomes usable once its time lock has passed.
+// @Tags Withdrawals
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Param request body models.ConfirmWithdrawalAddressRequest true "Confirmation token"
+// @Success 200 {object} map[string]interface{} "Address confirmed"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 404 {object} map[string]interface{} "Invalid or expired confirmation token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/withdrawals/addresses/confirm [post]
+func (h *WithdrawalHandler) ConfirmWithdrawalAddress(c *gin.Context) {
+	var req models.ConfirmWithdrawalAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var id uuid.UUID
+	var availableAt time.Time
+	err := h.DB.QueryRow(`
+		UPDATE withdrawal_addresses
+		SET status = 'active', confirmed_at = NOW(), updated_at = NOW()
+		WHERE confirm_token_hash = $1 AND status = 'pending' AND confirm_expires_at > NOW()
+		RETURNING id, available_at
+	`, hashToken(req.Token)).Scan(&id, &availableAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "invalid_confirm_token",
+			"message": "Confirmation link is invalid or has expired",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to confirm withdrawal address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Withdrawal address confirmed",
+		"id":           id,
+		"available_at": availableAt,
+	})
+}
+
+// RemoveWithdrawalAddress godoc
+// @Summary Remove a withdrawal address
+// @Description Remove an entry from the withdrawal address book
+// @Tags Withdrawals
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param id path string true "Address book entry ID"
+// @Success 200 {object} map[string]interface{} "Address removed"
+// @Failure 400 {object} map[string]interface{} "Invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Address not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/withdrawals/addresses/{id} [delete]
+func (h *WithdrawalHandler) RemoveWithdrawalAddress(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id", "message": "Invalid address ID"})
+		return
+	}
+
+	result, err := h.DB.Exec(`
+		UPDATE withdrawal_addresses
+		SET status = 'removed', removed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status != 'removed'
+	`, id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to remove withdrawal address"})
+		return
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "address_not_found", "message": "Withdrawal address not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Withdrawal address removed"})
+}
+
+// SetWithdrawalWhitelist godoc
+// @Summary Enable or disable whitelist-only withdrawals
+// @Description In whitelist-only mode withdrawals are only allowed to confirmed address book entries past their time lock. Enabling takes effect at once and cancels a pending disable. Disabling requires a 2fa OTP code and only takes effect once confirmed with the link sent by email; withdrawals are then locked for the same delay as a new address.
+// @Tags Withdrawals
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Security BearerAuth
+// @Param request body models.SetWithdrawalWhitelistRequest true "Whitelist mode"
+// @Success 200 {object} map[string]interface{} "Whitelist mode updated or disable confirmation sent"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors or invalid OTP"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/withdrawals/whitelist [post]
+func (h *WithdrawalHandler) SetWithdrawalWhitelist(c *gin.Context) {
+	userID, ok := contextUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.SetWithdrawalWhitelistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Turning the protection off must not be possible with a stolen session alone
+	if !req.Enabled {
+		if req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "otp_required", "message": "A 2fa code is required to disable whitelist-only mode"})
+			return
+		}
+		if err := consumeOTP(h.DB, userID, "2fa", req.Code); err != nil {
+			respondOTPError(c, err)
+			return
+		}
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update whitelist mode"})
+		return
+	}
+	defer tx.Rollback()
+
+	var wasEnabled bool
+	var email, firstName, lastName, username string
+	err = tx.QueryRow(`
+		SELECT withdrawal_whitelist_enabled, email, first_name, last_name, username FROM users WHERE id = $1 FOR UPDATE
+	`, userID).Scan(&wasEnabled, &email, &firstName, &lastName, &username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update whitelist mode"})
+		return
+	}
+
+	if req.Enabled || !wasEnabled {
+		_, err = tx.Exec(`
+			UPDATE users
+			SET withdrawal_whitelist_enabled = $1, withdrawal_whitelist_disable_token_hash = NULL,
+			    withdrawal_whitelist_disable_expires_at = NULL, updated_at = NOW()
+			WHERE id = $2
+		`, req.Enabled, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update whitelist mode"})
+			return
+		}
+
+		err = recordAuditEvent(tx, c, auditEvent{
+			ActorID:    &userID,
+			Action:     "user.withdrawal_whitelist",
+			TargetType: "user",
+			TargetID:   userID.String(),
+			Before:     map[string]interface{}{"withdrawal_whitelist_enabled": wasEnabled},
+			After:      map[string]interface{}{"withdrawal_whitelist_enabled": req.Enabled},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update whitelist mode"})
+			return
+		}
+
+		message := "Whitelist-only withdrawals enabled"
+		if !req.Enabled {
+			message = "Whitelist-only withdrawals disabled"
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":           message,
+			"whitelist_enabled": req.Enabled,
+		})
+		return
+	}
+
+	// Disabling waits for the emailed confirmation; a new request replaces the previous link
+	confirmToken, confirmTokenHash, err := generateCancelToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token_generation_error", "message": "Failed to generate confirmation token"})
+		return
+	}
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET withdrawal_whitelist_disable_token_hash = $1, withdrawal_whitelist_disable_expires_at = $2, updated_at = NOW()
+		WHERE id = $3
+	`, confirmTokenHash, expiresAt, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update whitelist mode"})
+		return
+	}
+
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &userID,
+		Action:     "user.withdrawal_whitelist_disable_request",
+		TargetType: "user",
+		TargetID:   userID.String(),
+		Details:    map[string]interface{}{"expires_at": expiresAt},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to update whitelist mode"})
+		return
+	}
+
+	emailService := userEmailService(h.DB, userID)
+	if !emailService.IsEnabled() {
+		// SMTP not enabled - return the confirmation token in response (development mode)
+		c.JSON(http.StatusOK, gin.H{
+			"message":           "Whitelist disable requested (SMTP not enabled)",
+			"whitelist_enabled": true,
+			"confirm_token":     confirmToken, // Only in development
+			"expires_at":        expiresAt,
+		})
+		return
+	}
+
+	userName := fmt.Sprintf("%s %s", firstName, lastName)
+	if userName == " " {
+		userName = username
+	}
+
+	confirmURL := fmt.Sprintf("%s/confirm-withdrawal-whitelist-disable?token=%s", getFrontendURL(), url.QueryEscape(confirmToken))
+	err = emailService.SendWithdrawalWhitelistDisableConfirmation(email, userName, confirmURL,
+		expiresAt.UTC().Format("2006-01-02 15:04 MST"), int(getWithdrawalAddressDelay().Hours()))
+	if err != nil {
+		fmt.Printf("Failed to send email: %v\n", err)
+		c.JSON(http.StatusOK, gin.H{
+			"message":           "Whitelist disable requested, but email sending failed. Please contact support.",
+			"error":             "email_send_failed",
+			"whitelist_enabled": true,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Confirm disabling whitelist-only withdrawals with the link sent to your email.",
+		"whitelist_enabled": true,
+		"expires_at":        expiresAt,
+	})
+}
+
+// ConfirmWithdrawalWhitelistDisable godoc
+// @Summary Confirm disabling whitelist-only withdrawals
+// @Description Turn whitelist-only mode off with the token sent by email. Withdrawals are locked for the same delay as a new address book entry.
+// @Tags Withdrawals
+// @Accept json
+// @Produce json
+// @Security BackendSecret
+// @Param request body models.ConfirmWithdrawalAddressRequest true "Confirmation token"
+// @Success 200 {object} map[string]interface{} "Whitelist-only mode disabled"
+// @Failure 400 {object} map[string]interface{} "Bad request - validation errors"
+// @Failure 404 {object} map[string]interface{} "Invalid or expired confirmation token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /api/v1/withdrawals/whitelist/confirm [post]
+func (h *WithdrawalHandler) ConfirmWithdrawalWhitelistDisable(c *gin.Context) {
+	var req models.ConfirmWithdrawalAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_failed",
+			"message": "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to disable whitelist mode"})
+		return
+	}
+	defer tx.Rollback()
+
+	lockedUntil := time.Now().Add(getWithdrawalAddressDelay())
+	var userID uuid.UUID
+	err = tx.QueryRow(`
+		UPDATE users
+		SET withdrawal_whitelist_enabled = FALSE, withdrawal_whitelist_disable_token_hash = NULL,
+		    withdrawal_whitelist_disable_expires_at = NULL,
+		    withdrawals_locked_until = GREATEST(COALESCE(withdrawals_locked_until, $2), $2), updated_at = NOW()
+		WHERE withdrawal_whitelist_disable_token_hash = $1 AND withdrawal_whitelist_disable_expires_at > NOW()
+		  AND withdrawal_whitelist_enabled = TRUE
+		RETURNING id
+	`, hashToken(req.Token), lockedUntil).Scan(&userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "invalid_confirm_token",
+			"message": "Confirmation link is invalid or has expired",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to disable whitelist mode"})
+		return
+	}
+
+	// The link was sent to the account's address, so its owner is the actor
+	err = recordAuditEvent(tx, c, auditEvent{
+		ActorID:    &userID,
+		Action:     "user.withdrawal_whitelist",
+		TargetType: "user",
+		TargetID:   userID.String(),
+		Before:     map[string]interface{}{"withdrawal_whitelist_enabled": true},
+		After:      map[string]interface{}{"withdrawal_whitelist_enabled": false, "withdrawals_locked_until": lockedUntil},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to record audit event"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to disable whitelist mode"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                  "Whitelist-only withdrawals disabled",
+		"whitelist_enabled":        false,
+		"withdrawals_locked_until": lockedUntil,
+	})
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/models"
+)
+
+// apiClientSecret is one accepted secret hash for a client
+type apiClientSecret struct {
+	hash      []byte
+	expiresAt *time.Time // nil for the current secret
+}
+
+// apiClientEntry is a cached active API client
+type apiClientEntry struct {
+	name    string
+	secrets []apiClientSecret
+}
+
+// APIClientRegistry authenticates backend secrets against the api_clients table.
+// Active clients are cached and reloaded periodically, so a revoked or rotated
+// secret stops working within the cache TTL.
+type APIClientRegistry struct {
+	db       *sql.DB
+	ttl      time.Duration
+	mu       sync.RWMutex
+	clients  []apiClientEntry
+	loadedAt time.Time
+}
+
+// NewAPIClientRegistry creates a registry backed by the api_clients table
+func NewAPIClientRegistry(db *sql.DB) *APIClientRegistry {
+	return &APIClientRegistry{
+		db:  db,
+		ttl: getAPIClientsCacheTTL(),
+	}
+}
+
+// getAPIClientsCacheTTL retrieves how long the client list is cached
+func getAPIClientsCacheTTL() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("API_CLIENTS_CACHE_SECONDS"))
+	if err != nil || seconds < 0 {
+		return 30 * time.Second // Default 30 seconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Authenticate returns the name of the client owning the secret.
+// Every accepted secret is compared in constant time.
+func (r *APIClientRegistry) Authenticate(secret string) (string, bool) {
+	clients := r.activeClients()
+
+	presented, err := hex.DecodeString(models.HashClientSecret(secret))
+	if err != nil {
+		return "", false
+	}
+
+	now := time.Now()
+	matched := ""
+	for _, client := range clients {
+		for _, s := range client.secrets {
+			if s.expiresAt != nil && now.After(*s.expiresAt) {
+				continue
+			}
+			if subtle.ConstantTimeCompare(presented, s.hash) == 1 {
+				matched = client.name
+			}
+		}
+	}
+
+	return matched, matched != ""
+}
+
+// HasClients reports whether any active client is configured
+func (r *APIClientRegistry) HasClients() bool {
+	return len(r.activeClients()) > 0
+}
+
+// activeClients returns the cached clients, reloading them when the cache is stale
+func (r *APIClientRegistry) activeClients() []apiClientEntry {
+	r.mu.RLock()
+	if !r.loadedAt.IsZero() && time.Since(r.loadedAt) < r.ttl {
+		clients := r.clients
+		r.mu.RUnlock()
+		return clients
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Another request may have reloaded while we waited for the lock
+	if !r.loadedAt.IsZero() && time.Since(r.loadedAt) < r.ttl {
+		return r.clients
+	}
+
+	clients, err := r.load()
+	if err != nil {
+		// Keep serving the last known list rather than locking every client out
+		log.Printf("Failed to load API clients: %v", err)
+		return r.clients
+	}
+
+	r.clients = clients
+	r.loadedAt = time.Now()
+	return r.clients
+}
+
+// load reads active clients and their accepted secrets from the database
+func (r *APIClientRegistry) load() ([]apiClientEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT name, secret_hash, previous_secret_hash, previous_secret_expires_at
+		FROM api_clients
+		WHERE status = 'active'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []apiClientEntry{}
+	for rows.Next() {
+		var name, secretHash string
+		var previousHash sql.NullString
+		var previousExpiresAt sql.NullTime
+		if err := rows.Scan(&name, &secretHash, &previousHash, &previousExpiresAt); err != nil {
+			return nil, err
+		}
+
+		entry := apiClientEntry{name: name}
+		if hash, err := hex.DecodeString(secretHash); err == nil {
+			entry.secrets = append(entry.secrets, apiClientSecret{hash: hash})
+		}
+		if previousHash.Valid && previousExpiresAt.Valid {
+			if hash, err := hex.DecodeString(previousHash.String); err == nil {
+				expiresAt := previousExpiresAt.Time
+				entry.secrets = append(entry.secrets, apiClientSecret{hash: hash, expiresAt: &expiresAt})
+			}
+		}
+		clients = append(clients, entry)
+	}
+
+	return clients, rows.Err()
+}
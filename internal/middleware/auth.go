@@ -2,26 +2,17 @@ package middleware
 
 import (
 	"net/http"
-	"os"
 
 	"github.com/Bixor-Engine/backend/internal/models"
 	"github.com/gin-gonic/gin"
 )
 
-// BackendSecretMiddleware validates the backend secret from request header
-// This is used to protect API routes that should only be accessible from the frontend
-func BackendSecretMiddleware() gin.HandlerFunc {
+// BackendSecretMiddleware validates the backend secret from request header against the
+// registered API clients. This is used to protect API routes that should only be
+// accessible from our own clients (web, mobile, admin panel). The matching client
+// name is stored in the context as "apiClient" for logging.
+func BackendSecretMiddleware(clients *APIClientRegistry) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		backendSecret := os.Getenv("BACKEND_SECRET")
-		if backendSecret == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "backend_secret_not_configured",
-				"message": "Backend secret is not configured. Please set BACKEND_SECRET in environment variables.",
-			})
-			c.Abort()
-			return
-		}
-
 		// Get secret from header (X-Backend-Secret or X-API-Secret)
 		secret := c.GetHeader("X-Backend-Secret")
 		if secret == "" {
@@ -37,7 +28,17 @@ func BackendSecretMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		if secret != backendSecret {
+		clientName, ok := clients.Authenticate(secret)
+		if !ok {
+			if !clients.HasClients() {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "api_clients_not_configured",
+					"message": "No API clients are configured. Create one with 'bixor client create <name>'.",
+				})
+				c.Abort()
+				return
+			}
+
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "invalid_backend_secret",
 				"message": "Invalid backend secret",
@@ -46,6 +47,8 @@ func BackendSecretMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		c.Set("apiClient", clientName)
+
 		c.Next()
 	}
 }
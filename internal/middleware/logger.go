@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger logs requests like gin's default logger, adding the API client
+// that authenticated the request (or "-" for public routes) and the request ID
+func RequestLogger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		client := "-"
+		if name, ok := param.Keys["apiClient"].(string); ok && name != "" {
+			client = name
+		}
+
+		requestID, _ := param.Keys["requestID"].(string)
+
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-10s | %-7s %#v | %s\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency.Round(time.Microsecond),
+			param.ClientIP,
+			client,
+			param.Method,
+			param.Path,
+			requestID,
+			param.ErrorMessage,
+		)
+	})
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rolePermissions lists the staff roles granted each admin permission
+var rolePermissions = map[string][]string{
+	"users.view":        {"superadmin", "admin", "compliance", "support"},
+	"users.status":      {"superadmin", "admin"},               // Activate, deactivate, suspend, ban, unlock
+	"users.freeze":      {"superadmin", "admin", "compliance"}, // Freeze and unfreeze
+	"users.credentials": {"superadmin", "admin", "support"},    // Forced password reset, 2FA reset
+	"users.delete":      {"superadmin"},                        // Delete test accounts
+	"coins.manage":      {"superadmin", "admin"},               // Add, edit, enable and disable coins
+	"fees.manage":       {"superadmin", "admin"},               // Fee tiers, schedules, user overrides and fee revenue
+}
+
+// HasPermission reports whether a role is granted a permission
+func HasPermission(role, permission string) bool {
+	for _, allowed := range rolePermissions[permission] {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission allows the request only if the authenticated user's role is granted the
+// permission. Must run after UserTokenMiddleware.
+func RequirePermission(db *sql.DB, permission string) gin.HandlerFunc {
+	return RequireRole(db, rolePermissions[permission]...)
+}
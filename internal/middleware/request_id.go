@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestID tags each request with an ID, taken from a well-formed X-Request-ID header
+// (e.g. set by a proxy) or generated. The ID is returned in the X-Request-ID response
+// header and stored in the context as "requestID" for logs and audit events.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		c.Set("requestID", requestID)
+		c.Header("X-Request-ID", requestID)
+		c.Next()
+	}
+}
+
+// validRequestID accepts up to 64 letters, digits, dashes, underscores and dots
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
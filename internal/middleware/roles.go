@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole allows the request only if the authenticated user has one of the given roles.
+// The role is read from the database rather than the JWT, so a demoted staff member loses
+// access immediately. Must run after UserTokenMiddleware.
+func RequireRole(db *sql.DB, roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized", "message": "User ID not found in context"})
+			c.Abort()
+			return
+		}
+
+		var role, status string
+		err := db.QueryRow("SELECT role, status FROM users WHERE id = $1 AND deleted_at IS NULL", userID).Scan(&role, &status)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized", "message": "User not found"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database_error", "message": "Failed to check permissions"})
+			c.Abort()
+			return
+		}
+
+		// Same account states that may log in
+		if !allowed[role] || (status != "active" && status != "pending") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": "You do not have permission to access this resource"})
+			c.Abort()
+			return
+		}
+
+		c.Set("role", role)
+
+		c.Next()
+	}
+}
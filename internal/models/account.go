@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CloseAccountRequest represents the request payload for closing the caller's account
+type CloseAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required,len=6,numeric"` // 2FA OTP code
+	Reason   string `json:"reason" binding:"omitempty,max=500"`
+}
+
+// DataExportRequest represents the request payload for exporting the caller's personal data
+type DataExportRequest struct {
+	Format string `json:"format" binding:"omitempty,oneof=json csv"` // Defaults to json
+}
+
+// DataExport represents an archive of a user's personal data
+type DataExport struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	Format      string     `json:"format" db:"format"` // json, csv
+	Status      string     `json:"status" db:"status"` // pending, processing, ready, failed, expired
+	StorageKey  *string    `json:"-" db:"storage_key"`
+	SizeBytes   *int64     `json:"size_bytes,omitempty" db:"size_bytes"`
+	SHA256      *string    `json:"sha256,omitempty" db:"sha256"`
+	Error       *string    `json:"error,omitempty" db:"error"`
+	DownloadURL string     `json:"download_url,omitempty" db:"-"` // Signed, short-lived; only when ready
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminUserListItem represents a user in the admin user search
+type AdminUserListItem struct {
+	ID           uuid.UUID  `json:"id"`
+	FirstName    string     `json:"first_name"`
+	LastName     string     `json:"last_name"`
+	Username     string     `json:"username"`
+	Email        string     `json:"email"`
+	EmailStatus  bool       `json:"email_status"`
+	Role         string     `json:"role"`
+	Status       string     `json:"status"`
+	KYCStatus    string     `json:"kyc_status"`
+	TwoFAEnabled bool       `json:"twofa_enabled"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+}
+
+// AdminWallet represents a wallet in the admin user detail view
+type AdminWallet struct {
+	CoinID        int       `json:"coin_id"`
+	Ticker        string    `json:"ticker"`
+	Balance       string    `json:"balance"`
+	FrozenBalance string    `json:"frozen_balance"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// LoginEvent represents a recorded sign-in
+type LoginEvent struct {
+	ID        uuid.UUID `json:"id"`
+	IPAddress *string   `json:"ip_address,omitempty"`
+	UserAgent *string   `json:"user_agent,omitempty"`
+	NewDevice bool      `json:"new_device"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminUserStatusRequest represents the request payload for changing a user's account status
+type AdminUserStatusRequest struct {
+	Action string `json:"action" binding:"required,oneof=activate deactivate suspend ban freeze unlock"`
+	Reason string `json:"reason" binding:"required,min=5,max=1000"`
+}
+
+// AdminUserFreezeRequest represents the request payload for freezing or unfreezing an account
+type AdminUserFreezeRequest struct {
+	Action string `json:"action" binding:"required,oneof=freeze unfreeze"`
+	Reason string `json:"reason" binding:"required,min=5,max=1000"`
+}
+
+// AdminTestUserCleanupRequest represents the request payload for deleting test accounts
+type AdminTestUserCleanupRequest struct {
+	Reason string `json:"reason" binding:"required,min=5,max=1000"`
+	DryRun bool   `json:"dry_run"` // Only list the accounts that would be deleted
+}
+
+// AdminUserActionRequest represents the request payload for an admin action that needs a reason
+type AdminUserActionRequest struct {
+	Reason string `json:"reason" binding:"required,min=5,max=1000"`
+}
+
+// CreateCoinRequest represents the request payload for adding a coin
+type CreateCoinRequest struct {
+	Name            string   `json:"name" binding:"required,max=255"`
+	Ticker          string   `json:"ticker" binding:"required,alphanum,max=10"`
+	Decimal         *int     `json:"decimal" binding:"required"`
+	PriceDecimal    *int     `json:"price_decimal" binding:"required"`
+	Logo            *string  `json:"logo"`
+	Price           string   `json:"price"`
+	DepositGateway  []string `json:"deposit_gateway"`
+	WithdrawGateway []string `json:"withdraw_gateway"`
+	DepositFee      *string  `json:"deposit_fee"`
+	WithdrawFee     *string  `json:"withdraw_fee"`
+	DepositFeeType  *int     `json:"deposit_fee_type"`
+	WithdrawFeeType *int     `json:"withdraw_fee_type"`
+	Confirmation    *int     `json:"confirmation"`
+	Status          *int     `json:"status"`
+	DepositStatus   *int     `json:"deposit_status"`
+	WithdrawStatus  *int     `json:"withdraw_status"`
+	Website         *string  `json:"website"`
+	Explorer        *string  `json:"explorer"`
+	ExplorerTx      *string  `json:"explorer_tx"`
+	ExplorerAddress *string  `json:"explorer_address"`
+}
+
+// UpdateCoinRequest represents the request payload for changing a coin; omitted fields are kept
+type UpdateCoinRequest struct {
+	Name            *string  `json:"name" binding:"omitempty,max=255"`
+	Ticker          *string  `json:"ticker" binding:"omitempty,alphanum,max=10"`
+	Decimal         *int     `json:"decimal"`
+	PriceDecimal    *int     `json:"price_decimal"`
+	Logo            *string  `json:"logo"`
+	Price           *string  `json:"price"`
+	DepositGateway  []string `json:"deposit_gateway"`
+	WithdrawGateway []string `json:"withdraw_gateway"`
+	DepositFee      *string  `json:"deposit_fee"`
+	WithdrawFee     *string  `json:"withdraw_fee"`
+	DepositFeeType  *int     `json:"deposit_fee_type"`
+	WithdrawFeeType *int     `json:"withdraw_fee_type"`
+	Confirmation    *int     `json:"confirmation"`
+	Website         *string  `json:"website"`
+	Explorer        *string  `json:"explorer"`
+	ExplorerTx      *string  `json:"explorer_tx"`
+	ExplorerAddress *string  `json:"explorer_address"`
+}
+
+// CoinStatusRequest represents the request payload for enabling or disabling a coin, deposits or withdrawals
+type CoinStatusRequest struct {
+	Status         *int `json:"status" binding:"omitempty,oneof=0 1"`
+	DepositStatus  *int `json:"deposit_status" binding:"omitempty,oneof=0 1"`
+	WithdrawStatus *int `json:"withdraw_status" binding:"omitempty,oneof=0 1"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// clientSecretPrefix makes API client secrets easy to recognize in logs and secret scanners
+const clientSecretPrefix = "bxs_"
+
+// GenerateClientSecret creates a new random API client secret
+func GenerateClientSecret() (string, error) {
+	b, err := generateRandomBytes(32)
+	if err != nil {
+		return "", err
+	}
+	return clientSecretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashClientSecret returns the hex-encoded SHA-256 hash stored for an API client secret.
+// Secrets are long random values, so a fast hash is sufficient.
+func HashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent represents a recorded staff action or security relevant change. Events are
+// append-only and chained: each hash covers the event and the hash of the event before it.
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	ActorRole  *string         `json:"actor_role,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Reason     *string         `json:"reason,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"` // Changed fields before the action
+	After      json.RawMessage `json:"after,omitempty"`  // Changed fields after the action
+	IPAddress  *string         `json:"ip_address,omitempty"`
+	UserAgent  *string         `json:"user_agent,omitempty"`
+	RequestID  *string         `json:"request_id,omitempty"`
+	PrevHash   *string         `json:"prev_hash,omitempty"`
+	Hash       *string         `json:"hash,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// auditHashInput fixes the fields and their order covered by an audit event hash
+type auditHashInput struct {
+	PrevHash   string          `json:"prev_hash"`
+	CreatedAt  string          `json:"created_at"`
+	ActorID    *uuid.UUID      `json:"actor_id"`
+	ActorRole  *string         `json:"actor_role"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Reason     *string         `json:"reason"`
+	Details    json.RawMessage `json:"details"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	IPAddress  *string         `json:"ip_address"`
+	UserAgent  *string         `json:"user_agent"`
+	RequestID  *string         `json:"request_id"`
+}
+
+// AuditEventHash returns the hex-encoded SHA-256 hash of an event chained to prevHash
+// (empty for the first event). JSON fields are hashed in canonical form, so the hash is
+// the same before insertion and after reading the event back from a JSONB column.
+// CreatedAt must be truncated to microseconds, the precision of the database.
+func AuditEventHash(prevHash string, event *AuditEvent) (string, error) {
+	input := auditHashInput{
+		PrevHash:   prevHash,
+		CreatedAt:  event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ActorID:    event.ActorID,
+		ActorRole:  event.ActorRole,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Reason:     event.Reason,
+		IPAddress:  event.IPAddress,
+		UserAgent:  event.UserAgent,
+		RequestID:  event.RequestID,
+	}
+
+	var err error
+	if input.Details, err = CanonicalJSON(event.Details); err != nil {
+		return "", err
+	}
+	if input.Before, err = CanonicalJSON(event.Before); err != nil {
+		return "", err
+	}
+	if input.After, err = CanonicalJSON(event.After); err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CanonicalJSON re-encodes a JSON value with sorted object keys and no whitespace.
+// Empty input and null return nil.
+func CanonicalJSON(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
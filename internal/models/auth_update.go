@@ -27,3 +27,36 @@ type ToggleTwoFARequest struct {
 	Enable bool   `json:"enable"`
 	Code   string `json:"code" binding:"required,len=6,numeric"` // OTP to verify action
 }
+
+// ChangeEmailRequest represents the request payload for starting an email address change
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"` // Current password to authorize the change
+}
+
+// ConfirmEmailChangeRequest represents the request payload for confirming a new email address
+type ConfirmEmailChangeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"` // OTP sent to the new address
+}
+
+// CancelEmailChangeRequest represents the request payload for cancelling an email change
+type CancelEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"` // Token from the link sent to the old address
+}
+
+// SetAntiPhishingCodeRequest represents the request payload for setting the anti-phishing code
+type SetAntiPhishingCodeRequest struct {
+	AntiPhishingCode string `json:"anti_phishing_code" binding:"omitempty,min=4,max=32"` // Empty to remove
+}
+
+// ForgotPasswordRequest represents the request payload for requesting a password reset code
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest represents the request payload for setting a new password with a reset code
+type ResetPasswordRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	Code        string `json:"code" binding:"required,len=6,numeric"` // Code from the password reset email
+	NewPassword string `json:"new_password" binding:"required,min=8,max=128"`
+}
@@ -38,6 +38,7 @@ type User struct {
 	PhoneNumber  *string    `json:"phone_number,omitempty" db:"phone_number"`
 	PhoneStatus  bool       `json:"phone_status" db:"phone_status"`
 	ReferredBy   *uuid.UUID `json:"referred_by,omitempty" db:"referred_by"`
+	ReferralCode string     `json:"referral_code" db:"referral_code"`
 	Address      *string    `json:"address,omitempty" db:"address"`
 	City         *string    `json:"city,omitempty" db:"city"`
 	Country      *string    `json:"country,omitempty" db:"country"`
@@ -45,6 +46,7 @@ type User struct {
 	Status       string     `json:"status" db:"status"`
 	KYCStatus    string     `json:"kyc_status" db:"kyc_status"`
 	TwoFAEnabled bool       `json:"twofa_enabled" db:"twofa_enabled"`
+	PasswordResetRequired bool `json:"password_reset_required" db:"password_reset_required"`
 	LastLoginAt  *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
 	LastLoginIP  *string    `json:"last_login_ip,omitempty" db:"last_login_ip"`
 	DeviceInfo   *string    `json:"device_info,omitempty" db:"device_info"` // JSONB as string
@@ -58,18 +60,19 @@ type User struct {
 
 // RegisterRequest represents the request payload for user registration
 type RegisterRequest struct {
-	FirstName   string  `json:"first_name" binding:"required,min=2,max=50"`
-	LastName    string  `json:"last_name" binding:"required,min=2,max=50"`
-	Username    string  `json:"username" binding:"required,min=3,max=30,alphanum"`
-	Email       string  `json:"email" binding:"required,email"`
-	Password    string  `json:"password" binding:"required,min=8,max=128"`
-	PhoneNumber *string `json:"phone_number,omitempty"`
-	ReferredBy  *string `json:"referred_by,omitempty"` // UUID as string in request
-	Address     *string `json:"address,omitempty"`
-	City        *string `json:"city,omitempty"`
-	Country     *string `json:"country,omitempty"`
-	Language    *string `json:"language,omitempty"`
-	Timezone    *string `json:"timezone,omitempty"`
+	FirstName    string  `json:"first_name" binding:"required,min=2,max=50"`
+	LastName     string  `json:"last_name" binding:"required,min=2,max=50"`
+	Username     string  `json:"username" binding:"required,min=3,max=30,alphanum"`
+	Email        string  `json:"email" binding:"required,email"`
+	Password     string  `json:"password" binding:"required,min=8,max=128"`
+	PhoneNumber  *string `json:"phone_number,omitempty"`
+	ReferralCode *string `json:"referral_code,omitempty"` // Referrer's referral code
+	ReferredBy   *string `json:"referred_by,omitempty"`   // Deprecated: referrer's UUID, use referral_code
+	Address      *string `json:"address,omitempty"`
+	City         *string `json:"city,omitempty"`
+	Country      *string `json:"country,omitempty"`
+	Language     *string `json:"language,omitempty"`
+	Timezone     *string `json:"timezone,omitempty"`
 }
 
 // UserResponse represents the response payload for user data (excluding sensitive fields)
@@ -89,6 +92,7 @@ type UserResponse struct {
 	Status       string     `json:"status"`
 	KYCStatus    string     `json:"kyc_status"`
 	TwoFAEnabled bool       `json:"twofa_enabled"`
+	ReferralCode string     `json:"referral_code"`
 	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
 	Language     string     `json:"language"`
 	Timezone     string     `json:"timezone"`
@@ -108,21 +112,6 @@ type SystemHealth struct {
 	Environment    string    `json:"environment" db:"environment"`
 }
 
-// Market represents a trading market/pair
-type Market struct {
-	ID                uuid.UUID `json:"id" db:"id"`
-	Symbol            string    `json:"symbol" db:"symbol"`
-	BaseCurrency      string    `json:"base_currency" db:"base_currency"`
-	QuoteCurrency     string    `json:"quote_currency" db:"quote_currency"`
-	IsActive          bool      `json:"is_active" db:"is_active"`
-	MinQuantity       string    `json:"min_quantity" db:"min_quantity"` // Use string for precise decimal handling
-	MaxQuantity       *string   `json:"max_quantity,omitempty" db:"max_quantity"`
-	PricePrecision    int       `json:"price_precision" db:"price_precision"`
-	QuantityPrecision int       `json:"quantity_precision" db:"quantity_precision"`
-	CreatedAt         time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
-}
-
 // LoginRequest represents the request payload for user login
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -157,7 +146,8 @@ type OTP struct {
 
 // RequestOTPRequest represents the request payload for requesting an OTP
 type RequestOTPRequest struct {
-	Type string `json:"type" binding:"required,oneof=email-verification password-reset 2fa phone-verification"`
+	Type    string `json:"type" binding:"required,oneof=email-verification password-reset 2fa phone-verification"`
+	Channel string `json:"channel,omitempty" binding:"omitempty,oneof=email sms"` // Delivery channel for 2fa codes (default email)
 }
 
 // VerifyOTPRequest represents the request payload for verifying an OTP
@@ -240,7 +230,60 @@ type Transaction struct {
 	Description   *string   `json:"description,omitempty" db:"description"`
 	ReferenceID   *string   `json:"reference_id,omitempty" db:"reference_id"`
 	PaymentMethod *int      `json:"payment_method,omitempty" db:"payment_method"` // e.g. 1=coinpayments, etc.
+	Address       *string   `json:"address,omitempty" db:"address"`               // Withdrawal destination
+	Memo          *string   `json:"memo,omitempty" db:"memo"`
 	Status        string    `json:"status" db:"status"`
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// EmailChange represents a request to change a user's email address
+type EmailChange struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
+	OldEmail        string     `json:"old_email" db:"old_email"`
+	OldEmailStatus  bool       `json:"old_email_status" db:"old_email_status"`
+	NewEmail        string     `json:"new_email" db:"new_email"`
+	CancelTokenHash string     `json:"-" db:"cancel_token_hash"`
+	Status          string     `json:"status" db:"status"` // pending, confirmed, cancelled, expired
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	CancelUntil     time.Time  `json:"cancel_until" db:"cancel_until"`
+	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty" db:"confirmed_at"`
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// APIClient represents a named client (web, mobile, admin panel) allowed to call protected routes
+type APIClient struct {
+	ID                      uuid.UUID  `json:"id" db:"id"`
+	Name                    string     `json:"name" db:"name"`
+	Description             *string    `json:"description,omitempty" db:"description"`
+	SecretHash              string     `json:"-" db:"secret_hash"`
+	PreviousSecretHash      *string    `json:"-" db:"previous_secret_hash"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty" db:"previous_secret_expires_at"`
+	Status                  string     `json:"status" db:"status"` // active, revoked
+	RotatedAt               *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	RevokedAt               *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt               time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// WithdrawalAddress represents an entry of a user's withdrawal address book
+type WithdrawalAddress struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	UserID           uuid.UUID  `json:"user_id" db:"user_id"`
+	CoinID           int        `json:"coin_id" db:"coin_id"`
+	CoinTicker       string     `json:"coin_ticker" db:"coin_ticker"`
+	Label            *string    `json:"label,omitempty" db:"label"`
+	Address          string     `json:"address" db:"address"`
+	Memo             *string    `json:"memo,omitempty" db:"memo"`
+	Status           string     `json:"status" db:"status"` // pending, active, removed
+	ConfirmTokenHash string     `json:"-" db:"confirm_token_hash"`
+	ConfirmExpiresAt time.Time  `json:"confirm_expires_at" db:"confirm_expires_at"`
+	AvailableAt      time.Time  `json:"available_at" db:"available_at"`
+	Usable           bool       `json:"usable"` // Confirmed and past the time lock
+	ConfirmedAt      *time.Time `json:"confirmed_at,omitempty" db:"confirmed_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
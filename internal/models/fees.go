@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FeeTier is a VIP tier reached by rolling 30-day trading volume
+type FeeTier struct {
+	Tier         int       `json:"tier"`
+	Name         string    `json:"name"`
+	MinVolumeUSD string    `json:"min_volume_usd"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// FeeSchedule holds the maker and taker rates of one tier on one market
+type FeeSchedule struct {
+	MarketID     int       `json:"market_id"`
+	Market       string    `json:"market"`
+	Tier         int       `json:"tier"`
+	MakerFeeRate string    `json:"maker_fee_rate"` // Negative for a rebate
+	TakerFeeRate string    `json:"taker_fee_rate"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// UserFeeOverride holds rates set by an admin for one user, on one market or on all markets
+type UserFeeOverride struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	MarketID     *int       `json:"market_id"` // Nil for all markets
+	Market       *string    `json:"market"`
+	MakerFeeRate string     `json:"maker_fee_rate"`
+	TakerFeeRate string     `json:"taker_fee_rate"`
+	Reason       string     `json:"reason"`
+	CreatedBy    *uuid.UUID `json:"created_by"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// FeeTierListResponse represents the response for listing fee tiers
+type FeeTierListResponse struct {
+	Tiers []FeeTier `json:"tiers"`
+	Total int       `json:"total"`
+}
+
+// FeeScheduleListResponse represents the response for listing fee schedules
+type FeeScheduleListResponse struct {
+	Schedules []FeeSchedule `json:"schedules"`
+	Total     int           `json:"total"`
+}
+
+// UserFeeOverrideListResponse represents the response for listing fee overrides
+type UserFeeOverrideListResponse struct {
+	Overrides []UserFeeOverride `json:"overrides"`
+	Total     int               `json:"total"`
+}
+
+// MarketFeeRate is the rate a user pays on one market
+type MarketFeeRate struct {
+	Market       string `json:"market"`
+	MakerFeeRate string `json:"maker_fee_rate"`
+	TakerFeeRate string `json:"taker_fee_rate"`
+	Source       string `json:"source"` // override, schedule, market
+}
+
+// TradingFeesResponse represents a user's fee tier and the rates they pay
+type TradingFeesResponse struct {
+	Tier         int             `json:"tier"`
+	TierName     string          `json:"tier_name"`
+	Volume30dUSD string          `json:"volume_30d_usd"`
+	NextTier     *FeeTier        `json:"next_tier,omitempty"` // Nil at the highest tier
+	UpdatedAt    *time.Time      `json:"updated_at,omitempty"`
+	Markets      []MarketFeeRate `json:"markets"`
+}
+
+// FeeRevenueBalance is the fee revenue collected in one coin
+type FeeRevenueBalance struct {
+	CoinID              int       `json:"coin_id"`
+	Ticker              string    `json:"ticker"`
+	Balance             string    `json:"balance"`              // Fees collected minus rebates and referral commissions paid
+	Fees                string    `json:"fees"`                 // Fees collected in the last days
+	Rebates             string    `json:"rebates"`              // Rebates paid in the last days, as a positive amount
+	ReferralCommissions string    `json:"referral_commissions"` // Referral commissions paid in the last days, as a positive amount
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// FeeRevenueResponse represents the fee revenue account
+type FeeRevenueResponse struct {
+	Days     int                 `json:"days"` // Period of the fees, rebates and commissions totals
+	Balances []FeeRevenueBalance `json:"balances"`
+}
+
+// UpsertFeeTierRequest represents the request payload for adding or changing a fee tier
+type UpsertFeeTierRequest struct {
+	Name         string `json:"name" binding:"required,max=100"`
+	MinVolumeUSD string `json:"min_volume_usd" binding:"required"`
+}
+
+// UpsertFeeScheduleRequest represents the request payload for setting a tier's rates on a market
+type UpsertFeeScheduleRequest struct {
+	MakerFeeRate string `json:"maker_fee_rate" binding:"required"`
+	TakerFeeRate string `json:"taker_fee_rate" binding:"required"`
+}
+
+// UpsertUserFeeOverrideRequest represents the request payload for setting a user's rates. An
+// empty market applies the rates to all markets.
+type UpsertUserFeeOverrideRequest struct {
+	UserID       uuid.UUID  `json:"user_id" binding:"required"`
+	Market       string     `json:"market" binding:"omitempty,max=20"`
+	MakerFeeRate string     `json:"maker_fee_rate" binding:"required"`
+	TakerFeeRate string     `json:"taker_fee_rate" binding:"required"`
+	Reason       string     `json:"reason" binding:"required,min=5,max=1000"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
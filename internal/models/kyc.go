@@ -0,0 +1,87 @@
+package models
+
+import (
+	"mime/multipart"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KYCSubmission represents identity data submitted by a user for review
+type KYCSubmission struct {
+	ID              uuid.UUID     `json:"id" db:"id"`
+	UserID          uuid.UUID     `json:"user_id" db:"user_id"`
+	Status          string        `json:"status" db:"status"` // pending, approved, rejected
+	FirstName       string        `json:"first_name" db:"first_name"`
+	LastName        string        `json:"last_name" db:"last_name"`
+	DateOfBirth     string        `json:"date_of_birth" db:"date_of_birth"` // YYYY-MM-DD
+	Nationality     string        `json:"nationality" db:"nationality"`
+	Country         string        `json:"country" db:"country"`
+	City            string        `json:"city" db:"city"`
+	Address         string        `json:"address" db:"address"`
+	PostalCode      *string       `json:"postal_code,omitempty" db:"postal_code"`
+	DocumentType    string        `json:"document_type" db:"document_type"`
+	DocumentNumber  string        `json:"document_number,omitempty" db:"document_number"` // Only shown to reviewers
+	ReviewedBy      *uuid.UUID    `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt      *time.Time    `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	RejectionReason *string       `json:"rejection_reason,omitempty" db:"rejection_reason"`
+	Documents       []KYCDocument `json:"documents,omitempty"`
+	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// KYCDocument represents a file attached to a KYC submission
+type KYCDocument struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	SubmissionID uuid.UUID `json:"submission_id" db:"submission_id"`
+	Kind         string    `json:"kind" db:"kind"` // document_front, document_back, selfie, proof_of_address
+	StorageKey   *string   `json:"-" db:"storage_key"`
+	FilePath     *string   `json:"-" db:"file_path"` // Legacy uploads below KYC_UPLOAD_DIR
+	FileName     string    `json:"file_name" db:"file_name"`
+	ContentType  string    `json:"content_type" db:"content_type"`
+	SizeBytes    int64     `json:"size_bytes" db:"size_bytes"`
+	SHA256       string    `json:"sha256" db:"sha256"`
+	DownloadURL  string    `json:"download_url,omitempty" db:"-"` // Signed, short-lived; reviewers only
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// KYCHistoryEntry represents one immutable KYC status change
+type KYCHistoryEntry struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	SubmissionID uuid.UUID  `json:"submission_id" db:"submission_id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	ActorID      *uuid.UUID `json:"actor_id,omitempty" db:"actor_id"`
+	Action       string     `json:"action" db:"action"` // submitted, approved, rejected
+	FromStatus   string     `json:"from_status" db:"from_status"`
+	ToStatus     string     `json:"to_status" db:"to_status"`
+	Reason       *string    `json:"reason,omitempty" db:"reason"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SubmitKYCRequest represents the multipart form for a KYC submission
+type SubmitKYCRequest struct {
+	FirstName      string                `form:"first_name" binding:"required,min=2,max=50"`
+	LastName       string                `form:"last_name" binding:"required,min=2,max=50"`
+	DateOfBirth    string                `form:"date_of_birth" binding:"required,datetime=2006-01-02"`
+	Nationality    string                `form:"nationality" binding:"required,len=2,alpha"`
+	Country        string                `form:"country" binding:"required,len=2,alpha"`
+	City           string                `form:"city" binding:"required,max=100"`
+	Address        string                `form:"address" binding:"required,max=255"`
+	PostalCode     string                `form:"postal_code" binding:"omitempty,max=20"`
+	DocumentType   string                `form:"document_type" binding:"required,oneof=passport national_id driving_license"`
+	DocumentNumber string                `form:"document_number" binding:"required,max=50"`
+	DocumentFront  *multipart.FileHeader `form:"document_front" binding:"required"`
+	DocumentBack   *multipart.FileHeader `form:"document_back"`
+	Selfie         *multipart.FileHeader `form:"selfie" binding:"required"`
+	ProofOfAddress *multipart.FileHeader `form:"proof_of_address"`
+}
+
+// ApproveKYCRequest represents the request payload for approving a KYC submission
+type ApproveKYCRequest struct {
+	Note string `json:"note" binding:"omitempty,max=500"`
+}
+
+// RejectKYCRequest represents the request payload for rejecting a KYC submission
+type RejectKYCRequest struct {
+	Reason string `json:"reason" binding:"required,min=5,max=500"` // Shown to the user
+}
@@ -0,0 +1,38 @@
+package models
+
+// KYCTierFeatures lists the features unlocked by a KYC status
+type KYCTierFeatures struct {
+	Deposit  bool `json:"deposit" db:"can_deposit"`
+	Withdraw bool `json:"withdraw" db:"can_withdraw"`
+	Transfer bool `json:"transfer" db:"can_transfer"`
+	Trade    bool `json:"trade" db:"can_trade"`
+}
+
+// LimitWindow represents a cap over one period. Values are USD strings; a nil limit means unlimited.
+type LimitWindow struct {
+	Limit           *string `json:"limit"`
+	Used            string  `json:"used"`
+	Remaining       *string `json:"remaining"`
+	RemainingAmount *string `json:"remaining_amount"` // Remaining cap in coin units at the current price
+}
+
+// LimitPeriods groups the daily (UTC day) and monthly (UTC calendar month) windows
+type LimitPeriods struct {
+	Daily   LimitWindow `json:"daily"`
+	Monthly LimitWindow `json:"monthly"`
+}
+
+// CoinLimits represents the deposit and withdrawal limits of a user for one coin
+type CoinLimits struct {
+	Coin     string       `json:"coin"`
+	Price    string       `json:"price"`
+	Withdraw LimitPeriods `json:"withdraw"`
+	Deposit  LimitPeriods `json:"deposit"`
+}
+
+// LimitsResponse represents the response for the limits endpoint
+type LimitsResponse struct {
+	KYCStatus string          `json:"kyc_status"`
+	Features  KYCTierFeatures `json:"features"`
+	Coins     []CoinLimits    `json:"coins"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MonitoringRule represents a configurable transaction or login monitoring rule
+type MonitoringRule struct {
+	ID            int       `json:"id" db:"id"`
+	Code          string    `json:"code" db:"code"`
+	Name          string    `json:"name" db:"name"`
+	Description   *string   `json:"description,omitempty" db:"description"`
+	RuleType      string    `json:"rule_type" db:"rule_type"`   // threshold, structuring, velocity, deposit_withdraw, new_device_withdrawal
+	EventType     string    `json:"event_type" db:"event_type"` // deposit, withdraw, login
+	ThresholdUSD  *string   `json:"threshold_usd,omitempty" db:"threshold_usd"`
+	WindowMinutes *int      `json:"window_minutes,omitempty" db:"window_minutes"`
+	MinCount      *int      `json:"min_count,omitempty" db:"min_count"`
+	Ratio         *string   `json:"ratio,omitempty" db:"ratio"`
+	Severity      string    `json:"severity" db:"severity"` // low, medium, high, critical
+	Action        string    `json:"action" db:"action"`     // alert, freeze
+	Enabled       bool      `json:"enabled" db:"enabled"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MonitoringAlert represents a monitoring rule hit awaiting compliance review
+type MonitoringAlert struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	RuleID         int             `json:"rule_id" db:"rule_id"`
+	RuleCode       string          `json:"rule_code" db:"rule_code"`
+	UserID         uuid.UUID       `json:"user_id" db:"user_id"`
+	TransactionID  *uuid.UUID      `json:"transaction_id,omitempty" db:"transaction_id"`
+	LoginEventID   *uuid.UUID      `json:"login_event_id,omitempty" db:"login_event_id"`
+	Severity       string          `json:"severity" db:"severity"`
+	Details        json.RawMessage `json:"details" db:"details"` // Observed values that triggered the rule
+	AccountFrozen  bool            `json:"account_frozen" db:"account_frozen"`
+	PreviousStatus *string         `json:"previous_status,omitempty" db:"previous_status"`
+	Status         string          `json:"status" db:"status"` // open, cleared, confirmed
+	ReviewedBy     *uuid.UUID      `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt     *time.Time      `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewNote     *string         `json:"review_note,omitempty" db:"review_note"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// UpdateMonitoringRuleRequest represents the request payload for changing a monitoring rule.
+// Omitted fields keep their value.
+type UpdateMonitoringRuleRequest struct {
+	ThresholdUSD  *string `json:"threshold_usd" binding:"omitempty,numeric"`
+	WindowMinutes *int    `json:"window_minutes" binding:"omitempty,min=1,max=525600"`
+	MinCount      *int    `json:"min_count" binding:"omitempty,min=1,max=10000"`
+	Ratio         *string `json:"ratio" binding:"omitempty,numeric"`
+	Severity      *string `json:"severity" binding:"omitempty,oneof=low medium high critical"`
+	Action        *string `json:"action" binding:"omitempty,oneof=alert freeze"`
+	Enabled       *bool   `json:"enabled"`
+}
+
+// ReviewMonitoringAlertRequest represents the request payload for clearing or confirming a monitoring alert
+type ReviewMonitoringAlertRequest struct {
+	Note string `json:"note" binding:"required,min=5,max=1000"`
+}
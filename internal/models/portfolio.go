@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PortfolioAsset represents the holding of one coin valued in the portfolio quote currency
+type PortfolioAsset struct {
+	CoinID            int    `json:"coin_id"`
+	Ticker            string `json:"ticker"`
+	Name              string `json:"name"`
+	Balance           string `json:"balance"`
+	FrozenBalance     string `json:"frozen_balance"`
+	Quantity          string `json:"quantity"` // Balance plus frozen balance
+	Price             string `json:"price"`
+	Value             string `json:"value"`
+	AllocationPercent string `json:"allocation_percent"`
+}
+
+// PortfolioSnapshot represents the portfolio value at the start of a day (UTC)
+type PortfolioSnapshot struct {
+	Date       string `json:"date"` // YYYY-MM-DD
+	TotalValue string `json:"total_value"`
+}
+
+// Portfolio represents the valuation of all wallets of a user
+type Portfolio struct {
+	QuoteCurrency string              `json:"quote_currency"`
+	TotalValue    string              `json:"total_value"`
+	Assets        []PortfolioAsset    `json:"assets"`    // Largest value first
+	Snapshots     []PortfolioSnapshot `json:"snapshots"` // Oldest first
+	Time          time.Time           `json:"time"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// ReferralEarning is the commission earned in one coin
+type ReferralEarning struct {
+	CoinID    int    `json:"coin_id"`
+	Ticker    string `json:"ticker"`
+	Amount    string `json:"amount"`     // Coin units
+	AmountUSD string `json:"amount_usd"` // At the coin's current price
+}
+
+// ReferralSummaryResponse represents the referral program overview of a user
+type ReferralSummaryResponse struct {
+	ReferralCode      string            `json:"referral_code"`
+	ReferralLink      string            `json:"referral_link"`
+	CommissionRate    string            `json:"commission_rate"` // Share of the referees' trading fees, e.g. 0.2000
+	ReferralCount     int               `json:"referral_count"`
+	RefereesVolumeUSD string            `json:"referees_volume_usd"` // Traded value of all referees
+	EarnedUSD         string            `json:"earned_usd"`          // All commissions at current prices
+	Earnings          []ReferralEarning `json:"earnings"`
+}
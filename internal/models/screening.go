@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScreeningCase represents a sanctions list hit that blocks an action until it is reviewed
+type ScreeningCase struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	UserID        uuid.UUID       `json:"user_id" db:"user_id"`
+	SubjectType   string          `json:"subject_type" db:"subject_type"` // registration, kyc_submission, withdrawal_address
+	SubjectID     *uuid.UUID      `json:"subject_id,omitempty" db:"subject_id"`
+	ScreenedValue string          `json:"screened_value" db:"screened_value"`
+	Matches       json.RawMessage `json:"matches" db:"matches"` // List entries that matched, best first
+	TopScore      float64         `json:"top_score" db:"top_score"`
+	Status        string          `json:"status" db:"status"` // open, cleared, confirmed
+	ReviewedBy    *uuid.UUID      `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt    *time.Time      `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewNote    *string         `json:"review_note,omitempty" db:"review_note"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// ReviewScreeningCaseRequest represents the request payload for clearing or confirming a screening case
+type ReviewScreeningCaseRequest struct {
+	Note string `json:"note" binding:"required,min=5,max=1000"`
+}
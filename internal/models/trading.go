@@ -0,0 +1,194 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Market represents a trading market/pair
+type Market struct {
+	ID                int       `json:"id" db:"id"`
+	Symbol            string    `json:"symbol" db:"symbol"` // e.g. BTC-USDT
+	BaseCoinID        int       `json:"base_coin_id" db:"base_coin_id"`
+	QuoteCoinID       int       `json:"quote_coin_id" db:"quote_coin_id"`
+	BaseCurrency      string    `json:"base_currency" db:"base_currency"`   // Base coin ticker
+	QuoteCurrency     string    `json:"quote_currency" db:"quote_currency"` // Quote coin ticker
+	IsActive          bool      `json:"is_active" db:"is_active"`
+	MinQuantity       string    `json:"min_quantity" db:"min_quantity"` // Use string for precise decimal handling
+	MaxQuantity       *string   `json:"max_quantity,omitempty" db:"max_quantity"`
+	MinNotional       string    `json:"min_notional" db:"min_notional"` // Minimum order value in the quote coin
+	PricePrecision    int       `json:"price_precision" db:"price_precision"`
+	QuantityPrecision int       `json:"quantity_precision" db:"quantity_precision"`
+	MakerFeeRate      string    `json:"maker_fee_rate" db:"maker_fee_rate"`
+	TakerFeeRate      string    `json:"taker_fee_rate" db:"taker_fee_rate"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Order represents an order of a user in a market
+type Order struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	UserID         uuid.UUID  `json:"user_id" db:"user_id"`
+	MarketID       int        `json:"market_id" db:"market_id"`
+	Market         string     `json:"market"`         // Market symbol
+	Side           string     `json:"side" db:"side"` // buy, sell
+	Type           string     `json:"type" db:"type"` // limit, market, stop_market, stop_limit, take_profit, take_profit_limit
+	Price          *string    `json:"price,omitempty" db:"price"`
+	Quantity       *string    `json:"quantity,omitempty" db:"quantity"`
+	QuoteQuantity  *string    `json:"quote_quantity,omitempty" db:"quote_quantity"` // Market buys sized by quote amount
+	FilledQuantity string     `json:"filled_quantity" db:"filled_quantity"`
+	FilledQuote    string     `json:"filled_quote" db:"filled_quote"`
+	Fee            string     `json:"fee" db:"fee"`                               // In the received coin, negative when maker rebates exceed fees
+	Reserved       string     `json:"reserved" db:"reserved"`                     // Still held in the frozen balance
+	Status         string     `json:"status" db:"status"`                         // untriggered, new, partially_filled, filled, cancelled, expired, rejected
+	RejectReason   *string    `json:"reject_reason,omitempty" db:"reject_reason"` // Reason code of a rejected order
+	TimeInForce    string     `json:"time_in_force" db:"time_in_force"`           // GTC, IOC, FOK, GTD
+	ExpireAt       *time.Time `json:"expire_at,omitempty" db:"expire_at"`         // GTD orders
+	PostOnly       bool       `json:"post_only" db:"post_only"`
+	PostOnlyMode   *string    `json:"post_only_mode,omitempty" db:"post_only_mode"`     // reject, reprice
+	SelfTrade      string     `json:"self_trade_prevention" db:"self_trade_prevention"` // none, cancel_newest, cancel_oldest, cancel_both, decrement_and_cancel
+	ClientOrderID  *string    `json:"client_order_id,omitempty" db:"client_order_id"`
+	StopPrice      *string    `json:"stop_price,omitempty" db:"stop_price"`
+	TriggerBy      *string    `json:"trigger_by,omitempty" db:"trigger_by"` // last_price, mark_price
+	TriggeredAt    *time.Time `json:"triggered_at,omitempty" db:"triggered_at"`
+	LinkedOrderID  *uuid.UUID `json:"linked_order_id,omitempty" db:"linked_order_id"` // Other leg of an OCO pair
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PlaceOrderRequest represents the request payload for placing an order. Stop and take-profit
+// orders wait until the trigger_by price reaches stop_price and then run as a limit order
+// (stop_limit, take_profit_limit) or a market order (stop_market, take_profit). An oco order
+// places a limit order at price and a stop order at stop_price (a stop_limit at
+// stop_limit_price if given, otherwise a stop_market); when one of them fills or triggers,
+// the other is cancelled.
+//
+// time_in_force is GTC (rest until cancelled, the default for limit orders), IOC (cancel what
+// does not fill at once, the default and only other choice for market orders), FOK (fill
+// completely at once or reject) or GTD (rest until expire_at). A post-only limit order never
+// takes liquidity: if it would match, it is rejected or, with post_only_mode reprice, moved
+// one price step away from the best opposite price. self_trade_prevention decides what happens
+// when the order would match another order of the same user.
+type PlaceOrderRequest struct {
+	Market         string     `json:"market" binding:"required"` // Market symbol, e.g. BTC-USDT
+	Side           string     `json:"side" binding:"required,oneof=buy sell"`
+	Type           string     `json:"type" binding:"required,oneof=limit market stop_market stop_limit take_profit take_profit_limit oco"`
+	Price          string     `json:"price" binding:"omitempty"`          // Required for limit, stop_limit, take_profit_limit and oco orders
+	Quantity       string     `json:"quantity" binding:"omitempty"`       // Base quantity
+	QuoteQuantity  string     `json:"quote_quantity" binding:"omitempty"` // Market buys only: quote amount to spend instead of quantity
+	StopPrice      string     `json:"stop_price" binding:"omitempty"`
+	StopLimitPrice string     `json:"stop_limit_price" binding:"omitempty"` // oco only
+	TriggerBy      string     `json:"trigger_by" binding:"omitempty,oneof=last_price mark_price"`
+	TimeInForce    string     `json:"time_in_force" binding:"omitempty,oneof=GTC IOC FOK GTD"`
+	ExpireAt       *time.Time `json:"expire_at" binding:"omitempty"` // GTD only
+	PostOnly       bool       `json:"post_only"`
+	PostOnlyMode   string     `json:"post_only_mode" binding:"omitempty,oneof=reject reprice"`                                                           // Default reject
+	SelfTrade      string     `json:"self_trade_prevention" binding:"omitempty,oneof=none cancel_newest cancel_oldest cancel_both decrement_and_cancel"` // Default: the account setting
+	ClientOrderID  string     `json:"client_order_id" binding:"omitempty,max=64"`
+}
+
+// SelfTradePrevention is sent on the private orders channel for every match between two orders
+// of the user that the matching engine prevented. The orders' own updates follow.
+type SelfTradePrevention struct {
+	Event          string    `json:"event"` // Always self_trade_prevented
+	Market         string    `json:"market"`
+	Mode           string    `json:"mode"` // cancel_newest, cancel_oldest, cancel_both, decrement_and_cancel
+	TakerOrderID   uuid.UUID `json:"taker_order_id"`
+	MakerOrderID   uuid.UUID `json:"maker_order_id"`
+	Price          string    `json:"price"`
+	Quantity       string    `json:"quantity"` // What would have matched
+	TakerCancelled bool      `json:"taker_cancelled"`
+	MakerCancelled bool      `json:"maker_cancelled"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TradingSettings represents the account defaults applied to new orders
+type TradingSettings struct {
+	SelfTrade string     `json:"self_trade_prevention"` // none, cancel_newest, cancel_oldest, cancel_both, decrement_and_cancel
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`  // Nil until the settings are first changed
+}
+
+// UpdateTradingSettingsRequest represents the request payload for changing the trading settings
+type UpdateTradingSettingsRequest struct {
+	SelfTrade string `json:"self_trade_prevention" binding:"required,oneof=none cancel_newest cancel_oldest cancel_both decrement_and_cancel"`
+}
+
+// OrderRejection is sent on the private orders channel when an order is rejected before it is
+// recorded. Orders rejected later (a triggered stop order) carry the code in reject_reason.
+type OrderRejection struct {
+	Status        string    `json:"status"` // Always rejected
+	Market        string    `json:"market"`
+	Side          string    `json:"side"`
+	Type          string    `json:"type"`
+	ClientOrderID *string   `json:"client_order_id,omitempty"`
+	RejectReason  string    `json:"reject_reason"`
+	Message       string    `json:"message"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PublicTrade represents an executed trade as shown to everyone
+type PublicTrade struct {
+	ID            uuid.UUID `json:"id"`
+	Market        string    `json:"market"`
+	Price         string    `json:"price"`
+	Quantity      string    `json:"quantity"`
+	QuoteQuantity string    `json:"quote_quantity"`
+	TakerSide     string    `json:"taker_side"` // buy, sell
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// OrderBookDepth represents aggregated order book levels as [price, quantity] pairs, best first.
+// In a diff, a quantity of 0 removes the level.
+type OrderBookDepth struct {
+	Market   string      `json:"market"`
+	UpdateID uint64      `json:"update_id"`
+	Tick     string      `json:"tick,omitempty"` // Price step levels are grouped by, REST snapshots only
+	Bids     [][2]string `json:"bids"`
+	Asks     [][2]string `json:"asks"`
+}
+
+// MarketTicker represents the latest prices of a market and its statistics over the last 24 hours.
+// The 24h prices are null when there were no trades in that time.
+type MarketTicker struct {
+	Market             string    `json:"market"`
+	LastPrice          *string   `json:"last_price"`
+	BestBid            *string   `json:"best_bid"`
+	BestAsk            *string   `json:"best_ask"`
+	Open24h            *string   `json:"open_24h"`
+	High24h            *string   `json:"high_24h"`
+	Low24h             *string   `json:"low_24h"`
+	Volume24h          string    `json:"volume_24h"`       // In the base coin
+	QuoteVolume24h     string    `json:"quote_volume_24h"` // In the quote coin
+	TradeCount24h      int       `json:"trade_count_24h"`
+	PriceChange24h     *string   `json:"price_change_24h"`
+	PriceChangePercent *string   `json:"price_change_percent_24h"`
+	Time               time.Time `json:"time"`
+}
+
+// MarketSummary represents a market with its ticker
+type MarketSummary struct {
+	Market
+	Ticker MarketTicker `json:"ticker"`
+}
+
+// BalanceUpdate represents the current balance of one of a user's wallets
+type BalanceUpdate struct {
+	CoinID        int    `json:"coin_id"`
+	Ticker        string `json:"ticker"`
+	Balance       string `json:"balance"`
+	FrozenBalance string `json:"frozen_balance"`
+}
+
+// Candle represents an OHLCV bar of a market. Volume is in the base coin, QuoteVolume in the quote coin.
+type Candle struct {
+	OpenTime    time.Time `json:"open_time" db:"open_time"`
+	CloseTime   time.Time `json:"close_time"` // Exclusive end of the bar
+	Open        string    `json:"open" db:"open"`
+	High        string    `json:"high" db:"high"`
+	Low         string    `json:"low" db:"low"`
+	Close       string    `json:"close" db:"close"`
+	Volume      string    `json:"volume" db:"volume"`
+	QuoteVolume string    `json:"quote_volume" db:"quote_volume"`
+	TradeCount  int       `json:"trade_count" db:"trade_count"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// TransferRequest represents the request payload for sending funds to another user
+type TransferRequest struct {
+	Coin      string `json:"coin" binding:"required"`               // Coin ticker, e.g. BTC
+	Recipient string `json:"recipient" binding:"required,max=255"`  // Email or username of the recipient
+	Amount    string `json:"amount" binding:"required"`             // Decimal string, e.g. "0.015"
+	Code      string `json:"code" binding:"required,len=6,numeric"` // 2FA OTP code
+}
@@ -0,0 +1,30 @@
+package models
+
+// AddWithdrawalAddressRequest represents the request payload for adding an address book entry
+type AddWithdrawalAddressRequest struct {
+	Coin    string `json:"coin" binding:"required"` // Coin ticker, e.g. BTC
+	Address string `json:"address" binding:"required,min=10,max=255"`
+	Memo    string `json:"memo" binding:"omitempty,max=255"`
+	Label   string `json:"label" binding:"omitempty,max=100"`
+	Code    string `json:"code" binding:"required,len=6,numeric"` // 2FA OTP code
+}
+
+// ConfirmWithdrawalAddressRequest represents the request payload for confirming an address book entry
+type ConfirmWithdrawalAddressRequest struct {
+	Token string `json:"token" binding:"required"` // Token from the confirmation email
+}
+
+// SetWithdrawalWhitelistRequest represents the request payload for toggling whitelist-only mode
+type SetWithdrawalWhitelistRequest struct {
+	Enabled bool   `json:"enabled"`
+	Code    string `json:"code" binding:"omitempty,len=6,numeric"` // 2FA OTP code, required to disable
+}
+
+// WithdrawRequest represents the request payload for creating a withdrawal
+type WithdrawRequest struct {
+	Coin    string `json:"coin" binding:"required"`
+	Address string `json:"address" binding:"required,min=10,max=255"`
+	Memo    string `json:"memo" binding:"omitempty,max=255"`
+	Amount  string `json:"amount" binding:"required"`             // Decimal string, e.g. "0.015"
+	Code    string `json:"code" binding:"required,len=6,numeric"` // 2FA OTP code
+}
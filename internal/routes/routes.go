@@ -2,29 +2,89 @@ package routes
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
 
 	"github.com/Bixor-Engine/backend/internal/handlers"
 	"github.com/Bixor-Engine/backend/internal/middleware"
+	"github.com/Bixor-Engine/backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupRoutes(db *sql.DB) *gin.Engine {
-	router := gin.Default()
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.RequestLogger(), gin.Recovery())
+
+	// Encrypted file storage for KYC documents and attachments
+	fileStorage, err := services.NewStorageService()
+	if err != nil {
+		log.Fatalf("Failed to initialize file storage: %v", err)
+	}
+
+	// Sanctions lists for screening registrations, KYC submissions and withdrawal addresses
+	screening, err := services.NewScreeningService()
+	if err != nil {
+		log.Fatalf("Failed to load sanctions lists: %v", err)
+	}
+	if stats := screening.Stats(); stats.Entries == 0 {
+		log.Printf("Warning: no sanctions lists found in %s, screening will not match anything", services.GetScreeningConfig().ListDir)
+	}
+
+	// WebSocket fan-out and the in-memory matching engine
+	streamHub := services.NewStreamHub()
+	tradingEngine := handlers.NewTradingEngine(db, streamHub)
+	tradingEngine.Start()
 
 	// Initialize handlers
 	apiHandler := handlers.NewAPIHandler()
 	healthHandler := handlers.NewHealthHandler(db)
-	authHandler := handlers.NewAuthHandler(db)
-	currencyHandler := handlers.NewCurrencyHandler(db)
+	authHandler := handlers.NewAuthHandler(db, screening)
+	coinCache := handlers.NewCoinCache(db)
+	currencyHandler := handlers.NewCurrencyHandler(db, coinCache)
 	walletHandler := handlers.NewWalletHandler(db)
+	portfolioHandler := handlers.NewPortfolioHandler(db)
 	transactionHandler := handlers.NewTransactionHandler(db)
+	withdrawalHandler := handlers.NewWithdrawalHandler(db, screening, streamHub)
+	transferHandler := handlers.NewTransferHandler(db, streamHub)
+	kycHandler := handlers.NewKYCHandler(db, fileStorage, screening)
+	limitsHandler := handlers.NewLimitsHandler(db)
+	referralHandler := handlers.NewReferralHandler(db)
+	screeningHandler := handlers.NewScreeningHandler(db, screening)
+	monitoringHandler := handlers.NewMonitoringHandler(db)
+	auditHandler := handlers.NewAuditHandler(db)
+	adminUserHandler := handlers.NewAdminUserHandler(db)
+	coinAdminHandler := handlers.NewCoinAdminHandler(db, coinCache)
+	feeAdminHandler := handlers.NewFeeAdminHandler(db)
+	fileHandler := handlers.NewFileHandler(fileStorage)
+	accountHandler := handlers.NewAccountHandler(db, fileStorage)
+	orderHandler := handlers.NewOrderHandler(db, tradingEngine)
+	marketHandler := handlers.NewMarketHandler(db, tradingEngine)
+	streamHandler := handlers.NewStreamHandler(db, streamHub, tradingEngine)
+
+	// Background jobs: data export archives and anonymization of closed accounts
+	handlers.NewAccountJobs(db, fileStorage).Start()
+
+	// Background jobs: coin prices from the configured sources
+	handlers.NewPriceOracle(db, coinCache).Start()
+
+	// Background jobs: users.global_balance and daily portfolio snapshots
+	handlers.NewPortfolioJobs(db).Start()
+
+	// Background jobs: 5m to 1w candles rebuilt from the 1m candles written with every trade
+	handlers.NewCandleAggregator(db).Start()
+
+	// Background jobs: rolling 30-day trading volume and fee tier of every user
+	handlers.NewFeeTierJobs(db).Start()
+
+	// Registered API clients (web, mobile, admin panel) for backend secret checks
+	apiClients := middleware.NewAPIClientRegistry(db)
 
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Backend-Secret, X-API-Secret")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Backend-Secret, X-API-Secret, X-Device-ID, X-Request-ID")
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -37,6 +97,9 @@ func SetupRoutes(db *sql.DB) *gin.Engine {
 	// Landing page
 	router.GET("/", apiHandler.LandingPage)
 
+	// WebSocket streams (browsers can not send the backend secret; private channels need a JWT)
+	router.GET("/ws", streamHandler.Stream)
+
 	// Load HTML templates
 	router.LoadHTMLGlob("internal/templates/*")
 
@@ -105,13 +168,25 @@ func SetupRoutes(db *sql.DB) *gin.Engine {
 				currency.GET("", currencyHandler.GetCoins)
 				currency.GET("/:ticker", currencyHandler.GetCoinByTicker)
 			}
+
+			// Market data
+			markets := public.Group("/markets")
+			{
+				markets.GET("", marketHandler.GetMarkets)
+				markets.GET("/:symbol/ticker", marketHandler.GetTicker)
+				markets.GET("/:symbol/depth", marketHandler.GetDepth)
+				markets.GET("/:symbol/candles", marketHandler.GetCandles)
+			}
+
+			// Signed file downloads (the URL signature is the credential)
+			public.GET("/files/download", fileHandler.DownloadFile)
 		}
 
 		// ============================================
 		// PROTECTED BY BACKEND SECRET - Frontend requests
 		// ============================================
 		protected := v1.Group("")
-		protected.Use(middleware.BackendSecretMiddleware())
+		protected.Use(middleware.BackendSecretMiddleware(apiClients))
 		{
 			// Authentication endpoints (frontend uses backend secret)
 			auth := protected.Group("/auth")
@@ -135,23 +210,149 @@ func SetupRoutes(db *sql.DB) *gin.Engine {
 				auth.POST("/profile/update", authHandler.UpdateProfile)
 				auth.POST("/settings/update", authHandler.UpdateSettings)
 
+				// Email change (cancel is reached from the link sent to the old address, no JWT)
+				email := auth.Group("/email")
+				{
+					email.POST("/change", authHandler.RequestEmailChange)
+					email.POST("/confirm", authHandler.ConfirmEmailChange)
+					email.POST("/cancel", authHandler.CancelEmailChange)
+				}
+
 				// Security management
 				security := auth.Group("/security")
 				{
 					security.POST("/password", authHandler.ChangePassword)
 					security.POST("/2fa", authHandler.ToggleTwoFA)
+					security.GET("/anti-phishing", authHandler.GetAntiPhishingCode)
+					security.POST("/anti-phishing", authHandler.SetAntiPhishingCode)
 				}
 
-				// Future auth endpoints will be added here
-				// auth.POST("/forgot-password", authHandler.ForgotPassword)
+				// Password reset (no JWT, also completes resets forced by an administrator)
+				auth.POST("/password/forgot", authHandler.ForgotPassword)
+				auth.POST("/password/reset", authHandler.ResetPassword)
 			}
 
+			// Withdrawal address and whitelist disable confirmations are reached from the emailed link, no JWT
+			protected.POST("/withdrawals/addresses/confirm", withdrawalHandler.ConfirmWithdrawalAddress)
+			protected.POST("/withdrawals/whitelist/confirm", withdrawalHandler.ConfirmWithdrawalWhitelistDisable)
+
 			// Authenticated User Routes (require both Secret + JWT)
 			userRoutes := protected.Group("")
 			userRoutes.Use(middleware.UserTokenMiddleware())
 			{
 				userRoutes.GET("/wallets", walletHandler.GetWallets)
+				userRoutes.GET("/portfolio", portfolioHandler.GetPortfolio)
 				userRoutes.GET("/transactions", transactionHandler.GetTransactions)
+				userRoutes.GET("/limits", limitsHandler.GetLimits)
+				userRoutes.GET("/referrals", referralHandler.GetReferrals)
+
+				// Withdrawals and the withdrawal address book
+				withdrawals := userRoutes.Group("/withdrawals")
+				{
+					withdrawals.POST("", withdrawalHandler.CreateWithdrawal)
+					withdrawals.GET("/addresses", withdrawalHandler.GetWithdrawalAddresses)
+					withdrawals.POST("/addresses", withdrawalHandler.AddWithdrawalAddress)
+					withdrawals.DELETE("/addresses/:id", withdrawalHandler.RemoveWithdrawalAddress)
+					withdrawals.POST("/whitelist", withdrawalHandler.SetWithdrawalWhitelist)
+				}
+
+				// Transfers to other users
+				userRoutes.POST("/transfers", transferHandler.CreateTransfer)
+
+				// Spot orders
+				orders := userRoutes.Group("/orders")
+				{
+					orders.POST("", orderHandler.PlaceOrder)
+					orders.GET("", orderHandler.GetOrders)
+					orders.GET("/:id", orderHandler.GetOrder)
+					orders.DELETE("/:id", orderHandler.CancelOrder)
+				}
+				userRoutes.GET("/trading/settings", orderHandler.GetTradingSettings)
+				userRoutes.PUT("/trading/settings", orderHandler.UpdateTradingSettings)
+				userRoutes.GET("/trading/fees", orderHandler.GetTradingFees)
+
+				// KYC submission
+				userRoutes.GET("/kyc", kycHandler.GetKYCStatus)
+				userRoutes.POST("/kyc", kycHandler.SubmitKYC)
+
+				// Account closure and personal data exports
+				account := userRoutes.Group("/account")
+				{
+					account.POST("/close", accountHandler.CloseAccount)
+					account.GET("/exports", accountHandler.GetDataExports)
+					account.POST("/exports", accountHandler.RequestDataExport)
+				}
+			}
+
+			// Compliance routes (require Secret + JWT + compliance role)
+			compliance := protected.Group("/compliance")
+			compliance.Use(middleware.UserTokenMiddleware(), middleware.RequireRole(db, "compliance", "admin", "superadmin"))
+			{
+				compliance.GET("/kyc", kycHandler.ListKYCSubmissions)
+				compliance.GET("/kyc/:id", kycHandler.GetKYCSubmission)
+				compliance.GET("/kyc/:id/documents/:documentId", kycHandler.GetKYCDocument)
+				compliance.POST("/kyc/:id/approve", kycHandler.ApproveKYC)
+				compliance.POST("/kyc/:id/reject", kycHandler.RejectKYC)
+
+				// Sanctions screening cases and lists
+				compliance.GET("/screening", screeningHandler.ListScreeningCases)
+				compliance.GET("/screening/lists", screeningHandler.GetScreeningLists)
+				compliance.POST("/screening/lists/reload", screeningHandler.ReloadScreeningLists)
+				compliance.GET("/screening/:id", screeningHandler.GetScreeningCase)
+				compliance.POST("/screening/:id/clear", screeningHandler.ClearScreeningCase)
+				compliance.POST("/screening/:id/confirm", screeningHandler.ConfirmScreeningCase)
+
+				// Transaction monitoring rules and alerts
+				compliance.GET("/monitoring/rules", monitoringHandler.ListMonitoringRules)
+				compliance.PUT("/monitoring/rules/:id", monitoringHandler.UpdateMonitoringRule)
+				compliance.GET("/monitoring/alerts", monitoringHandler.ListMonitoringAlerts)
+				compliance.GET("/monitoring/alerts/:id", monitoringHandler.GetMonitoringAlert)
+				compliance.POST("/monitoring/alerts/:id/clear", monitoringHandler.ClearMonitoringAlert)
+				compliance.POST("/monitoring/alerts/:id/confirm", monitoringHandler.ConfirmMonitoringAlert)
+
+				// Audit log
+				compliance.GET("/audit", auditHandler.ListAuditEvents)
+			}
+
+			// Admin routes (require Secret + JWT + a staff permission per endpoint)
+			admin := protected.Group("/admin")
+			admin.Use(middleware.UserTokenMiddleware())
+			{
+				// Freezing through /status also needs users.freeze, checked per action in the handler
+				admin.GET("/users", middleware.RequirePermission(db, "users.view"), adminUserHandler.ListUsers)
+				admin.POST("/users/test-cleanup", middleware.RequirePermission(db, "users.delete"), adminUserHandler.CleanupTestUsers)
+				admin.GET("/users/:id", middleware.RequirePermission(db, "users.view"), adminUserHandler.GetUser)
+				admin.POST("/users/:id/status", middleware.RequirePermission(db, "users.status"), adminUserHandler.ChangeUserStatus)
+				admin.POST("/users/:id/freeze", middleware.RequirePermission(db, "users.freeze"), adminUserHandler.FreezeUser)
+				admin.POST("/users/:id/password-reset", middleware.RequirePermission(db, "users.credentials"), adminUserHandler.ForcePasswordReset)
+				admin.POST("/users/:id/2fa-reset", middleware.RequirePermission(db, "users.credentials"), adminUserHandler.ResetTwoFA)
+
+				// Coin management
+				coins := admin.Group("/coins")
+				coins.Use(middleware.RequirePermission(db, "coins.manage"))
+				{
+					coins.GET("", coinAdminHandler.ListCoins)
+					coins.POST("", coinAdminHandler.CreateCoin)
+					coins.GET("/:id", coinAdminHandler.GetCoin)
+					coins.PUT("/:id", coinAdminHandler.UpdateCoin)
+					coins.POST("/:id/status", coinAdminHandler.UpdateCoinStatus)
+				}
+
+				// Trading fee tiers, schedules, user overrides and fee revenue
+				fees := admin.Group("/fees")
+				fees.Use(middleware.RequirePermission(db, "fees.manage"))
+				{
+					fees.GET("/tiers", feeAdminHandler.ListFeeTiers)
+					fees.PUT("/tiers/:tier", feeAdminHandler.UpsertFeeTier)
+					fees.DELETE("/tiers/:tier", feeAdminHandler.DeleteFeeTier)
+					fees.GET("/schedules", feeAdminHandler.ListFeeSchedules)
+					fees.PUT("/schedules/:market/:tier", feeAdminHandler.UpsertFeeSchedule)
+					fees.DELETE("/schedules/:market/:tier", feeAdminHandler.DeleteFeeSchedule)
+					fees.GET("/overrides", feeAdminHandler.ListFeeOverrides)
+					fees.POST("/overrides", feeAdminHandler.UpsertFeeOverride)
+					fees.DELETE("/overrides/:id", feeAdminHandler.DeleteFeeOverride)
+					fees.GET("/revenue", feeAdminHandler.GetFeeRevenue)
+				}
 			}
 		}
 
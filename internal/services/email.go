@@ -47,7 +47,8 @@ func GetEmailConfig() *EmailConfig {
 
 // EmailService handles email sending
 type EmailService struct {
-	config *EmailConfig
+	config           *EmailConfig
+	antiPhishingCode string
 }
 
 // NewEmailService creates a new email service instance
@@ -57,6 +58,14 @@ func NewEmailService() *EmailService {
 	}
 }
 
+// WithAntiPhishingCode returns a copy of the service that shows the recipient's
+// anti-phishing code in every email it sends
+func (es *EmailService) WithAntiPhishingCode(code string) *EmailService {
+	copied := *es
+	copied.antiPhishingCode = code
+	return &copied
+}
+
 // getTemplateFileName returns the template file name based on OTP type
 func getTemplateFileName(otpType string) string {
 	templateMap := map[string]string{
@@ -64,6 +73,7 @@ func getTemplateFileName(otpType string) string {
 		"password-reset":     "password_reset.html",
 		"2fa":                "two_factor_auth.html",
 		"phone-verification": "phone_verification.html",
+		"email-change":       "email_change.html",
 	}
 
 	if filename, ok := templateMap[otpType]; ok {
@@ -80,6 +90,7 @@ func getEmailSubject(otpType string) string {
 		"password-reset":     "Password Reset Code - Bixor Engine",
 		"2fa":                "Two-Factor Authentication Code - Bixor Engine",
 		"phone-verification": "Phone Verification Code - Bixor Engine",
+		"email-change":       "Confirm Your New Email Address - Bixor Engine",
 	}
 
 	if subject, ok := subjectMap[otpType]; ok {
@@ -90,6 +101,71 @@ func getEmailSubject(otpType string) string {
 
 // SendOTPEmail sends an OTP verification code email
 func (es *EmailService) SendOTPEmail(otpType, toEmail, toName, otpCode string) error {
+	return es.sendTemplateEmail(getTemplateFileName(otpType), getEmailSubject(otpType), toEmail, toName, map[string]interface{}{
+		"OTPCode": otpCode,
+	})
+}
+
+// SendEmailChangeNotice notifies the current address that an email change was requested
+// and gives the owner a link to cancel it
+func (es *EmailService) SendEmailChangeNotice(toEmail, toName, newEmail, cancelURL string) error {
+	return es.sendTemplateEmail("email_change_notice.html", "Email Change Requested - Bixor Engine", toEmail, toName, map[string]interface{}{
+		"NewEmail":  newEmail,
+		"CancelURL": cancelURL,
+	})
+}
+
+// SendWithdrawalAddressConfirmation asks the user to confirm a new withdrawal address book entry
+func (es *EmailService) SendWithdrawalAddressConfirmation(toEmail, toName, coin, address, memo, confirmURL, availableAt string) error {
+	return es.sendTemplateEmail("withdrawal_address_confirm.html", "Confirm New Withdrawal Address - Bixor Engine", toEmail, toName, map[string]interface{}{
+		"Coin":        coin,
+		"Address":     address,
+		"Memo":        memo,
+		"ConfirmURL":  confirmURL,
+		"AvailableAt": availableAt,
+	})
+}
+
+// SendWithdrawalWhitelistDisableConfirmation asks the user to confirm turning off whitelist-only withdrawals
+func (es *EmailService) SendWithdrawalWhitelistDisableConfirmation(toEmail, toName, confirmURL, expiresAt string, lockHours int) error {
+	return es.sendTemplateEmail("withdrawal_whitelist_disable.html", "Confirm Disabling Withdrawal Whitelist - Bixor Engine", toEmail, toName, map[string]interface{}{
+		"ConfirmURL": confirmURL,
+		"ExpiresAt":  expiresAt,
+		"LockHours":  lockHours,
+	})
+}
+
+// SendKYCDecision informs the user about the outcome of a KYC review
+func (es *EmailService) SendKYCDecision(toEmail, toName string, approved bool, reason, kycURL string) error {
+	subject := "Identity Verification Approved - Bixor Engine"
+	if !approved {
+		subject = "Identity Verification Rejected - Bixor Engine"
+	}
+	return es.sendTemplateEmail("kyc_decision.html", subject, toEmail, toName, map[string]interface{}{
+		"Approved": approved,
+		"Reason":   reason,
+		"KYCURL":   kycURL,
+	})
+}
+
+// SendAccountClosed confirms that the user closed their account
+func (es *EmailService) SendAccountClosed(toEmail, toName, closedAt, anonymizeAfter string) error {
+	return es.sendTemplateEmail("account_closed.html", "Account Closed - Bixor Engine", toEmail, toName, map[string]interface{}{
+		"ClosedAt":       closedAt,
+		"AnonymizeAfter": anonymizeAfter,
+	})
+}
+
+// SendDataExportReady tells the user that their personal data export can be downloaded
+func (es *EmailService) SendDataExportReady(toEmail, toName, exportURL, expiresAt string) error {
+	return es.sendTemplateEmail("data_export_ready.html", "Your Data Export Is Ready - Bixor Engine", toEmail, toName, map[string]interface{}{
+		"ExportURL": exportURL,
+		"ExpiresAt": expiresAt,
+	})
+}
+
+// sendTemplateEmail renders an embedded template and sends it to a single recipient
+func (es *EmailService) sendTemplateEmail(templateFile, subject, toEmail, toName string, fields map[string]interface{}) error {
 	if !es.config.Enabled {
 		return fmt.Errorf("SMTP is not enabled. Set SMTP_ENABLED=true in .env file")
 	}
@@ -100,7 +176,6 @@ func (es *EmailService) SendOTPEmail(otpType, toEmail, toName, otpCode string) e
 	}
 
 	// Load template from embedded files
-	templateFile := getTemplateFileName(otpType)
 	// Use forward slashes for embedded filesystem (works on all platforms)
 	templatePath := fmt.Sprintf("emails/templates/%s", templateFile)
 
@@ -116,18 +191,16 @@ func (es *EmailService) SendOTPEmail(otpType, toEmail, toName, otpCode string) e
 	}
 
 	// Prepare data
-	data := struct {
-		FromEmail string
-		FromName  string
-		ToEmail   string
-		ToName    string
-		OTPCode   string
-	}{
-		FromEmail: es.config.FromEmail,
-		FromName:  es.config.FromName,
-		ToEmail:   toEmail,
-		ToName:    toName,
-		OTPCode:   otpCode,
+	data := map[string]interface{}{
+		"FromEmail": es.config.FromEmail,
+		"FromName":  es.config.FromName,
+		"ToEmail":   toEmail,
+		"ToName":    toName,
+		// Lets users tell genuine emails apart from phishing (empty if not set)
+		"AntiPhishingCode": es.antiPhishingCode,
+	}
+	for key, value := range fields {
+		data[key] = value
 	}
 
 	// Execute template
@@ -142,7 +215,7 @@ func (es *EmailService) SendOTPEmail(otpType, toEmail, toName, otpCode string) e
 	// Email headers
 	headers := fmt.Sprintf("From: %s <%s>\r\n", es.config.FromName, es.config.FromEmail)
 	headers += fmt.Sprintf("To: %s <%s>\r\n", toName, toEmail)
-	headers += fmt.Sprintf("Subject: %s\r\n", getEmailSubject(otpType))
+	headers += fmt.Sprintf("Subject: %s\r\n", subject)
 	headers += "MIME-Version: 1.0\r\n"
 	headers += "Content-Type: text/html; charset=UTF-8\r\n"
 	headers += "\r\n"
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const encryptedPrefix = "v1:"
+
+var (
+	// ErrEncryptionKeyMissing is returned when DATA_ENCRYPTION_KEY is not set
+	ErrEncryptionKeyMissing = errors.New("DATA_ENCRYPTION_KEY is not configured")
+
+	// ErrInvalidCiphertext is returned when a stored value cannot be decrypted
+	ErrInvalidCiphertext = errors.New("invalid encrypted value")
+)
+
+// getEncryptionKey derives the 256-bit data encryption key from DATA_ENCRYPTION_KEY
+func getEncryptionKey() ([]byte, error) {
+	secret := os.Getenv("DATA_ENCRYPTION_KEY")
+	if secret == "" {
+		return nil, ErrEncryptionKeyMissing
+	}
+	key := sha256.Sum256([]byte(secret))
+	return key[:], nil
+}
+
+// EncryptBytes encrypts data with AES-256-GCM. The random nonce is prepended to the ciphertext.
+func EncryptBytes(plaintext []byte) ([]byte, error) {
+	key, err := getEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// DecryptBytes decrypts data produced by EncryptBytes
+func DecryptBytes(ciphertext []byte) ([]byte, error) {
+	key, err := getEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+// EncryptString encrypts a value for storage in a TEXT column
+func EncryptString(plaintext string) (string, error) {
+	sealed, err := EncryptBytes([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString decrypts a value produced by EncryptString
+func DecryptString(encoded string) (string, error) {
+	if !strings.HasPrefix(encoded, encryptedPrefix) {
+		return "", ErrInvalidCiphertext
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, encryptedPrefix))
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	plaintext, err := DecryptBytes(sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
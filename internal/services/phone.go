@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// ErrInvalidPhoneNumber is returned when a phone number cannot be normalized to E.164
+var ErrInvalidPhoneNumber = errors.New("phone number must be in international format, e.g. +14155552671")
+
+// NormalizePhoneNumber converts a phone number to E.164 format (+ followed by 8-15 digits).
+// Spaces, dashes, dots and parentheses are removed and a leading "00" is treated as "+".
+// Numbers without an international prefix use SMS_DEFAULT_COUNTRY_CODE, dropping a
+// leading trunk "0".
+func NormalizePhoneNumber(raw string) (string, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '\t':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+
+	switch {
+	case strings.HasPrefix(cleaned, "+"):
+		cleaned = cleaned[1:]
+	case strings.HasPrefix(cleaned, "00"):
+		cleaned = cleaned[2:]
+	default:
+		countryCode := strings.TrimPrefix(os.Getenv("SMS_DEFAULT_COUNTRY_CODE"), "+")
+		if countryCode == "" {
+			return "", ErrInvalidPhoneNumber
+		}
+		cleaned = countryCode + strings.TrimPrefix(cleaned, "0")
+	}
+
+	if len(cleaned) < 8 || len(cleaned) > 15 || cleaned[0] == '0' {
+		return "", ErrInvalidPhoneNumber
+	}
+	for _, r := range cleaned {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidPhoneNumber
+		}
+	}
+
+	return "+" + cleaned, nil
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPriceResponseBytes caps the body read from a price provider
+const maxPriceResponseBytes = 1 << 20
+
+// PriceOracleConfig holds price oracle configuration
+type PriceOracleConfig struct {
+	Interval     time.Duration // Pause between two price updates
+	Sources      []string      // trades, http, file
+	HTTPURLs     []string      // Index providers; {tickers} is replaced with the comma separated tickers
+	HTTPAPIKey   string        // Sent as a Bearer token
+	File         string
+	TradeMaxAge  time.Duration // Older last trades are not used as a price
+	MaxDeviation *big.Rat      // Quotes further than this fraction from the median are rejected
+	MinSources   int           // Quotes left after outlier rejection needed to update a price
+}
+
+// GetPriceOracleConfig loads price oracle configuration from environment variables
+func GetPriceOracleConfig() *PriceOracleConfig {
+	config := &PriceOracleConfig{
+		Interval:    time.Duration(positiveEnvInt("PRICE_ORACLE_INTERVAL_SECONDS", 60)) * time.Second,
+		Sources:     splitList(os.Getenv("PRICE_SOURCES")),
+		HTTPURLs:    splitList(os.Getenv("PRICE_HTTP_URLS")),
+		HTTPAPIKey:  os.Getenv("PRICE_HTTP_API_KEY"),
+		File:        os.Getenv("PRICE_FILE"),
+		TradeMaxAge: time.Duration(positiveEnvInt("PRICE_TRADE_MAX_AGE_SECONDS", 3600)) * time.Second,
+		MinSources:  positiveEnvInt("PRICE_MIN_SOURCES", 1),
+	}
+	if len(config.Sources) == 0 {
+		config.Sources = []string{"trades"}
+	}
+	if config.File == "" {
+		config.File = "data/prices.json"
+	}
+
+	deviation, err := strconv.ParseFloat(os.Getenv("PRICE_MAX_DEVIATION_PERCENT"), 64)
+	if err != nil || deviation <= 0 {
+		deviation = 5
+	}
+	config.MaxDeviation = new(big.Rat).Quo(new(big.Rat).SetFloat64(deviation), big.NewRat(100, 1))
+	return config
+}
+
+func splitList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// PriceSource provides USD prices of coins by ticker. Coins a source has no price for are
+// left out of the result.
+type PriceSource interface {
+	Name() string
+	Prices(ctx context.Context, tickers []string) (map[string]*big.Rat, error)
+}
+
+// PriceQuote is the price of a coin from one source
+type PriceQuote struct {
+	Source   string `json:"source"`
+	Price    string `json:"price"`
+	Rejected bool   `json:"rejected,omitempty"` // Outlier, not used for the price
+	value    *big.Rat
+}
+
+// NewPriceQuote creates a quote
+func NewPriceQuote(source string, price *big.Rat) PriceQuote {
+	return PriceQuote{Source: source, Price: price.FloatString(8), value: price}
+}
+
+// AggregatePrice combines the quotes of one coin: quotes further than maxDeviation (a fraction)
+// from the median of all quotes are rejected and the median of the rest is the price. ok is
+// false when fewer than minSources quotes are left. The quotes are returned with outliers marked.
+func AggregatePrice(quotes []PriceQuote, maxDeviation *big.Rat, minSources int) (*big.Rat, []PriceQuote, bool) {
+	if len(quotes) == 0 {
+		return nil, quotes, false
+	}
+
+	values := make([]*big.Rat, 0, len(quotes))
+	for _, quote := range quotes {
+		values = append(values, quote.value)
+	}
+	center := median(values)
+
+	kept := make([]*big.Rat, 0, len(quotes))
+	for i := range quotes {
+		deviation := new(big.Rat).Sub(quotes[i].value, center)
+		deviation.Abs(deviation)
+		if center.Sign() > 0 && deviation.Quo(deviation, center).Cmp(maxDeviation) > 0 {
+			quotes[i].Rejected = true
+			continue
+		}
+		kept = append(kept, quotes[i].value)
+	}
+	if len(kept) < minSources || len(kept) == 0 {
+		return nil, quotes, false
+	}
+	return median(kept), quotes, true
+}
+
+// median returns the middle value, or the mean of the two middle values
+func median(values []*big.Rat) *big.Rat {
+	sorted := append([]*big.Rat(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return new(big.Rat).Set(sorted[middle])
+	}
+	sum := new(big.Rat).Add(sorted[middle-1], sorted[middle])
+	return sum.Quo(sum, big.NewRat(2, 1))
+}
+
+// parsePriceMap decodes a JSON object of ticker to price, given as a number or a string
+func parsePriceMap(data []byte) (map[string]*big.Rat, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]*big.Rat, len(raw))
+	for ticker, value := range raw {
+		var text string
+		switch v := value.(type) {
+		case json.Number:
+			text = v.String()
+		case string:
+			text = v
+		default:
+			continue
+		}
+		price, ok := new(big.Rat).SetString(strings.TrimSpace(text))
+		if !ok || price.Sign() <= 0 {
+			continue
+		}
+		prices[strings.ToUpper(ticker)] = price
+	}
+	return prices, nil
+}
+
+// HTTPPriceSource reads prices from an index provider. The provider answers a GET request with
+// a JSON object of ticker to USD price, e.g. {"BTC": "95012.5", "ETH": 3201.2}.
+type HTTPPriceSource struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPPriceSource creates a source for an index provider URL
+func NewHTTPPriceSource(rawURL, apiKey string) *HTTPPriceSource {
+	return &HTTPPriceSource{
+		url:    rawURL,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name identifies the source by the provider's host
+func (s *HTTPPriceSource) Name() string {
+	if parsed, err := url.Parse(s.url); err == nil && parsed.Host != "" {
+		return "http:" + parsed.Host
+	}
+	return "http"
+}
+
+// Prices requests the prices of the tickers from the provider
+func (s *HTTPPriceSource) Prices(ctx context.Context, tickers []string) (map[string]*big.Rat, error) {
+	requestURL := strings.ReplaceAll(s.url, "{tickers}", url.QueryEscape(strings.Join(tickers, ",")))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create price request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request prices: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPriceResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prices: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("price provider returned status %d", resp.StatusCode)
+	}
+
+	prices, err := parsePriceMap(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode prices: %w", err)
+	}
+	return prices, nil
+}
+
+// FilePriceSource reads prices from a JSON file in the same format as the HTTP provider. It
+// stands in for real providers in development.
+type FilePriceSource struct {
+	path string
+}
+
+// NewFilePriceSource creates a source for a JSON price file
+func NewFilePriceSource(path string) *FilePriceSource {
+	return &FilePriceSource{path: path}
+}
+
+// Name identifies the source
+func (s *FilePriceSource) Name() string {
+	return "file"
+}
+
+// Prices reads the file on every call, so it can be edited while the server runs
+func (s *FilePriceSource) Prices(ctx context.Context, tickers []string) (map[string]*big.Rat, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price file: %w", err)
+	}
+	prices, err := parsePriceMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode price file %s: %w", s.path, err)
+	}
+	return prices, nil
+}
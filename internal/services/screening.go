@@ -0,0 +1,560 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxScreeningMatches caps the matches reported for one screened value
+const maxScreeningMatches = 10
+
+// digitalCurrencyAddress extracts addresses from SDN CSV remarks, e.g. "Digital Currency Address - XBT 1Abc...;"
+var digitalCurrencyAddress = regexp.MustCompile(`Digital Currency Address - ([A-Za-z0-9]+)\s+([A-Za-z0-9]+)`)
+
+// ScreeningConfig holds sanctions screening configuration
+type ScreeningConfig struct {
+	ListDir       string
+	NameThreshold float64 // Minimum similarity (0-1) for a name match
+}
+
+// GetScreeningConfig loads sanctions screening configuration from environment variables
+func GetScreeningConfig() *ScreeningConfig {
+	dir := os.Getenv("SCREENING_LIST_DIR")
+	if dir == "" {
+		dir = "data/sanctions"
+	}
+
+	threshold, err := strconv.ParseFloat(os.Getenv("SCREENING_NAME_THRESHOLD"), 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		threshold = 0.90
+	}
+
+	return &ScreeningConfig{
+		ListDir:       dir,
+		NameThreshold: threshold,
+	}
+}
+
+// ScreeningMatch is a list entry that matched a screened name or address
+type ScreeningMatch struct {
+	List      string   `json:"list"` // Source file name
+	EntryUID  string   `json:"entry_uid"`
+	EntryName string   `json:"entry_name"`
+	Matched   string   `json:"matched"` // The name, alias or address that matched
+	Score     float64  `json:"score"`
+	Programs  []string `json:"programs,omitempty"`
+}
+
+// ScreeningListStats describes the loaded lists
+type ScreeningListStats struct {
+	Files         []string  `json:"files"`
+	Entries       int       `json:"entries"`
+	Names         int       `json:"names"`
+	Addresses     int       `json:"addresses"`
+	NameThreshold float64   `json:"name_threshold"`
+	LoadedAt      time.Time `json:"loaded_at"`
+}
+
+// sanctionsEntry is one SDN entry with its primary name, aliases and digital currency addresses
+type sanctionsEntry struct {
+	List       string
+	UID        string
+	Name       string
+	Individual bool // Individual names in SDN CSV files are written "LAST, First"
+	Programs   []string
+	Aliases    []string
+	Addresses  []string
+}
+
+// screeningName is a normalized name pointing back to its entry
+type screeningName struct {
+	entry  *sanctionsEntry
+	raw    string
+	sorted string   // Tokens sorted and joined, so word order does not matter
+	tokens []string // Sorted tokens
+}
+
+// ScreeningService screens names and crypto addresses against sanctions lists in OFAC SDN
+// format (sdn.xml, or sdn.csv with alt.csv aliases) loaded from SCREENING_LIST_DIR
+type ScreeningService struct {
+	config *ScreeningConfig
+
+	mu        sync.RWMutex
+	files     []string
+	entries   []*sanctionsEntry
+	names     []screeningName
+	addresses map[string][]*sanctionsEntry
+	loadedAt  time.Time
+}
+
+// NewScreeningService creates a screening service and loads the lists. A missing list
+// directory leaves the lists empty; unreadable or malformed files are an error.
+func NewScreeningService() (*ScreeningService, error) {
+	s := &ScreeningService{config: GetScreeningConfig()}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload reads all list files again and replaces the loaded lists
+func (s *ScreeningService) Reload() error {
+	files, entries, err := loadSanctionsLists(s.config.ListDir)
+	if err != nil {
+		return err
+	}
+
+	names := []screeningName{}
+	addresses := map[string][]*sanctionsEntry{}
+	for _, entry := range entries {
+		for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+			tokens := nameTokens(name)
+			if len(tokens) == 0 {
+				continue
+			}
+			names = append(names, screeningName{entry: entry, raw: name, sorted: strings.Join(tokens, " "), tokens: tokens})
+		}
+		for _, address := range entry.Addresses {
+			key := normalizeAddress(address)
+			addresses[key] = append(addresses[key], entry)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = files
+	s.entries = entries
+	s.names = names
+	s.addresses = addresses
+	s.loadedAt = time.Now()
+	return nil
+}
+
+// Stats describes the currently loaded lists
+func (s *ScreeningService) Stats() ScreeningListStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return ScreeningListStats{
+		Files:         append([]string{}, s.files...),
+		Entries:       len(s.entries),
+		Names:         len(s.names),
+		Addresses:     len(s.addresses),
+		NameThreshold: s.config.NameThreshold,
+		LoadedAt:      s.loadedAt,
+	}
+}
+
+// ScreenName returns the entries whose name or alias is at least SCREENING_NAME_THRESHOLD similar, best first
+func (s *ScreeningService) ScreenName(name string) []ScreeningMatch {
+	tokens := nameTokens(name)
+	if len(tokens) == 0 {
+		return nil
+	}
+	sorted := strings.Join(tokens, " ")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Keep the best scoring name per entry
+	best := map[*sanctionsEntry]ScreeningMatch{}
+	for _, candidate := range s.names {
+		score := nameSimilarity(sorted, tokens, candidate.sorted, candidate.tokens)
+		if score < s.config.NameThreshold {
+			continue
+		}
+		if current, ok := best[candidate.entry]; ok && current.Score >= score {
+			continue
+		}
+		best[candidate.entry] = newScreeningMatch(candidate.entry, candidate.raw, score)
+	}
+
+	matches := make([]ScreeningMatch, 0, len(best))
+	for _, match := range best {
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].EntryUID < matches[j].EntryUID
+	})
+	if len(matches) > maxScreeningMatches {
+		matches = matches[:maxScreeningMatches]
+	}
+	return matches
+}
+
+// ScreenAddress returns the entries listing the crypto address
+func (s *ScreeningService) ScreenAddress(address string) []ScreeningMatch {
+	key := normalizeAddress(address)
+	if key == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := []ScreeningMatch{}
+	for _, entry := range s.addresses[key] {
+		matches = append(matches, newScreeningMatch(entry, strings.TrimSpace(address), 1))
+	}
+	return matches
+}
+
+func newScreeningMatch(entry *sanctionsEntry, matched string, score float64) ScreeningMatch {
+	return ScreeningMatch{
+		List:      entry.List,
+		EntryUID:  entry.UID,
+		EntryName: entry.Name,
+		Matched:   matched,
+		Score:     float64(int(score*10000)) / 10000,
+		Programs:  entry.Programs,
+	}
+}
+
+// loadSanctionsLists reads every *.xml and *.csv file in dir. CSV files whose name contains
+// "alt" are alias files (alt.csv); other CSV files are primary SDN files (sdn.csv).
+func loadSanctionsLists(dir string) ([]string, []*sanctionsEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(paths)
+
+	files := []string{}
+	entries := []*sanctionsEntry{}
+	csvEntries := map[string]*sanctionsEntry{}
+	aliasFiles := []string{}
+
+	for _, path := range paths {
+		name := filepath.Base(path)
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".xml":
+			loaded, err := loadSDNXML(path)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+			entries = append(entries, loaded...)
+		case ".csv":
+			if strings.Contains(strings.ToLower(name), "alt") {
+				aliasFiles = append(aliasFiles, path)
+				continue
+			}
+			loaded, err := loadSDNCSV(path)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+			for _, entry := range loaded {
+				csvEntries[entry.UID] = entry
+			}
+			entries = append(entries, loaded...)
+		default:
+			continue
+		}
+		files = append(files, name)
+	}
+
+	for _, path := range aliasFiles {
+		if err := loadAltCSV(path, csvEntries); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		files = append(files, filepath.Base(path))
+	}
+
+	return files, entries, nil
+}
+
+// sdnXMLList mirrors the parts of the OFAC sdn.xml schema used for screening
+type sdnXMLList struct {
+	Entries []struct {
+		UID       string   `xml:"uid"`
+		FirstName string   `xml:"firstName"`
+		LastName  string   `xml:"lastName"`
+		Programs  []string `xml:"programList>program"`
+		IDs       []struct {
+			Type   string `xml:"idType"`
+			Number string `xml:"idNumber"`
+		} `xml:"idList>id"`
+		Akas []struct {
+			FirstName string `xml:"firstName"`
+			LastName  string `xml:"lastName"`
+		} `xml:"akaList>aka"`
+	} `xml:"sdnEntry"`
+}
+
+func loadSDNXML(path string) ([]*sanctionsEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var list sdnXMLList
+	if err := xml.NewDecoder(f).Decode(&list); err != nil {
+		return nil, err
+	}
+
+	source := filepath.Base(path)
+	entries := make([]*sanctionsEntry, 0, len(list.Entries))
+	for _, e := range list.Entries {
+		entry := &sanctionsEntry{
+			List:     source,
+			UID:      e.UID,
+			Name:     joinName(e.FirstName, e.LastName),
+			Programs: e.Programs,
+		}
+		for _, aka := range e.Akas {
+			if alias := joinName(aka.FirstName, aka.LastName); alias != "" {
+				entry.Aliases = append(entry.Aliases, alias)
+			}
+		}
+		for _, id := range e.IDs {
+			if strings.HasPrefix(id.Type, "Digital Currency Address") && id.Number != "" {
+				entry.Addresses = append(entry.Addresses, id.Number)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// loadSDNCSV reads an OFAC sdn.csv file: ent_num, SDN_Name, SDN_Type, Program, Title, Call_Sign,
+// Vess_type, Tonnage, GRT, Vess_flag, Vess_owner, Remarks. "-0-" marks an empty field.
+func loadSDNCSV(path string) ([]*sanctionsEntry, error) {
+	records, err := readSDNCSV(path)
+	if err != nil {
+		return nil, err
+	}
+
+	list := filepath.Base(path)
+	entries := []*sanctionsEntry{}
+	for _, record := range records {
+		if len(record) < 2 || sdnField(record, 1) == "" {
+			continue
+		}
+		individual := strings.EqualFold(sdnField(record, 2), "individual")
+		entry := &sanctionsEntry{
+			List:       list,
+			UID:        sdnField(record, 0),
+			Name:       sdnCSVName(sdnField(record, 1), individual),
+			Individual: individual,
+		}
+		if program := sdnField(record, 3); program != "" {
+			for _, p := range strings.Split(program, "] [") {
+				entry.Programs = append(entry.Programs, strings.Trim(p, "[] "))
+			}
+		}
+		for _, m := range digitalCurrencyAddress.FindAllStringSubmatch(sdnField(record, 11), -1) {
+			entry.Addresses = append(entry.Addresses, m[2])
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// loadAltCSV reads an OFAC alt.csv file (ent_num, alt_num, alt_type, alt_name, alt_remarks)
+// and attaches the aliases to the entries loaded from the primary CSV files
+func loadAltCSV(path string, entries map[string]*sanctionsEntry) error {
+	records, err := readSDNCSV(path)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		entry, ok := entries[sdnField(record, 0)]
+		if !ok {
+			continue
+		}
+		if alias := sdnCSVName(sdnField(record, 3), entry.Individual); alias != "" {
+			entry.Aliases = append(entry.Aliases, alias)
+		}
+	}
+	return nil
+}
+
+func readSDNCSV(path string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records := [][]string{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// sdnField returns a trimmed CSV field, treating OFAC's "-0-" placeholder as empty
+func sdnField(record []string, i int) string {
+	if i >= len(record) {
+		return ""
+	}
+	value := strings.TrimSpace(strings.Trim(record[i], "\x1a"))
+	if value == "-0-" {
+		return ""
+	}
+	return value
+}
+
+// sdnCSVName turns an individual's "LAST, First" into "First LAST"
+func sdnCSVName(name string, individual bool) string {
+	if last, first, ok := strings.Cut(name, ", "); ok && individual {
+		return joinName(first, last)
+	}
+	return strings.TrimSpace(name)
+}
+
+func joinName(first, last string) string {
+	return strings.TrimSpace(strings.TrimSpace(first) + " " + strings.TrimSpace(last))
+}
+
+// nameTokens normalizes a name to lowercase ASCII words without accents or punctuation, sorted
+func nameTokens(name string) []string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), name)
+	if err != nil {
+		folded = name
+	}
+
+	tokens := strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(tokens)
+	return tokens
+}
+
+// normalizeAddress compares addresses case-insensitively (hex addresses are not case sensitive,
+// and base58/bech32 collisions that only differ in case are not a practical concern)
+func normalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// nameSimilarity scores two tokenized names from 0 to 1. It takes the better of the
+// Jaro-Winkler similarity of the sorted names and the mean best-token similarity in both
+// directions, so reordered names and small spelling differences still match.
+func nameSimilarity(a string, aTokens []string, b string, bTokens []string) float64 {
+	score := jaroWinkler(a, b)
+	if tokenScore := (bestTokenMean(aTokens, bTokens) + bestTokenMean(bTokens, aTokens)) / 2; tokenScore > score {
+		score = tokenScore
+	}
+	return score
+}
+
+// bestTokenMean averages, for each token of a, its best Jaro-Winkler similarity in b
+func bestTokenMean(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, x := range a {
+		best := 0.0
+		for _, y := range b {
+			if s := jaroWinkler(x, y); s > best {
+				best = s
+			}
+		}
+		total += best
+	}
+	return total / float64(len(a))
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of two strings
+func jaroWinkler(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ar, br := []rune(a), []rune(b)
+	if len(ar) == 0 || len(br) == 0 {
+		return 0
+	}
+
+	window := maxInt(len(ar), len(br))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	aMatched := make([]bool, len(ar))
+	bMatched := make([]bool, len(br))
+	matches := 0
+	for i := range ar {
+		start := maxInt(0, i-window)
+		end := minInt(len(br), i+window+1)
+		for j := start; j < end; j++ {
+			if bMatched[j] || ar[i] != br[j] {
+				continue
+			}
+			aMatched[i], bMatched[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range ar {
+		if !aMatched[i] {
+			continue
+		}
+		for !bMatched[j] {
+			j++
+		}
+		if ar[i] != br[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ar)) + m/float64(len(br)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < minInt(4, minInt(len(ar), len(br))) && ar[prefix] == br[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
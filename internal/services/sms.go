@@ -0,0 +1,153 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMSSender sends text messages to E.164 phone numbers
+type SMSSender interface {
+	Send(toPhone, message string) error
+}
+
+// SMSConfig holds SMS provider configuration
+type SMSConfig struct {
+	Provider string // "log" (default) or "http"
+	LogFile  string
+	HTTPURL  string
+	APIKey   string
+	From     string
+}
+
+// GetSMSConfig loads SMS configuration from environment variables
+func GetSMSConfig() *SMSConfig {
+	provider := strings.ToLower(os.Getenv("SMS_PROVIDER"))
+	if provider == "" {
+		provider = "log"
+	}
+
+	return &SMSConfig{
+		Provider: provider,
+		LogFile:  os.Getenv("SMS_LOG_FILE"),
+		HTTPURL:  os.Getenv("SMS_HTTP_URL"),
+		APIKey:   os.Getenv("SMS_API_KEY"),
+		From:     os.Getenv("SMS_FROM"),
+	}
+}
+
+// NewSMSSender creates the SMS sender selected by SMS_PROVIDER
+func NewSMSSender() SMSSender {
+	config := GetSMSConfig()
+
+	switch config.Provider {
+	case "http":
+		return NewHTTPSMSSender(config)
+	default:
+		return NewLogSMSSender(config.LogFile)
+	}
+}
+
+// LogSMSSender is a stand-in sender that writes messages to a file or the
+// standard log instead of delivering them. Useful for development and tests.
+type LogSMSSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogSMSSender creates a sender that appends to path, or prints to stdout if path is empty
+func NewLogSMSSender(path string) *LogSMSSender {
+	return &LogSMSSender{path: path}
+}
+
+// Send records the message instead of delivering it
+func (s *LogSMSSender) Send(toPhone, message string) error {
+	line := fmt.Sprintf("%s SMS to %s: %s\n", time.Now().UTC().Format(time.RFC3339), toPhone, message)
+
+	if s.path == "" {
+		fmt.Print(line)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open SMS log file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(line); err != nil {
+		return fmt.Errorf("failed to write SMS log file: %w", err)
+	}
+	return nil
+}
+
+// HTTPSMSSender delivers messages through a generic HTTP SMS gateway.
+// The gateway receives a JSON body {"from", "to", "message"} with the API key
+// as a Bearer token.
+type HTTPSMSSender struct {
+	config *SMSConfig
+	client *http.Client
+}
+
+// NewHTTPSMSSender creates a sender for an HTTP SMS gateway
+func NewHTTPSMSSender(config *SMSConfig) *HTTPSMSSender {
+	return &HTTPSMSSender{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send posts the message to the gateway
+func (s *HTTPSMSSender) Send(toPhone, message string) error {
+	if s.config.HTTPURL == "" {
+		return fmt.Errorf("SMS gateway is not configured. Set SMS_HTTP_URL in .env file")
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"from":    s.config.From,
+		"to":      toPhone,
+		"message": message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode SMS payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.config.HTTPURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("SMS gateway returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetOTPSMSMessage returns the SMS text for an OTP code
+func GetOTPSMSMessage(otpType, otpCode string) string {
+	switch otpType {
+	case "2fa":
+		return fmt.Sprintf("Bixor Engine: your two-factor authentication code is %s. It expires in 10 minutes. Never share it.", otpCode)
+	default:
+		return fmt.Sprintf("Bixor Engine: your phone verification code is %s. It expires in 10 minutes.", otpCode)
+	}
+}
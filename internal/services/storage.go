@@ -0,0 +1,335 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrBlobNotFound is returned when a stored object does not exist
+	ErrBlobNotFound = errors.New("object not found")
+
+	// ErrBlobTooLarge is returned when an upload exceeds its size limit
+	ErrBlobTooLarge = errors.New("file is too large")
+
+	// ErrBlobTypeNotAllowed is returned when the sniffed content type is not accepted
+	ErrBlobTypeNotAllowed = errors.New("file type is not allowed")
+
+	// ErrInvalidBlobKey is returned for keys that could escape the storage root
+	ErrInvalidBlobKey = errors.New("invalid object key")
+
+	// ErrInvalidSignature is returned when a signed download URL does not verify
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrSignedURLExpired is returned when a signed download URL is past its expiry
+	ErrSignedURLExpired = errors.New("download link has expired")
+)
+
+// blobKeyPattern limits keys to safe path segments
+var blobKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*(/[A-Za-z0-9][A-Za-z0-9._-]*)*$`)
+
+// BlobStore stores raw objects by key. Implementations do not interpret the content.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// StorageConfig holds file storage configuration
+type StorageConfig struct {
+	Driver         string // "local" (default) or "s3"
+	LocalDir       string
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3PathStyle    bool
+	MaxUploadBytes int64
+	SigningKey     string
+	PublicURL      string // Base URL of this API, used for signed download links
+	URLTTL         time.Duration
+}
+
+// GetStorageConfig loads file storage configuration from environment variables
+func GetStorageConfig() *StorageConfig {
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+	if driver == "" {
+		driver = "local"
+	}
+
+	localDir := os.Getenv("STORAGE_LOCAL_DIR")
+	if localDir == "" {
+		localDir = "storage/files"
+	}
+
+	region := os.Getenv("S3_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	maxMB, err := strconv.Atoi(os.Getenv("STORAGE_MAX_UPLOAD_MB"))
+	if err != nil || maxMB <= 0 {
+		maxMB = 20 // Default 20 MB
+	}
+
+	ttlSeconds, err := strconv.Atoi(os.Getenv("STORAGE_URL_TTL_SECONDS"))
+	if err != nil || ttlSeconds <= 0 {
+		ttlSeconds = 300 // Default 5 minutes
+	}
+
+	publicURL := os.Getenv("API_PUBLIC_URL")
+	if publicURL == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		publicURL = "http://localhost:" + port
+	}
+
+	pathStyle := os.Getenv("S3_USE_PATH_STYLE")
+
+	return &StorageConfig{
+		Driver:         driver,
+		LocalDir:       localDir,
+		S3Endpoint:     os.Getenv("S3_ENDPOINT"),
+		S3Region:       region,
+		S3Bucket:       os.Getenv("S3_BUCKET"),
+		S3AccessKey:    os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:    os.Getenv("S3_SECRET_KEY"),
+		S3PathStyle:    pathStyle == "" || pathStyle == "true" || pathStyle == "1",
+		MaxUploadBytes: int64(maxMB) << 20,
+		SigningKey:     os.Getenv("STORAGE_SIGNING_KEY"),
+		PublicURL:      strings.TrimRight(publicURL, "/"),
+		URLTTL:         time.Duration(ttlSeconds) * time.Second,
+	}
+}
+
+// NewBlobStore creates the store selected by STORAGE_DRIVER
+func NewBlobStore(config *StorageConfig) (BlobStore, error) {
+	switch config.Driver {
+	case "local":
+		return NewLocalBlobStore(config.LocalDir), nil
+	case "s3":
+		return NewS3BlobStore(config)
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", config.Driver)
+	}
+}
+
+// UploadOptions restricts what an upload may contain
+type UploadOptions struct {
+	MaxBytes     int64             // 0 uses STORAGE_MAX_UPLOAD_MB
+	AllowedTypes map[string]string // Sniffed content type -> file extension
+}
+
+// StoredObject describes a file saved through the StorageService
+type StoredObject struct {
+	Key         string
+	ContentType string
+	Size        int64 // Size of the plaintext
+	SHA256      string
+}
+
+// StorageService stores user files (KYC documents, attachments) encrypted at rest and
+// hands out time-limited signed download URLs
+type StorageService struct {
+	store  BlobStore
+	config *StorageConfig
+}
+
+// NewStorageService creates a storage service from environment configuration
+func NewStorageService() (*StorageService, error) {
+	config := GetStorageConfig()
+	store, err := NewBlobStore(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewStorageServiceWithStore(store, config), nil
+}
+
+// NewStorageServiceWithStore creates a storage service on top of an existing store
+func NewStorageServiceWithStore(store BlobStore, config *StorageConfig) *StorageService {
+	return &StorageService{store: store, config: config}
+}
+
+// Save validates, encrypts and stores an upload below prefix. The content type is
+// sniffed from the data; the client supplied type and file name are not trusted.
+func (s *StorageService) Save(ctx context.Context, prefix string, r io.Reader, opts UploadOptions) (*StoredObject, error) {
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 || maxBytes > s.config.MaxUploadBytes {
+		maxBytes = s.config.MaxUploadBytes
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrBlobTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := opts.AllowedTypes[contentType]
+	if !ok {
+		return nil, ErrBlobTypeNotAllowed
+	}
+
+	key := strings.Trim(prefix, "/") + "/" + uuid.New().String() + ext
+	if !blobKeyPattern.MatchString(key) {
+		return nil, ErrInvalidBlobKey
+	}
+
+	sealed, err := EncryptBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Put(ctx, key, sealed, "application/octet-stream"); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return &StoredObject{
+		Key:         key,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// SaveGenerated encrypts and stores content produced by the server (for example data
+// exports) below prefix. Unlike Save it applies no upload size or type checks.
+func (s *StorageService) SaveGenerated(ctx context.Context, prefix, ext string, data []byte) (*StoredObject, error) {
+	key := strings.Trim(prefix, "/") + "/" + uuid.New().String() + ext
+	if !blobKeyPattern.MatchString(key) {
+		return nil, ErrInvalidBlobKey
+	}
+
+	sealed, err := EncryptBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Put(ctx, key, sealed, "application/octet-stream"); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return &StoredObject{
+		Key:         key,
+		ContentType: http.DetectContentType(data),
+		Size:        int64(len(data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// Load reads and decrypts a stored object
+func (s *StorageService) Load(ctx context.Context, key string) ([]byte, error) {
+	if !blobKeyPattern.MatchString(key) {
+		return nil, ErrInvalidBlobKey
+	}
+
+	sealed, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecryptBytes(sealed)
+}
+
+// Open returns a reader over the decrypted object
+func (s *StorageService) Open(ctx context.Context, key string) (io.ReadSeeker, int64, error) {
+	data, err := s.Load(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// Delete removes a stored object
+func (s *StorageService) Delete(ctx context.Context, key string) error {
+	if !blobKeyPattern.MatchString(key) {
+		return ErrInvalidBlobKey
+	}
+	return s.store.Delete(ctx, key)
+}
+
+// SignedURL returns a download URL for key that expires after STORAGE_URL_TTL_SECONDS
+func (s *StorageService) SignedURL(key, filename string) (string, time.Time, error) {
+	signingKey, err := s.signingKey()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(s.config.URLTTL).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("key", key)
+	query.Set("filename", filename)
+	query.Set("expires", expires)
+	query.Set("signature", signDownload(signingKey, key, filename, expires))
+
+	return s.config.PublicURL + "/api/v1/files/download?" + query.Encode(), expiresAt, nil
+}
+
+// VerifySignedURL checks the parameters of a signed download URL
+func (s *StorageService) VerifySignedURL(key, filename, expires, signature string) error {
+	signingKey, err := s.signingKey()
+	if err != nil {
+		return err
+	}
+
+	expected := signDownload(signingKey, key, filename, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if time.Now().After(time.Unix(unix, 0)) {
+		return ErrSignedURLExpired
+	}
+
+	return nil
+}
+
+// signingKey returns STORAGE_SIGNING_KEY, or a key derived from DATA_ENCRYPTION_KEY
+func (s *StorageService) signingKey() ([]byte, error) {
+	if s.config.SigningKey != "" {
+		return []byte(s.config.SigningKey), nil
+	}
+
+	key, err := getEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("storage-download-urls"))
+	return mac.Sum(nil), nil
+}
+
+// signDownload computes the HMAC-SHA256 signature of a download URL
+func signDownload(signingKey []byte, key, filename, expires string) string {
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte(key + "\n" + filename + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// LocalBlobStore stores objects as files below a root directory
+type LocalBlobStore struct {
+	root string
+}
+
+// NewLocalBlobStore creates a store rooted at dir. The directory is created on first write.
+func NewLocalBlobStore(dir string) *LocalBlobStore {
+	return &LocalBlobStore{root: dir}
+}
+
+// Put writes the object atomically so readers never see a partial file
+func (s *LocalBlobStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get reads the object
+func (s *LocalBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return data, err
+}
+
+// Delete removes the object. Deleting a missing object is not an error.
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path maps a key to a file below the root
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if !blobKeyPattern.MatchString(key) {
+		return "", ErrInvalidBlobKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
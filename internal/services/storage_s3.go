@@ -0,0 +1,175 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3BlobStore stores objects in an S3-compatible bucket (AWS S3, MinIO, Ceph, R2, ...).
+// Requests are signed with AWS Signature Version 4.
+type S3BlobStore struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	client    *http.Client
+}
+
+// NewS3BlobStore creates an S3 store from the storage configuration
+func NewS3BlobStore(config *StorageConfig) (*S3BlobStore, error) {
+	if config.S3Endpoint == "" || config.S3Bucket == "" || config.S3AccessKey == "" || config.S3SecretKey == "" {
+		return nil, errors.New("S3 storage requires S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY")
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(config.S3Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT %q", config.S3Endpoint)
+	}
+
+	return &S3BlobStore{
+		endpoint:  endpoint,
+		region:    config.S3Region,
+		bucket:    config.S3Bucket,
+		accessKey: config.S3AccessKey,
+		secretKey: config.S3SecretKey,
+		pathStyle: config.S3PathStyle,
+		client:    &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Put uploads the object
+func (s *S3BlobStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError(resp)
+	}
+	return nil
+}
+
+// Get downloads the object
+func (s *S3BlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrBlobNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, s.responseError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Delete removes the object. S3 treats deleting a missing object as success.
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return s.responseError(resp)
+	}
+	return nil
+}
+
+// do sends a signed request for an object
+func (s *S3BlobStore) do(ctx context.Context, method, key string, body []byte, contentType string) (*http.Response, error) {
+	if !blobKeyPattern.MatchString(key) {
+		return nil, ErrInvalidBlobKey
+	}
+
+	objectURL := *s.endpoint
+	if s.pathStyle {
+		objectURL.Path = s.endpoint.Path + "/" + s.bucket + "/" + key
+	} else {
+		objectURL.Host = s.bucket + "." + s.endpoint.Host
+		objectURL.Path = s.endpoint.Path + "/" + key
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, objectURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.ContentLength = int64(len(body))
+
+	s.sign(req, body, time.Now().UTC())
+
+	return s.client.Do(req)
+}
+
+// sign adds AWS Signature Version 4 headers to the request
+func (s *S3BlobStore) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256.Sum256(body)
+	payloadHex := hex.EncodeToString(payloadHash[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHex)
+
+	// Keys only contain unreserved characters and "/", so the path needs no further encoding
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHex + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHex,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// responseError turns an unexpected S3 response into an error
+func (s *S3BlobStore) responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrTooManySubscriptions is returned when a client subscribes to more channels than allowed
+var ErrTooManySubscriptions = errors.New("too many subscriptions")
+
+// CloseReasonSlowConsumer is the close reason of connections that kept falling behind
+const CloseReasonSlowConsumer = "slow consumer"
+
+// StreamConfig holds WebSocket stream configuration
+type StreamConfig struct {
+	SendBuffer       int           // Messages queued per connection before updates are dropped
+	MaxDropped       int           // Consecutive dropped messages before a slow connection is closed
+	MaxSubscriptions int           // Channels one connection can subscribe to
+	Heartbeat        time.Duration // Interval of heartbeat messages and pings
+}
+
+// GetStreamConfig loads WebSocket stream configuration from environment variables
+func GetStreamConfig() *StreamConfig {
+	return &StreamConfig{
+		SendBuffer:       positiveEnvInt("STREAM_SEND_BUFFER", 256),
+		MaxDropped:       positiveEnvInt("STREAM_MAX_DROPPED", 64),
+		MaxSubscriptions: positiveEnvInt("STREAM_MAX_SUBSCRIPTIONS", 50),
+		Heartbeat:        time.Duration(positiveEnvInt("STREAM_HEARTBEAT_SECONDS", 15)) * time.Second,
+	}
+}
+
+func positiveEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// StreamMessage is one message on a channel. Seq increases by one with every update published
+// on the channel, so a client that sees a jump has missed updates and should resync.
+type StreamMessage struct {
+	Channel string      `json:"channel"`
+	Seq     uint64      `json:"seq"`
+	Type    string      `json:"type"` // snapshot, update
+	Data    interface{} `json:"data"`
+}
+
+// streamTopic is a channel instance with its subscribers. Public channels have one topic
+// per name (trades:BTC-USDT); private channels have one topic per user.
+type streamTopic struct {
+	seq     uint64
+	clients map[*StreamClient]struct{}
+}
+
+// StreamHub fans out market data and account updates to WebSocket connections
+type StreamHub struct {
+	config *StreamConfig
+	mu     sync.Mutex
+	topics map[string]*streamTopic
+}
+
+// NewStreamHub creates a new stream hub
+func NewStreamHub() *StreamHub {
+	return &StreamHub{
+		config: GetStreamConfig(),
+		topics: make(map[string]*streamTopic),
+	}
+}
+
+// Config returns the stream configuration
+func (h *StreamHub) Config() *StreamConfig {
+	return h.config
+}
+
+// PrivateTopic returns the topic of a user's private channel
+func PrivateTopic(channel string, userID uuid.UUID) string {
+	return channel + "@" + userID.String()
+}
+
+// StreamClient is the send side of one connection. Messages are queued without blocking the
+// publisher; when the queue is full they are dropped (the client sees a seq gap), and a
+// connection that keeps falling behind is closed.
+type StreamClient struct {
+	hub     *StreamHub
+	send    chan []byte
+	done    chan struct{}
+	mu      sync.Mutex
+	topics  map[string]bool
+	dropped int
+	closed  bool
+	reason  string
+}
+
+// NewClient registers a new connection
+func (h *StreamHub) NewClient() *StreamClient {
+	return &StreamClient{
+		hub:    h,
+		send:   make(chan []byte, h.config.SendBuffer),
+		done:   make(chan struct{}),
+		topics: make(map[string]bool),
+	}
+}
+
+// Send returns the queue of encoded messages to write to the connection
+func (c *StreamClient) Send() <-chan []byte {
+	return c.send
+}
+
+// Done is closed when the hub gives up on the connection
+func (c *StreamClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// CloseReason explains why the hub closed the connection
+func (c *StreamClient) CloseReason() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reason
+}
+
+// Enqueue queues an encoded message. It returns false if the message was dropped.
+func (c *StreamClient) Enqueue(message []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+
+	select {
+	case c.send <- message:
+		c.dropped = 0
+		return true
+	default:
+		c.dropped++
+		if c.dropped >= c.hub.config.MaxDropped {
+			c.closeLocked(CloseReasonSlowConsumer)
+		}
+		return false
+	}
+}
+
+// Close stops delivery to the connection
+func (c *StreamClient) Close(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked(reason)
+}
+
+func (c *StreamClient) closeLocked(reason string) {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.reason = reason
+	close(c.done)
+}
+
+// Subscribe adds the client to a topic and returns the topic's current seq. If snapshot is not
+// nil it is queued as a snapshot message with that seq, ahead of any later update. Callers that
+// publish under their own lock must build the snapshot and subscribe under the same lock.
+func (h *StreamHub) Subscribe(c *StreamClient, topic, channel string, snapshot interface{}) (uint64, error) {
+	var encoded []byte
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c.mu.Lock()
+	if !c.topics[topic] && len(c.topics) >= h.config.MaxSubscriptions {
+		c.mu.Unlock()
+		return 0, ErrTooManySubscriptions
+	}
+	c.topics[topic] = true
+	c.mu.Unlock()
+
+	t := h.topics[topic]
+	if t == nil {
+		t = &streamTopic{clients: make(map[*StreamClient]struct{})}
+		h.topics[topic] = t
+	}
+	t.clients[c] = struct{}{}
+
+	if snapshot != nil {
+		var err error
+		encoded, err = json.Marshal(StreamMessage{Channel: channel, Seq: t.seq, Type: "snapshot", Data: snapshot})
+		if err != nil {
+			return 0, err
+		}
+		c.Enqueue(encoded)
+	}
+	return t.seq, nil
+}
+
+// Unsubscribe removes the client from a topic
+func (h *StreamHub) Unsubscribe(c *StreamClient, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c.mu.Lock()
+	delete(c.topics, topic)
+	c.mu.Unlock()
+	h.removeLocked(c, topic)
+}
+
+// Remove unsubscribes the client from everything and stops delivery
+func (h *StreamHub) Remove(c *StreamClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c.mu.Lock()
+	topics := c.topics
+	c.topics = make(map[string]bool)
+	c.mu.Unlock()
+	for topic := range topics {
+		h.removeLocked(c, topic)
+	}
+	c.Close("connection closed")
+}
+
+// Subscribed reports whether the client is subscribed to a topic
+func (h *StreamHub) Subscribed(c *StreamClient, topic string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.topics[topic]
+}
+
+func (h *StreamHub) removeLocked(c *StreamClient, topic string) {
+	t := h.topics[topic]
+	if t == nil {
+		return
+	}
+	delete(t.clients, c)
+	if len(t.clients) == 0 {
+		// The seq restarts when the topic is used again; subscribers get it with the ack
+		delete(h.topics, topic)
+	}
+}
+
+// Publish sends an update to every subscriber of a public channel
+func (h *StreamHub) Publish(channel string, data interface{}) {
+	h.publish(channel, channel, "update", data)
+}
+
+// PublishSnapshot sends a full state message, e.g. after an order book was rebuilt
+func (h *StreamHub) PublishSnapshot(channel string, data interface{}) {
+	h.publish(channel, channel, "snapshot", data)
+}
+
+// PublishPrivate sends an update on a private channel of one user
+func (h *StreamHub) PublishPrivate(userID uuid.UUID, channel string, data interface{}) {
+	h.publish(PrivateTopic(channel, userID), channel, "update", data)
+}
+
+// HasSubscribers reports whether anyone listens to a topic, so callers can skip building updates
+func (h *StreamHub) HasSubscribers(topic string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.topics[topic] != nil
+}
+
+func (h *StreamHub) publish(topic, channel, messageType string, data interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topics[topic]
+	if t == nil {
+		return
+	}
+	t.seq++
+
+	// Encoded once for all subscribers
+	encoded, err := json.Marshal(StreamMessage{Channel: channel, Seq: t.seq, Type: messageType, Data: data})
+	if err != nil {
+		return
+	}
+	for c := range t.clients {
+		c.Enqueue(encoded)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Bixor-Engine/backend/internal/services"
+)
+
+var (
+	driver  = flag.String("driver", "", "Storage driver to test (local or s3, default from STORAGE_DRIVER)")
+	verbose = flag.Bool("v", false, "Verbose output")
+)
+
+// A minimal valid PNG header, enough for content-type sniffing
+var pngSample = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+var allowedTypes = map[string]string{"image/png": ".png"}
+
+func main() {
+	flag.Parse()
+
+	config := services.GetStorageConfig()
+	if *driver != "" {
+		config.Driver = *driver
+	}
+
+	fmt.Println("==========================================")
+	fmt.Println("File Storage Test")
+	fmt.Println("==========================================")
+	fmt.Printf("Driver: %s\n", config.Driver)
+	if config.Driver == "s3" {
+		fmt.Printf("Endpoint: %s (bucket %s)\n", config.S3Endpoint, config.S3Bucket)
+	} else {
+		fmt.Printf("Directory: %s\n", config.LocalDir)
+	}
+	fmt.Println()
+
+	store, err := services.NewBlobStore(config)
+	if err != nil {
+		fmt.Printf("❌ ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	storage := services.NewStorageServiceWithStore(store, config)
+	ctx := context.Background()
+
+	passed, failed := 0, 0
+	check := func(name string, ok bool, detail string) {
+		if ok {
+			passed++
+			fmt.Printf("✅ %s - PASS\n", name)
+		} else {
+			failed++
+			fmt.Printf("❌ %s - FAIL (%s)\n", name, detail)
+		}
+	}
+
+	// Round trip
+	object, err := storage.Save(ctx, "storage-check", bytes.NewReader(pngSample), services.UploadOptions{AllowedTypes: allowedTypes})
+	check("Save", err == nil, fmt.Sprint(err))
+	if err != nil {
+		fmt.Println("\nCannot continue without a stored object.")
+		os.Exit(1)
+	}
+	if *verbose {
+		fmt.Printf("   key=%s type=%s size=%d sha256=%s\n", object.Key, object.ContentType, object.Size, object.SHA256)
+	}
+
+	raw, err := store.Get(ctx, object.Key)
+	check("Encrypted at rest", err == nil && !bytes.Equal(raw, pngSample), fmt.Sprint(err))
+
+	data, err := storage.Load(ctx, object.Key)
+	check("Load", err == nil && bytes.Equal(data, pngSample), fmt.Sprint(err))
+
+	// Validation
+	_, err = storage.Save(ctx, "storage-check", bytes.NewReader([]byte("plain text")), services.UploadOptions{AllowedTypes: allowedTypes})
+	check("Reject disallowed type", err == services.ErrBlobTypeNotAllowed, fmt.Sprint(err))
+
+	_, err = storage.Save(ctx, "storage-check", bytes.NewReader(pngSample), services.UploadOptions{MaxBytes: 8, AllowedTypes: allowedTypes})
+	check("Reject oversized file", err == services.ErrBlobTooLarge, fmt.Sprint(err))
+
+	_, err = storage.Load(ctx, "../etc/passwd")
+	check("Reject path traversal", err == services.ErrInvalidBlobKey, fmt.Sprint(err))
+
+	// Signed URLs
+	signedURL, _, err := storage.SignedURL(object.Key, "check.png")
+	check("Sign URL", err == nil, fmt.Sprint(err))
+	if err == nil {
+		if *verbose {
+			fmt.Printf("   %s\n", signedURL)
+		}
+		u, _ := url.Parse(signedURL)
+		q := u.Query()
+		err = storage.VerifySignedURL(q.Get("key"), q.Get("filename"), q.Get("expires"), q.Get("signature"))
+		check("Verify signed URL", err == nil, fmt.Sprint(err))
+
+		err = storage.VerifySignedURL(q.Get("key"), "other.png", q.Get("expires"), q.Get("signature"))
+		check("Reject tampered URL", err == services.ErrInvalidSignature, fmt.Sprint(err))
+	}
+
+	expiredConfig := *config
+	expiredConfig.URLTTL = -time.Minute
+	expired := services.NewStorageServiceWithStore(store, &expiredConfig)
+	if expiredURL, _, err := expired.SignedURL(object.Key, "check.png"); err == nil {
+		u, _ := url.Parse(expiredURL)
+		q := u.Query()
+		err = storage.VerifySignedURL(q.Get("key"), q.Get("filename"), q.Get("expires"), q.Get("signature"))
+		check("Reject expired URL", err == services.ErrSignedURLExpired, fmt.Sprint(err))
+	}
+
+	// Cleanup
+	err = storage.Delete(ctx, object.Key)
+	check("Delete", err == nil, fmt.Sprint(err))
+
+	_, err = storage.Load(ctx, object.Key)
+	check("Deleted object is gone", err == services.ErrBlobNotFound, fmt.Sprint(err))
+
+	fmt.Println()
+	fmt.Println("==========================================")
+	fmt.Println("Test Summary")
+	fmt.Println("==========================================")
+	fmt.Printf("✅ Passed: %d\n", passed)
+	fmt.Printf("❌ Failed: %d\n", failed)
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}